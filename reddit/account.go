@@ -1,5 +1,14 @@
 package reddit
 
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Account defines behaviors only an account can perform on Reddit.
 type Account interface {
 	// Reply posts a reply to something on reddit. The behavior depends on
@@ -13,6 +22,18 @@ type Account interface {
 	// name.
 	Reply(parentName, text string) error
 	GetReply(parentName, text string) (Submission, error)
+	// ReplyRichText is GetReply with a body in Reddit's rich text format
+	// instead of markdown. The comment limit applies to the body as it is
+	// sent, in json.
+	ReplyRichText(parentName string, body *RichText) (Submission, error)
+	// ReplyWithOptions is GetReply with optional settings for the reply.
+	ReplyWithOptions(parentName, text string, opts ReplyOptions) (
+		Submission,
+		error,
+	)
+	// SetSendReplies sets whether replies to the bot's post or comment
+	// with the given full name are sent to its inbox.
+	SetSendReplies(name string, enabled bool) error
 
 	// SendMessage sends a private message to a user.
 	SendMessage(user, subject, text string) error
@@ -20,26 +41,243 @@ type Account interface {
 	// PostSelf makes a text (self) post to a subreddit.
 	PostSelf(subreddit, title, text string) error
 	GetPostSelf(subreddit, title, text string) (Submission, error)
+	// PostSelfWithOptions is GetPostSelf with the optional settings of a
+	// submission, such as flair or NSFW.
+	PostSelfWithOptions(
+		subreddit, title, text string,
+		opts SubmitOptions,
+	) (Submission, error)
 
 	// PostLink makes a link post to a subreddit.
 	PostLink(subreddit, title, url string) error
 	GetPostLink(subreddit, title, url string) (Submission, error)
+	// PostLinkWithOptions is GetPostLink with the optional settings of a
+	// submission, such as flair or NSFW.
+	PostLinkWithOptions(
+		subreddit, title, url string,
+		opts SubmitOptions,
+	) (Submission, error)
+
+	// UserFlair returns a user's flair in a subreddit, or nil if they have
+	// none. It needs the "flair" scope, which is only asked for if it is in
+	// App.Scopes.
+	UserFlair(subreddit, user string) (*Flair, error)
+
+	// QuarantineOptIn opts the account in to viewing a quarantined
+	// subreddit. Reads of such subreddits fail with a QuarantinedError
+	// until it does.
+	QuarantineOptIn(subreddit string) error
+
+	// SubredditSettings returns the settings of a subreddit the account
+	// moderates. It and UpdateSubredditSettings need the "modconfig"
+	// scope, which is only asked for if it is in App.Scopes.
+	SubredditSettings(subreddit string) (*SubredditSettings, error)
+	// UpdateSubredditSettings changes the settings of a subreddit the
+	// account moderates. Only the settings which are set are changed.
+	UpdateSubredditSettings(subreddit string, settings SubredditSettings) error
+
+	// Saved returns the posts and comments the account has saved, most
+	// recently saved first. The params are listing parameters as in the
+	// Scanner's ListingWithParams; the harvest's After is the "after"
+	// parameter for the next page.
+	Saved(params map[string]string) (Harvest, error)
+
+	// ModeratedSubreddits returns every subreddit the account moderates,
+	// with its moderator permissions in each, following the listing's
+	// pages.
+	ModeratedSubreddits() ([]*SubredditDetail, error)
+
+	// Me returns the account's profile.
+	Me() (*User, error)
+
+	// KarmaBreakdown returns the account's karma in each subreddit it has
+	// earned karma in. It needs the "mysubreddits" scope, which is only
+	// asked for if it is in App.Scopes.
+	KarmaBreakdown() ([]*SubredditKarma, error)
+	// Multireddits returns the account's multireddits, or none if it has
+	// not made any.
+	Multireddits() ([]*Multi, error)
+	// NeedsCaptcha returns whether Reddit wants a captcha with the
+	// account's submissions. It is false for script apps.
+	NeedsCaptcha() (bool, error)
+
+	// SetUserFlair sets a user's flair in a subreddit the account
+	// moderates.
+	SetUserFlair(subreddit, user, text, cssClass string) error
+	// DeleteUserFlair removes a user's flair in a subreddit the account
+	// moderates.
+	DeleteUserFlair(subreddit, user string) error
+	// BulkFlairCSV sets the flair of many users in a subreddit the account
+	// moderates, returning Reddit's result for each row in order. Reddit
+	// takes 100 rows at a time, so larger updates take several requests;
+	// if one fails, the results of the rows before it are returned with
+	// the error.
+	BulkFlairCSV(subreddit string, rows []FlairRow) ([]FlairResult, error)
+
+	// VotePoll votes for an option, by its id, in the poll of a post, by
+	// its full name. It returns NotPollErr if the post is not a poll and
+	// PollClosedErr if voting in it has closed.
+	VotePoll(postName, optionID string) error
+
+	// AddToCollection adds a post, by its full name, to a collection in a
+	// subreddit the account moderates. It needs the "modposts" scope, which
+	// is only asked for if it is in App.Scopes.
+	AddToCollection(collectionID, postName string) error
+
+	// GiveAward gives a post or comment, by its full name, the award with
+	// the given id, e.g. "gid_1" or "award_...", with the account's
+	// coins. It returns InsufficientCoinsErr if the account cannot afford
+	// it.
+	GiveAward(name, awardID string) error
+
+	// SetSticky stickies or unstickies a post, by its full name, in its
+	// subreddit. A subreddit has two sticky slots, 1 and 2; stickying a
+	// post in a used slot replaces the post there. It returns
+	// StickyConflictErr if Reddit refuses the change.
+	SetSticky(postName string, slot int, state bool) error
+}
+
+// SubmitOptions are the optional settings of a post. The zero value uses
+// Reddit's defaults for all of them.
+type SubmitOptions struct {
+	// FlairID is the id of the subreddit's flair template to flair the
+	// post with. If the subreddit does not offer it, Reddit refuses the
+	// post and the error is returned.
+	FlairID string
+	// FlairText is the text of the flair, for templates which allow
+	// editing it. It requires FlairID.
+	FlairText string
+
+	// NSFW marks the post as not safe for work.
+	NSFW bool
+	// Spoiler marks the post as a spoiler.
+	Spoiler bool
+	// OC marks the post as original content.
+	OC bool
+	// DisableInboxReplies stops replies to the post from being sent to
+	// the account's inbox.
+	DisableInboxReplies bool
+
+	// RichText, if set, is the body of a self post in Reddit's rich text
+	// format, sent instead of its markdown text. It is invalid for link
+	// posts.
+	RichText *RichText
+}
+
+// ReplyOptions are the optional settings of a reply. The zero value uses
+// Reddit's defaults for all of them.
+type ReplyOptions struct {
+	// DisableInboxReplies stops replies to the comment from being sent to
+	// the account's inbox. Reddit takes this after the comment is made, so
+	// it costs a second request. It does not apply to replies to messages.
+	DisableInboxReplies bool
+}
+
+// errFlairTextWithoutID is returned for options with flair text but no flair
+// template to apply it to.
+var errFlairTextWithoutID = fmt.Errorf("flair text requires a flair id")
+
+// errRichTextLink is returned for link posts with a rich text body.
+var errRichTextLink = fmt.Errorf("rich text is only for self posts")
+
+// errNoAccount is returned for requests about the bot's account by apps which
+// do not log in to one.
+var errNoAccount = fmt.Errorf("the app is not logged in to an account")
+
+// maxFlairCSVRows is the most rows Reddit takes in one bulk flair update.
+const maxFlairCSVRows = 100
+
+// errStickySlot is returned for sticky slots other than Reddit's two.
+var errStickySlot = fmt.Errorf("sticky slot must be 1 or 2")
+
+// values adds the form values of the options to a submission's values,
+// encoding rich text with c.
+func (o SubmitOptions) values(c Codec, values map[string]string) (
+	map[string]string,
+	error,
+) {
+	if o.FlairText != "" && o.FlairID == "" {
+		return nil, errFlairTextWithoutID
+	}
+
+	if o.FlairID != "" {
+		values["flair_id"] = o.FlairID
+	}
+	if o.FlairText != "" {
+		values["flair_text"] = o.FlairText
+	}
+	if o.NSFW {
+		values["nsfw"] = "true"
+	}
+	if o.Spoiler {
+		values["spoiler"] = "true"
+	}
+	if o.OC {
+		values["oc"] = "true"
+	}
+	if o.DisableInboxReplies {
+		values["sendreplies"] = "false"
+	}
+	if o.RichText != nil {
+		if values["kind"] != "self" {
+			return nil, errRichTextLink
+		}
+
+		body, err := o.RichText.encode(c)
+		if err != nil {
+			return nil, err
+		}
+		delete(values, "text")
+		values["richtext_json"] = body
+	}
+
+	return values, nil
+}
+
+// accountConfig configures the behavior of an Account.
+type accountConfig struct {
+	// duplicateWindow is how long submissions are remembered to refuse
+	// duplicates. Duplicates are allowed if it is zero.
+	duplicateWindow time.Duration
+	// username is the name of the account, if the app logs in to one.
+	username string
+	// limits are the most characters sent in submissions' text.
+	limits BodyLimits
+	// clock times the duplicate window and polls. It is the system clock
+	// if nil.
+	clock Clock
 }
 
 type account struct {
 	// r is used to execute requests to Reddit.
 	r reaper
+	// submissions remembers recent submissions if duplicates are refused.
+	submissions *submissionGuard
+	// username is the name of the account.
+	username string
+	// limits are the most characters sent in submissions' text.
+	limits BodyLimits
+	// clock tells whether polls have closed.
+	clock Clock
 }
 
 // newAccount returns a new Account using the given reaper to make requests
 // to Reddit.
-func newAccount(r reaper) Account {
+func newAccount(r reaper, c accountConfig) Account {
 	return &account{
-		r: r,
+		r:           r,
+		submissions: newSubmissionGuard(c.duplicateWindow, c.clock),
+		username:    c.username,
+		limits:      c.limits.withDefaults(),
+		clock:       clockOrReal(c.clock),
 	}
 }
 
 func (a *account) Reply(parentName, text string) error {
+	if err := checkLength("comment", text, a.limits.Comment); err != nil {
+		return err
+	}
+
 	return a.r.sow(
 		"/api/comment", map[string]string{
 			"thing_id": parentName,
@@ -49,6 +287,10 @@ func (a *account) Reply(parentName, text string) error {
 }
 
 func (a *account) GetReply(parentName, text string) (Submission, error) {
+	if err := checkLength("comment", text, a.limits.Comment); err != nil {
+		return Submission{}, err
+	}
+
 	return a.r.get_sow(
 		"/api/comment", map[string]string{
 			"thing_id": parentName,
@@ -57,7 +299,56 @@ func (a *account) GetReply(parentName, text string) (Submission, error) {
 	)
 }
 
+func (a *account) ReplyRichText(parentName string, body *RichText) (
+	Submission,
+	error,
+) {
+	doc, err := body.encode(a.r.bodyCodec())
+	if err != nil {
+		return Submission{}, err
+	}
+
+	if err := checkLength("comment", doc, a.limits.Comment); err != nil {
+		return Submission{}, err
+	}
+
+	return a.r.get_sow(
+		"/api/comment", map[string]string{
+			"thing_id":      parentName,
+			"richtext_json": doc,
+		},
+	)
+}
+
+func (a *account) ReplyWithOptions(
+	parentName, text string,
+	opts ReplyOptions,
+) (Submission, error) {
+	reply, err := a.GetReply(parentName, text)
+	if err != nil {
+		return reply, err
+	}
+
+	if opts.DisableInboxReplies && !strings.HasPrefix(parentName, messageKind+"_") {
+		err = a.SetSendReplies(reply.Name, false)
+	}
+	return reply, err
+}
+
+func (a *account) SetSendReplies(name string, enabled bool) error {
+	return a.r.sow(
+		"/api/sendreplies", map[string]string{
+			"id":    name,
+			"state": strconv.FormatBool(enabled),
+		},
+	)
+}
+
 func (a *account) SendMessage(user, subject, text string) error {
+	if err := checkLength("message", text, a.limits.Message); err != nil {
+		return err
+	}
+
 	return a.r.sow(
 		"/api/compose", map[string]string{
 			"to":      user,
@@ -68,45 +359,515 @@ func (a *account) SendMessage(user, subject, text string) error {
 }
 
 func (a *account) PostSelf(subreddit, title, text string) error {
-	return a.r.sow(
-		"/api/submit", map[string]string{
-			"sr":    subreddit,
-			"kind":  "self",
-			"title": title,
-			"text":  text,
-		},
-	)
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkPost(title, text); err != nil {
+		return err
+	}
+
+	return a.guard(func() error {
+		return a.r.sow(
+			"/api/submit", map[string]string{
+				"sr":    subreddit,
+				"kind":  "self",
+				"title": title,
+				"text":  text,
+			},
+		)
+	}, subreddit, "self", title, text)
 }
 
 func (a *account) GetPostSelf(subreddit, title, text string) (Submission, error) {
-	return a.r.get_sow(
-		"/api/submit", map[string]string{
+	return a.PostSelfWithOptions(subreddit, title, text, SubmitOptions{})
+}
+
+func (a *account) PostSelfWithOptions(
+	subreddit, title, text string,
+	opts SubmitOptions,
+) (Submission, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	values, err := opts.values(
+		a.r.bodyCodec(),
+		map[string]string{
 			"sr":    subreddit,
 			"kind":  "self",
 			"title": title,
 			"text":  text,
 		},
 	)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	if body, ok := values["richtext_json"]; ok {
+		text = body
+	} else {
+		text = values["text"]
+	}
+
+	if err := a.checkPost(title, text); err != nil {
+		return Submission{}, err
+	}
+
+	var s Submission
+	return s, a.guard(func() (err error) {
+		s, err = a.r.get_sow("/api/submit", values)
+		return profileError(subreddit, err)
+	}, subreddit, "self", title, text)
 }
 
 func (a *account) PostLink(subreddit, title, url string) error {
-	return a.r.sow(
-		"/api/submit", map[string]string{
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkPost(title, ""); err != nil {
+		return err
+	}
+
+	return a.guard(func() error {
+		return a.r.sow(
+			"/api/submit", map[string]string{
+				"sr":    subreddit,
+				"kind":  "link",
+				"title": title,
+				"url":   url,
+			},
+		)
+	}, subreddit, "link", title, url)
+}
+
+func (a *account) GetPostLink(subreddit, title, url string) (Submission, error) {
+	return a.PostLinkWithOptions(subreddit, title, url, SubmitOptions{})
+}
+
+func (a *account) PostLinkWithOptions(
+	subreddit, title, url string,
+	opts SubmitOptions,
+) (Submission, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	values, err := opts.values(
+		a.r.bodyCodec(),
+		map[string]string{
 			"sr":    subreddit,
 			"kind":  "link",
 			"title": title,
 			"url":   url,
 		},
 	)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	if err := a.checkPost(title, ""); err != nil {
+		return Submission{}, err
+	}
+
+	var s Submission
+	return s, a.guard(func() (err error) {
+		s, err = a.r.get_sow("/api/submit", values)
+		return profileError(subreddit, err)
+	}, subreddit, "link", title, url)
 }
 
-func (a *account) GetPostLink(subreddit, title, url string) (Submission, error) {
-	return a.r.get_sow(
-		"/api/submit", map[string]string{
-			"sr":    subreddit,
-			"kind":  "link",
-			"title": title,
-			"url":   url,
+func (a *account) UserFlair(subreddit, user string) (*Flair, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.r.raw_sow(
+		"/r/"+subreddit+"/api/flairselector", map[string]string{
+			"name": user,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFlair(a.r.bodyCodec(), resp)
+}
+
+func (a *account) QuarantineOptIn(subreddit string) error {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return err
+	}
+
+	return a.r.sow(
+		"/api/quarantine_optin", map[string]string{
+			"sr_name": subreddit,
+		},
+	)
+}
+
+func (a *account) SubredditSettings(subreddit string) (
+	*SubredditSettings,
+	error,
+) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.r.raw_reap("/r/"+subreddit+"/about/edit", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, _, err := parseSettings(a.r.bodyCodec(), resp)
+	return settings, err
+}
+
+func (a *account) UpdateSubredditSettings(
+	subreddit string,
+	settings SubredditSettings,
+) error {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.r.raw_reap("/r/"+subreddit+"/about/edit", nil)
+	if err != nil {
+		return err
+	}
+
+	_, current, err := parseSettings(a.r.bodyCodec(), resp)
+	if err != nil {
+		return err
+	}
+
+	form := settingsForm(current)
+	settings.values(form)
+	form["api_type"] = "json"
+
+	resp, err = a.r.raw_sow("/api/site_admin", form)
+	if err != nil {
+		return err
+	}
+
+	return parseErrors(a.r.bodyCodec(), resp)
+}
+
+func (a *account) Saved(params map[string]string) (Harvest, error) {
+	if a.username == "" {
+		return Harvest{}, errNoAccount
+	}
+
+	reaperParams := map[string]string{
+		"raw_json": "1",
+	}
+	for key, value := range params {
+		reaperParams[key] = value
+	}
+
+	return a.r.reap("/user/"+a.username+"/saved", reaperParams)
+}
+
+// maxModeratedPages is the most pages of moderated subreddits read, to bound
+// the requests made if Reddit's pagination loops.
+const maxModeratedPages = 50
+
+func (a *account) ModeratedSubreddits() ([]*SubredditDetail, error) {
+	subreddits := []*SubredditDetail{}
+	after := ""
+	for page := 0; page < maxModeratedPages; page++ {
+		params := map[string]string{
+			"limit":    "100",
+			"raw_json": "1",
+		}
+		if after != "" {
+			params["after"] = after
+		}
+
+		harvest, err := a.r.reap("/subreddits/mine/moderator", params)
+		if err != nil {
+			return nil, err
+		}
+
+		subreddits = append(subreddits, harvest.Subreddits...)
+		if harvest.After == "" || harvest.After == after {
+			break
+		}
+		after = harvest.After
+	}
+
+	return subreddits, nil
+}
+
+func (a *account) Me() (*User, error) {
+	resp, err := a.r.raw_reap(
+		"/api/v1/me",
+		map[string]string{"raw_json": "1"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMe(a.r.bodyCodec(), resp)
+}
+
+func (a *account) KarmaBreakdown() ([]*SubredditKarma, error) {
+	resp, err := a.r.raw_reap("/api/v1/me/karma", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKarma(a.r.bodyCodec(), resp)
+}
+
+func (a *account) Multireddits() ([]*Multi, error) {
+	resp, err := a.r.raw_reap("/api/multi/mine", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMultis(a.r.bodyCodec(), resp)
+}
+
+func (a *account) NeedsCaptcha() (bool, error) {
+	resp, err := a.r.raw_reap("/api/needs_captcha", nil)
+	if err != nil {
+		return false, err
+	}
+
+	return parseNeedsCaptcha(a.r.bodyCodec(), resp)
+}
+
+func (a *account) SetUserFlair(subreddit, user, text, cssClass string) error {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.r.raw_sow(
+		"/r/"+subreddit+"/api/flair", map[string]string{
+			"name":      user,
+			"text":      text,
+			"css_class": cssClass,
+			"api_type":  "json",
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return parseErrors(a.r.bodyCodec(), resp)
+}
+
+func (a *account) DeleteUserFlair(subreddit, user string) error {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.r.raw_sow(
+		"/r/"+subreddit+"/api/deleteflair", map[string]string{
+			"name":     user,
+			"api_type": "json",
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return parseErrors(a.r.bodyCodec(), resp)
+}
+
+func (a *account) BulkFlairCSV(subreddit string, rows []FlairRow) (
+	[]FlairResult,
+	error,
+) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []FlairResult{}
+	for start := 0; start < len(rows); start += maxFlairCSVRows {
+		end := start + maxFlairCSVRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+
+		resp, err := a.r.raw_sow(
+			"/r/"+subreddit+"/api/flaircsv", map[string]string{
+				"flair_csv": flairCSV(chunk),
+			},
+		)
+		if err != nil {
+			return results, err
+		}
+
+		chunkResults, err := parseFlairResults(a.r.bodyCodec(), resp)
+		if err != nil {
+			return results, err
+		}
+
+		for i := range chunkResults {
+			if i < len(chunk) {
+				chunkResults[i].User = chunk[i].User
+			}
+		}
+		results = append(results, chunkResults...)
+	}
+
+	return results, nil
+}
+
+// flairCSV returns the csv of flair rows Reddit takes for bulk updates.
+func flairCSV(rows []FlairRow) string {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	for _, row := range rows {
+		w.Write([]string{row.User, row.Text, row.CSSClass})
+	}
+	w.Flush()
+	return buf.String()
+}
+
+func (a *account) VotePoll(postName, optionID string) error {
+	post, err := newLurker(a.r).Post(postName)
+	if err != nil {
+		return err
+	}
+
+	poll := post.PollData
+	if poll == nil {
+		return NotPollErr
+	}
+
+	if !poll.VotingEnd().After(a.clock.Now()) {
+		return PollClosedErr
+	}
+
+	found := false
+	for _, option := range poll.Options {
+		found = found || option.ID == optionID
+	}
+	if !found {
+		return fmt.Errorf("the poll has no option %q", optionID)
+	}
+
+	resp, err := a.r.raw_sow(
+		"/api/vote_poll", map[string]string{
+			"id":        postName,
+			"option_id": optionID,
+			"api_type":  "json",
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return parseErrors(a.r.bodyCodec(), resp)
+}
+
+func (a *account) AddToCollection(collectionID, postName string) error {
+	resp, err := a.r.raw_sow(
+		"/api/v1/collections/add_post_to_collection", map[string]string{
+			"collection_id": collectionID,
+			"link_fullname": postName,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return parseErrors(a.r.bodyCodec(), resp)
+}
+
+func (a *account) GiveAward(name, awardID string) error {
+	return a.r.sow(
+		"/api/v2/gold/gild", map[string]string{
+			"thing_id":  name,
+			"gild_type": awardID,
+		},
+	)
+}
+
+func (a *account) SetSticky(postName string, slot int, state bool) error {
+	if slot != 1 && slot != 2 {
+		return errStickySlot
+	}
+
+	resp, err := a.r.raw_sow(
+		"/api/set_subreddit_sticky", map[string]string{
+			"id":       postName,
+			"num":      strconv.Itoa(slot),
+			"state":    strconv.FormatBool(state),
+			"api_type": "json",
 		},
 	)
+	if err == conflictErr {
+		return StickyConflictErr
+	} else if err != nil {
+		return err
+	}
+
+	return parseErrors(a.r.bodyCodec(), resp)
+}
+
+// profileErrorCodes are the error codes Reddit refuses submissions to user
+// profiles with.
+var profileErrorCodes = []string{"SUBREDDIT_NOTALLOWED", "SUBREDDIT_NOEXIST"}
+
+// profileError returns ProfilePostingErr for errors refusing a submission to
+// a user profile, and any other error unchanged.
+func profileError(subreddit string, err error) error {
+	apiErr, ok := err.(*apiError)
+	if !ok || !strings.HasPrefix(subreddit, profilePrefix) {
+		return err
+	}
+
+	for _, code := range profileErrorCodes {
+		if apiErr.has(code) {
+			return ProfilePostingErr
+		}
+	}
+	return err
+}
+
+// checkPost returns a BodyTooLongError if a post's title or text is longer
+// than the account's limits.
+func (a *account) checkPost(title, text string) error {
+	if err := checkLength("title", title, a.limits.Title); err != nil {
+		return err
+	}
+
+	return checkLength("self text", text, a.limits.SelfText)
+}
+
+// guard calls submit unless the submission described by parts duplicates one
+// made recently. The submission is forgotten if Reddit refuses it, so it can
+// be retried.
+func (a *account) guard(submit func() error, parts ...string) error {
+	if a.submissions == nil {
+		return submit()
+	}
+
+	key, err := a.submissions.claim(parts...)
+	if err != nil {
+		return err
+	}
+
+	err = submit()
+	if refused(err) {
+		a.submissions.release(key)
+	}
+	return err
 }
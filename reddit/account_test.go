@@ -0,0 +1,575 @@
+package reddit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestSubmitOptionsFlairTextWithoutID(t *testing.T) {
+	r := &mockReaper{}
+	a := newAccount(r, accountConfig{})
+
+	if _, err := a.PostSelfWithOptions(
+		"self", "title", "text",
+		SubmitOptions{FlairText: "flair"},
+	); err != errFlairTextWithoutID {
+		t.Errorf("wanted errFlairTextWithoutID; got %v", err)
+	}
+
+	if r.path != "" {
+		t.Errorf("submitted post with invalid options to %s", r.path)
+	}
+}
+
+// settingsReaper serves a subreddit's settings and records the form of the
+// last update to them.
+type settingsReaper struct {
+	mockReaper
+	form map[string]string
+}
+
+func (s *settingsReaper) raw_sow(path string, values map[string]string) ([]byte, error) {
+	s.path = path
+	s.form = values
+	return []byte(`{"json": {"errors": []}}`), s.err
+}
+
+var settingsResponse = []byte(`{
+	"kind": "subreddit_settings",
+	"data": {
+		"subreddit_id": "t5_2qh1i",
+		"title": "Go",
+		"public_description": "The Go programming language",
+		"subreddit_type": "public",
+		"content_options": "any",
+		"over_18": false,
+		"spoilers_enabled": true,
+		"wiki_edit_karma": 100,
+		"comment_score_hide_mins": 0,
+		"header_hover_text": null
+	}
+}`)
+
+func TestSubredditSettings(t *testing.T) {
+	r := &mockReaper{raw: settingsResponse}
+	a := newAccount(r, accountConfig{})
+
+	settings, err := a.SubredditSettings("golang")
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+
+	if r.path != "/r/golang/about/edit" {
+		t.Errorf("read settings from wrong path: %s", r.path)
+	}
+
+	if settings.ID != "t5_2qh1i" ||
+		settings.Title == nil || *settings.Title != "Go" ||
+		settings.Type == nil || *settings.Type != "public" ||
+		settings.SpoilersEnabled == nil || !*settings.SpoilersEnabled ||
+		settings.WikiEditKarma == nil || *settings.WikiEditKarma != 100 {
+		t.Errorf("settings parsed incorrectly: %+v", settings)
+	}
+
+	if settings.SubmitText != nil {
+		t.Errorf("wanted missing submit text to be nil")
+	}
+
+	r.err = PermissionDeniedErr
+	if _, err := a.SubredditSettings("golang"); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+}
+
+func TestUpdateSubredditSettings(t *testing.T) {
+	r := &settingsReaper{mockReaper: mockReaper{raw: settingsResponse}}
+	a := newAccount(r, accountConfig{})
+
+	title := "Golang"
+	nsfw := true
+	if err := a.UpdateSubredditSettings(
+		"golang",
+		SubredditSettings{Title: &title, NSFW: &nsfw},
+	); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+
+	if r.path != "/api/site_admin" {
+		t.Errorf("updated settings at wrong path: %s", r.path)
+	}
+
+	expected := map[string]string{
+		"api_type":                "json",
+		"sr":                      "t5_2qh1i",
+		"title":                   "Golang",
+		"public_description":      "The Go programming language",
+		"type":                    "public",
+		"link_type":               "any",
+		"over_18":                 "true",
+		"spoilers_enabled":        "true",
+		"wiki_edit_karma":         "100",
+		"comment_score_hide_mins": "0",
+	}
+	if diff := pretty.Compare(r.form, expected); diff != "" {
+		t.Errorf("wrong update form: %s", diff)
+	}
+}
+
+func TestSaved(t *testing.T) {
+	r := &mockReaper{h: Harvest{After: "t1_def"}}
+	a := newAccount(r, accountConfig{username: "user"})
+
+	h, err := a.Saved(map[string]string{"limit": "10"})
+	if err != nil {
+		t.Fatalf("failed to fetch saved items: %v", err)
+	}
+
+	if r.path != "/user/user/saved" {
+		t.Errorf("fetched saved items from wrong path: %s", r.path)
+	}
+
+	if h.After != "t1_def" {
+		t.Errorf("wrong cursor: %q", h.After)
+	}
+
+	if _, err := newAccount(r, accountConfig{}).Saved(nil); err != errNoAccount {
+		t.Errorf("wanted errNoAccount without a username; got %v", err)
+	}
+}
+
+func TestAddToCollection(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{}`)}
+	a := newAccount(r, accountConfig{})
+
+	if err := a.AddToCollection("id", "t3_abc"); err != nil {
+		t.Fatalf("failed to add to collection: %v", err)
+	}
+
+	if r.path != "/api/v1/collections/add_post_to_collection" {
+		t.Errorf("added to collection at wrong path: %s", r.path)
+	}
+
+	r.raw = []byte(`{"json": {"errors": [["INVALID_PERMISSIONS", "you aren't allowed to do that", "sr"]]}}`)
+	if err := a.AddToCollection("id", "t3_abc"); err == nil {
+		t.Errorf("wanted error from errors envelope")
+	}
+
+	r.err = PermissionDeniedErr
+	if err := a.AddToCollection("id", "t3_abc"); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+}
+
+func TestAccountNormalizesSubreddits(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"current": {}}`)}
+	a := newAccount(r, accountConfig{})
+
+	if _, err := a.UserFlair("/r/golang/", "gopher"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.path != "/r/golang/api/flairselector" {
+		t.Errorf("read flair from wrong path: %s", r.path)
+	}
+
+	r.path = ""
+	if err := a.PostSelf("r/has space", "title", "text"); err == nil {
+		t.Errorf("wanted error for invalid subreddit name")
+	} else if r.path != "" {
+		t.Errorf("made request for invalid subreddit name to %s", r.path)
+	}
+}
+
+func TestSetSticky(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"json": {"errors": []}}`)}
+	a := newAccount(r, accountConfig{})
+
+	for _, slot := range []int{0, 3, -1} {
+		if err := a.SetSticky("t3_abc", slot, true); err != errStickySlot {
+			t.Errorf("wanted errStickySlot for slot %d; got %v", slot, err)
+		}
+	}
+	if r.path != "" {
+		t.Errorf("made request for invalid slot to %s", r.path)
+	}
+
+	if err := a.SetSticky("t3_abc", 1, true); err != nil {
+		t.Errorf("failed to set sticky: %v", err)
+	}
+
+	r.err = conflictErr
+	if err := a.SetSticky("t3_abc", 1, true); err != StickyConflictErr {
+		t.Errorf("wanted StickyConflictErr; got %v", err)
+	}
+
+	r.err = PermissionDeniedErr
+	if err := a.SetSticky("t3_abc", 1, false); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+}
+
+func TestProfilePostingErr(t *testing.T) {
+	notAllowed := &apiError{
+		errs: []interface{}{
+			[]interface{}{"SUBREDDIT_NOTALLOWED", "you aren't allowed to post there.", "sr"},
+		},
+	}
+	r := &mockReaper{err: notAllowed}
+	a := newAccount(r, accountConfig{duplicateWindow: time.Hour})
+
+	if _, err := a.GetPostSelf("u/gopher", "title", "text"); err != ProfilePostingErr {
+		t.Errorf("wanted ProfilePostingErr; got %v", err)
+	}
+
+	r.err = nil
+	if _, err := a.GetPostSelf("u/gopher", "title", "text"); err != nil {
+		t.Errorf("refused profile submission was not released for retry: %v", err)
+	}
+
+	r.err = notAllowed
+	if _, err := a.GetPostLink("golang", "title", "url"); err != notAllowed {
+		t.Errorf("wanted API error unchanged for a subreddit; got %v", err)
+	}
+}
+
+// flairCSVReaper answers bulk flair updates with a result for each row,
+// recording the csv of each request.
+type flairCSVReaper struct {
+	mockReaper
+	csvs []string
+}
+
+func (f *flairCSVReaper) raw_sow(path string, values map[string]string) ([]byte, error) {
+	f.path = path
+	f.csvs = append(f.csvs, values["flair_csv"])
+
+	rows := strings.Count(values["flair_csv"], "\n")
+	results := make([]string, rows)
+	for i := range results {
+		results[i] = `{"ok": true, "status": "added flair", "errors": {}, "warnings": {}}`
+	}
+	return []byte("[" + strings.Join(results, ",") + "]"), nil
+}
+
+func TestBulkFlairCSV(t *testing.T) {
+	rows := []FlairRow{}
+	for i := 0; i < 250; i++ {
+		rows = append(rows, FlairRow{User: fmt.Sprintf("user%d", i), Text: "Gopher, Jr."})
+	}
+
+	r := &flairCSVReaper{}
+	results, err := newAccount(r, accountConfig{}).BulkFlairCSV("golang", rows)
+	if err != nil {
+		t.Fatalf("failed bulk flair update: %v", err)
+	}
+
+	if r.path != "/r/golang/api/flaircsv" {
+		t.Errorf("updated flair at wrong path: %s", r.path)
+	}
+
+	if len(r.csvs) != 3 {
+		t.Fatalf("made %d requests; wanted 3", len(r.csvs))
+	}
+
+	for i, want := range []int{100, 100, 50} {
+		if rows := strings.Count(r.csvs[i], "\n"); rows != want {
+			t.Errorf("request %d had %d rows; wanted %d", i, rows, want)
+		}
+	}
+
+	if !strings.HasPrefix(r.csvs[1], "user100,\"Gopher, Jr.\",\n") {
+		t.Errorf("second request's csv began incorrectly: %q", r.csvs[1][:40])
+	}
+
+	if len(results) != 250 {
+		t.Fatalf("got %d results; wanted 250", len(results))
+	}
+	if results[249].User != "user249" || !results[249].OK {
+		t.Errorf("last result incorrect: %+v", results[249])
+	}
+}
+
+func TestBulkFlairCSVRowErrors(t *testing.T) {
+	r := &mockReaper{raw: []byte(`[
+		{"ok": true, "status": "added flair for user gopher", "errors": {}, "warnings": {}},
+		{"ok": false, "status": "skipped", "errors": {"user": "unable to resolve user 'nobody', ignoring"}, "warnings": {}}
+	]`)}
+
+	results, err := newAccount(r, accountConfig{}).BulkFlairCSV(
+		"golang",
+		[]FlairRow{{User: "gopher", Text: "gopher"}, {User: "nobody"}},
+	)
+	if err != nil {
+		t.Fatalf("failed bulk flair update: %v", err)
+	}
+
+	if len(results) != 2 || results[1].OK || results[1].User != "nobody" {
+		t.Errorf("results parsed incorrectly: %+v", results)
+	} else if results[1].Errors["user"] == "" {
+		t.Errorf("row error was not reported: %+v", results[1])
+	}
+
+	r.err = PermissionDeniedErr
+	if _, err := newAccount(r, accountConfig{}).BulkFlairCSV(
+		"golang", []FlairRow{{User: "gopher"}},
+	); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+}
+
+func TestVotePoll(t *testing.T) {
+	open := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	closed := time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+	poll := func(end int64) Harvest {
+		return Harvest{Posts: []*Post{{
+			Name: "t3_abc",
+			PollData: &PollData{
+				Options:            []PollOption{{ID: "1"}, {ID: "2"}},
+				VotingEndTimestamp: end,
+			},
+		}}}
+	}
+
+	for _, test := range []struct {
+		name   string
+		h      Harvest
+		option string
+		err    error
+	}{
+		{"not a poll", Harvest{Posts: []*Post{{Name: "t3_abc"}}}, "1", NotPollErr},
+		{"closed", poll(closed), "1", PollClosedErr},
+		{"missing", Harvest{}, "1", ThreadDoesNotExistErr},
+		{"open", poll(open), "2", nil},
+	} {
+		r := &mockReaper{h: test.h, raw: []byte(`{"json": {"errors": []}}`)}
+		err := newAccount(r, accountConfig{}).VotePoll("t3_abc", test.option)
+		if err != test.err {
+			t.Errorf("[%s] wanted %v; got %v", test.name, test.err, err)
+		}
+
+		if test.err == nil && r.path != "/api/vote_poll" {
+			t.Errorf("[%s] voted at wrong path: %s", test.name, r.path)
+		} else if test.err != nil && r.path != "/api/info" {
+			t.Errorf("[%s] made request after refusing vote: %s", test.name, r.path)
+		}
+	}
+
+	r := &mockReaper{h: poll(open)}
+	if err := newAccount(r, accountConfig{}).VotePoll("t3_abc", "3"); err == nil {
+		t.Errorf("wanted error for option the poll does not have")
+	}
+}
+
+// replyReaper answers replies with a comment, recording the path and form of
+// each request.
+type replyReaper struct {
+	mockReaper
+	paths []string
+	forms []map[string]string
+}
+
+func (r *replyReaper) get_sow(path string, values map[string]string) (Submission, error) {
+	r.paths = append(r.paths, path)
+	r.forms = append(r.forms, values)
+	return Submission{ID: "def", Name: "t1_def"}, nil
+}
+
+func (r *replyReaper) sow(path string, values map[string]string) error {
+	r.paths = append(r.paths, path)
+	r.forms = append(r.forms, values)
+	return nil
+}
+
+func TestReplyWithOptions(t *testing.T) {
+	r := &replyReaper{}
+	a := newAccount(r, accountConfig{})
+
+	reply, err := a.ReplyWithOptions("t3_abc", "hi", ReplyOptions{DisableInboxReplies: true})
+	if err != nil {
+		t.Fatalf("failed to reply: %v", err)
+	}
+
+	if reply.Name != "t1_def" {
+		t.Errorf("got reply %v; wanted t1_def", reply)
+	}
+
+	if diff := pretty.Compare(r.paths, []string{"/api/comment", "/api/sendreplies"}); diff != "" {
+		t.Errorf("requests incorrect; diff: %s", diff)
+	}
+
+	if form := r.forms[1]; form["id"] != "t1_def" || form["state"] != "false" {
+		t.Errorf("disabled inbox replies with form %v", form)
+	}
+
+	r = &replyReaper{}
+	for _, test := range []struct {
+		parent string
+		opts   ReplyOptions
+	}{
+		{"t1_abc", ReplyOptions{}},
+		{"t4_abc", ReplyOptions{DisableInboxReplies: true}},
+	} {
+		if _, err := newAccount(r, accountConfig{}).ReplyWithOptions(test.parent, "hi", test.opts); err != nil {
+			t.Errorf("failed to reply to %s: %v", test.parent, err)
+		}
+	}
+
+	if len(r.paths) != 2 {
+		t.Errorf("wanted only the replies; got requests %v", r.paths)
+	}
+}
+
+func TestBodyLimits(t *testing.T) {
+	r := &mockReaper{}
+	a := newAccount(r, accountConfig{limits: BodyLimits{Comment: 5}})
+
+	if err := a.Reply("t1_abc", "héllo"); err != nil {
+		t.Errorf("unexpected error for comment at the limit: %v", err)
+	}
+
+	r.path = ""
+	err := a.Reply("t1_abc", "héllo!")
+	if tooLong, ok := err.(*BodyTooLongError); !ok ||
+		tooLong.Field != "comment" ||
+		tooLong.Length != 6 ||
+		tooLong.Limit != 5 {
+		t.Errorf("wanted BodyTooLongError for over limit comment; got %v", err)
+	}
+	if r.path != "" {
+		t.Errorf("sent over limit comment to %s", r.path)
+	}
+
+	_, err = a.ReplyRichText("t1_abc", &RichText{
+		Document: []RichTextNode{RichParagraph(RichSpan("hi"))},
+	})
+	if tooLong, ok := err.(*BodyTooLongError); !ok || tooLong.Field != "comment" {
+		t.Errorf("wanted BodyTooLongError for over limit rich text; got %v", err)
+	}
+	if r.path != "" {
+		t.Errorf("sent over limit rich text comment to %s", r.path)
+	}
+
+	title := strings.Repeat("t", maxTitleLength)
+	if _, err := a.GetPostLink("link", title, "url"); err != nil {
+		t.Errorf("unexpected error for title at the limit: %v", err)
+	}
+	if _, err := a.GetPostLink("link", title+"t", "url"); err == nil {
+		t.Errorf("wanted error for over limit title")
+	}
+
+	text := strings.Repeat("x", maxSelfTextLength+1)
+	if err := a.PostSelf("self", "title", text); err == nil {
+		t.Errorf("wanted error for over limit self text")
+	}
+	if _, err := a.PostSelfWithOptions(
+		"self", "title", text,
+		SubmitOptions{
+			RichText: &RichText{
+				Document: []RichTextNode{RichParagraph(RichSpan("hi"))},
+			},
+		},
+	); err != nil {
+		t.Errorf("unexpected error for rich text post with unsent text: %v", err)
+	}
+	r.path = ""
+	if _, err := a.PostSelfWithOptions(
+		"self", "title", "",
+		SubmitOptions{
+			RichText: &RichText{
+				Document: []RichTextNode{RichParagraph(RichSpan(text))},
+			},
+		},
+	); err == nil {
+		t.Errorf("wanted error for over limit rich text self post")
+	}
+	if r.path != "" {
+		t.Errorf("sent over limit rich text self post to %s", r.path)
+	}
+
+	if err := a.SendMessage("user", "subject", strings.Repeat("m", maxMessageLength+1)); err == nil {
+		t.Errorf("wanted error for over limit message")
+	}
+}
+
+func TestNeedsCaptcha(t *testing.T) {
+	for _, test := range []struct {
+		resp  string
+		needs bool
+		err   bool
+	}{
+		{"true", true, false},
+		{"false", false, false},
+		{`{"json": {"errors": []}}`, false, true},
+	} {
+		r := &mockReaper{raw: []byte(test.resp)}
+		needs, err := newAccount(r, accountConfig{}).NeedsCaptcha()
+		if (err != nil) != test.err || needs != test.needs {
+			t.Errorf("[%s] got %v, %v; wanted %v", test.resp, needs, err, test.needs)
+		}
+		if r.path != "/api/needs_captcha" {
+			t.Errorf("requested %s; wanted /api/needs_captcha", r.path)
+		}
+	}
+}
+
+// pagedReaper returns the harvest of each page of a listing by its "after".
+type pagedReaper struct {
+	mockReaper
+	pages  map[string]Harvest
+	params []map[string]string
+}
+
+func (p *pagedReaper) reap(path string, params map[string]string) (Harvest, error) {
+	p.path = path
+	p.params = append(p.params, params)
+	return p.pages[params["after"]], nil
+}
+
+func TestModeratedSubreddits(t *testing.T) {
+	r := &pagedReaper{
+		pages: map[string]Harvest{
+			"": {
+				Subreddits: []*SubredditDetail{{Name: "t5_a"}, {Name: "t5_b"}},
+				After:      "t5_b",
+			},
+			"t5_b": {
+				Subreddits: []*SubredditDetail{{Name: "t5_c"}},
+			},
+		},
+	}
+
+	subreddits, err := newAccount(r, accountConfig{}).ModeratedSubreddits()
+	if err != nil {
+		t.Fatalf("failed to list moderated subreddits: %v", err)
+	}
+
+	if len(subreddits) != 3 || subreddits[2].Name != "t5_c" {
+		t.Errorf("got %d subreddits; wanted t5_a, t5_b and t5_c", len(subreddits))
+	}
+	if r.path != "/subreddits/mine/moderator" || len(r.params) != 2 {
+		t.Errorf("read %d pages of %s; wanted 2 of /subreddits/mine/moderator", len(r.params), r.path)
+	}
+	if _, ok := r.params[0]["after"]; ok || r.params[1]["after"] != "t5_b" {
+		t.Errorf("requested pages %v", r.params)
+	}
+
+	looping := &pagedReaper{
+		pages: map[string]Harvest{
+			"":     {Subreddits: []*SubredditDetail{{Name: "t5_a"}}, After: "t5_a"},
+			"t5_a": {Subreddits: []*SubredditDetail{{Name: "t5_a"}}, After: "t5_a"},
+		},
+	}
+	if _, err := newAccount(looping, accountConfig{}).ModeratedSubreddits(); err != nil ||
+		len(looping.params) != 2 {
+		t.Errorf("read %d pages of a looping listing; wanted 2", len(looping.params))
+	}
+
+	empty := &pagedReaper{pages: map[string]Harvest{}}
+	if subreddits, err := newAccount(empty, accountConfig{}).ModeratedSubreddits(); err != nil ||
+		subreddits == nil || len(subreddits) != 0 {
+		t.Errorf("wanted no subreddits; got %v, %v", subreddits, err)
+	}
+}
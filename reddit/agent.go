@@ -0,0 +1,63 @@
+package reddit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// agentPart matches the platform, app id and version of a user agent, which
+// may not contain the separators of the user agent's format.
+var agentPart = regexp.MustCompile(`^[^\s:()]+$`)
+
+// username matches valid Reddit usernames.
+var username = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+// UserAgent is a user agent in the format Reddit asks apps to use:
+//
+//	<platform>:<app ID>:<version string> (by /u/<reddit username>)
+//
+// Reddit throttles requests with generic or malformed user agents.
+type UserAgent struct {
+	Platform string
+	AppID    string
+	Version  string
+	Username string
+}
+
+// NewUserAgent returns the user agent of an app, e.g. "linux", "graw-bot",
+// "v1.0.0" and "gopher", or an error if one of the parts would break its
+// format. The username may be given as "u/gopher" or "/u/gopher".
+func NewUserAgent(platform, appID, version, user string) (*UserAgent, error) {
+	for _, part := range []struct{ name, value string }{
+		{"platform", platform},
+		{"app id", appID},
+		{"version", version},
+	} {
+		if !agentPart.MatchString(part.value) {
+			return nil, fmt.Errorf(
+				"invalid user agent %s %q", part.name, part.value,
+			)
+		}
+	}
+
+	user = strings.TrimPrefix(strings.TrimPrefix(user, "/"), "u/")
+	if !username.MatchString(user) {
+		return nil, fmt.Errorf("invalid user agent username %q", user)
+	}
+
+	return &UserAgent{
+		Platform: platform,
+		AppID:    appID,
+		Version:  version,
+		Username: user,
+	}, nil
+}
+
+// GetUserAgent returns the user agent string, for a BotConfig's Agent.
+func (u *UserAgent) GetUserAgent() string {
+	return fmt.Sprintf(
+		"%s:%s:%s (by /u/%s)",
+		u.Platform, u.AppID, u.Version, u.Username,
+	)
+}
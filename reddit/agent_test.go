@@ -0,0 +1,47 @@
+package reddit
+
+import (
+	"testing"
+)
+
+func TestNewUserAgent(t *testing.T) {
+	for _, test := range []struct {
+		platform, appID, version, user string
+		agent                          string
+	}{
+		{"linux", "graw-bot", "v1.0.0", "gopher", "linux:graw-bot:v1.0.0 (by /u/gopher)"},
+		{"go", "com.example.bot", "0.1", "u/gopher", "go:com.example.bot:0.1 (by /u/gopher)"},
+		{"go", "bot", "1", "/u/Go_pher-2", "go:bot:1 (by /u/Go_pher-2)"},
+	} {
+		agent, err := NewUserAgent(test.platform, test.appID, test.version, test.user)
+		if err != nil {
+			t.Errorf("unexpected error for %v: %v", test, err)
+		} else if got := agent.GetUserAgent(); got != test.agent {
+			t.Errorf("got agent %q; wanted %q", got, test.agent)
+		}
+	}
+}
+
+func TestNewUserAgentInvalid(t *testing.T) {
+	for _, test := range []struct {
+		platform, appID, version, user string
+	}{
+		{"", "bot", "1", "gopher"},
+		{"linux box", "bot", "1", "gopher"},
+		{"linux", "", "1", "gopher"},
+		{"linux", "my:bot", "1", "gopher"},
+		{"linux", "bot(beta)", "1", "gopher"},
+		{"linux", "bot", "", "gopher"},
+		{"linux", "bot", "1.0 beta", "gopher"},
+		{"linux", "bot", "1", ""},
+		{"linux", "bot", "1", "go"},
+		{"linux", "bot", "1", "gopher gopher"},
+		{"linux", "bot", "1", "waytoolongforausername"},
+	} {
+		if _, err := NewUserAgent(
+			test.platform, test.appID, test.version, test.user,
+		); err == nil {
+			t.Errorf("wanted error for %v", test)
+		}
+	}
+}
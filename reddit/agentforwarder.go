@@ -2,31 +2,79 @@ package reddit
 
 import (
 	"net/http"
+	"sync"
 )
 
-// agentForward forwards a user agent in all requests made by the Transport.
+// agentForward forwards a user agent and any extra headers in all requests
+// made by the Transport.
 type agentForwarder struct {
 	http.RoundTripper
 	agent string
+	// mu guards headers, which may change while requests are made.
+	mu      sync.RWMutex
+	headers http.Header
 }
 
-// RoundTrip sets a predefined agent in the request and then forwards it to the
-// default RountTrip implementation.
+// RoundTrip sets a predefined agent and headers in the request and then
+// forwards it to the default RountTrip implementation. Headers the request
+// already sets are left alone, so they take precedence over the forwarded
+// ones.
 func (a *agentForwarder) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
 	r.Header.Add("User-Agent", a.agent)
+	a.mu.RLock()
+	for key := range a.headers {
+		if r.Header.Get(key) == "" {
+			r.Header.Set(key, a.headers.Get(key))
+		}
+	}
+	a.mu.RUnlock()
 	return a.RoundTripper.RoundTrip(r)
 }
 
-func patchWithAgent(client *http.Client, agent string) *http.Client {
+// setHeader sets a header forwarded in requests made after it, or stops
+// forwarding it if value is empty.
+func (a *agentForwarder) setHeader(key, value string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if value == "" {
+		a.headers.Del(key)
+	} else {
+		a.headers.Set(key, value)
+	}
+}
+
+// setForwardedHeader sets a header the agent forwarder of the client
+// forwards, if it has one.
+func setForwardedHeader(client *http.Client, key, value string) {
+	if a, ok := client.Transport.(*agentForwarder); ok {
+		a.setHeader(key, value)
+	}
+}
+
+func patchWithAgent(
+	client *http.Client,
+	agent string,
+	headers map[string]string,
+) *http.Client {
 	if client.Transport == nil {
 		client.Transport = http.DefaultTransport
 	}
 
-	client.Transport = &agentForwarder{RoundTripper: client.Transport, agent: agent}
+	forwarded := http.Header{}
+	for key, value := range headers {
+		forwarded.Set(key, value)
+	}
+
+	client.Transport = &agentForwarder{
+		RoundTripper: client.Transport,
+		agent:        agent,
+		headers:      forwarded,
+	}
 	return client
 }
 
-func clientWithAgent(agent string) *http.Client {
+func clientWithAgent(agent string, headers map[string]string) *http.Client {
 	c := &http.Client{}
-	return patchWithAgent(c, agent)
+	return patchWithAgent(c, agent, headers)
 }
@@ -3,11 +3,13 @@ package reddit
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 )
 
 func TestClientWithAgent(t *testing.T) {
-	c := clientWithAgent("agent")
+	c := clientWithAgent("agent", nil)
 
 	forwarder := c.Transport
 	if v, ok := forwarder.(*agentForwarder); ok {
@@ -28,7 +30,7 @@ func (m mockTransport) RoundTrip(*http.Request) (*http.Response, error) {
 func TestPatchWithAgent(t *testing.T) {
 	t.Run("null-transport", func(t *testing.T) {
 		client := &http.Client{}
-		c := patchWithAgent(client, "agent")
+		c := patchWithAgent(client, "agent", nil)
 
 		forwarder := c.Transport
 		if v, ok := forwarder.(*agentForwarder); ok {
@@ -44,7 +46,7 @@ func TestPatchWithAgent(t *testing.T) {
 		client := &http.Client{
 			Transport: mockTransport{},
 		}
-		c := patchWithAgent(client, "agent")
+		c := patchWithAgent(client, "agent", nil)
 
 		forwarder := c.Transport
 		if _, ok := forwarder.(mockTransport); ok {
@@ -62,7 +64,7 @@ func TestAgentForwarder_RoundTrip(t *testing.T) {
 
 	defer server.Close()
 
-	client := patchWithAgent(server.Client(), "agent")
+	client := patchWithAgent(server.Client(), "agent", nil)
 
 	_, err := client.Get("https://example.com")
 	if err != nil {
@@ -70,3 +72,100 @@ func TestAgentForwarder_RoundTrip(t *testing.T) {
 		return
 	}
 }
+
+func TestAgentForwarderHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+		rw.Write([]byte("{}"))
+	}))
+
+	defer server.Close()
+
+	r := &reaperImpl{
+		cli: &baseClient{
+			cli: clientWithAgent(
+				"agent",
+				map[string]string{
+					"X-Trace":      "trace",
+					"Content-Type": "text/plain",
+				},
+			),
+		},
+		parser:   &mockParser{},
+		hostname: strings.TrimPrefix(server.URL, "http://"),
+		scheme:   "http",
+		mu:       &sync.Mutex{},
+	}
+
+	for _, test := range []struct {
+		name        string
+		f           func() error
+		contentType string
+	}{
+		{"GET", func() error {
+			_, err := r.reap("/path", nil)
+			return err
+		}, "text/plain"},
+		{"POST", func() error {
+			return r.sow("/path", nil)
+		}, "application/x-www-form-urlencoded"},
+	} {
+		if err := test.f(); err != nil {
+			t.Errorf("[%s] request failed: %v", test.name, err)
+			continue
+		}
+
+		h := <-headers
+		if v := h.Get("X-Trace"); v != "trace" {
+			t.Errorf("[%s] expected `trace`, got %s", test.name, v)
+		}
+		if v := h.Get("User-Agent"); v != "agent" {
+			t.Errorf("[%s] expected `agent`, got %s", test.name, v)
+		}
+		if v := h.Get("Content-Type"); v != test.contentType {
+			t.Errorf("[%s] expected `%s`, got %s", test.name, test.contentType, v)
+		}
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+		rw.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c, err := newClient(clientConfig{agent: "agent", headers: map[string]string{"X-Team": "a"}})
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+	b := &bot{cli: c}
+
+	do := func() http.Header {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return <-headers
+	}
+
+	if h := do(); h.Get("X-Team") != "a" {
+		t.Errorf("got X-Team %q; wanted the configured header", h.Get("X-Team"))
+	}
+
+	b.SetHeader("X-Team", "b")
+	b.SetHeader("X-Shard", "3")
+	if h := do(); h.Get("X-Team") != "b" || h.Get("X-Shard") != "3" {
+		t.Errorf("headers not updated: %v", h)
+	}
+
+	b.SetHeader("X-Team", "")
+	if h := do(); h.Get("X-Team") != "" {
+		t.Errorf("got X-Team %q after removing it", h.Get("X-Team"))
+	}
+}
@@ -1,13 +1,22 @@
 package reddit
 
-import "fmt"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
 
 var (
 	errMissingOauthCredentials = fmt.Errorf("missing oauth credentials")
 	errMissingUsername         = fmt.Errorf("missing username")
 	errMissingPassword         = fmt.Errorf("missing password")
+	errInvalidDeviceID         = fmt.Errorf("device id must be 20-30 characters")
+	errRefreshTokenAndPassword = fmt.Errorf("apps authorize with a refresh token or a password, not both")
 )
 
+// installedGrant is the grant type Reddit uses for installed apps.
+const installedGrant = "https://oauth.reddit.com/grants/installed_client"
+
 // App holds all the information needed to identify as a registered app on
 // Reddit. If you are unfamiliar with this information, you can find it in your
 // "apps" tab on reddit; see this tutorial:
@@ -21,13 +30,62 @@ type App struct {
 	// Username and Password are used to authorize with the endpoint.
 	Username string
 	Password string
+	// OTP is the current two factor code of the account, if it has two
+	// factor authentication enabled. Reddit expects it appended to the
+	// password.
+	OTP string
+	// OTPFunc, if set, is called for a fresh two factor code each time the
+	// bot authorizes, for time based codes. It takes precedence over OTP.
+	OTPFunc func() (string, error)
+
+	// RefreshToken, if set, is a refresh token a user granted the app,
+	// such as one from ExchangeCode. The bot acts as that user, getting
+	// an access token with it on its first request and refreshing the
+	// token whenever it expires. It replaces Username and Password.
+	RefreshToken string
+
+	// Installed marks the app as an installed app, which has an ID but no
+	// Secret and authorizes on behalf of a device rather than an account.
+	Installed bool
+	// DeviceID is the unique identifier of this installation sent when
+	// authorizing an installed app. It must be 20-30 characters long. If
+	// empty, one is generated when the client is created and reused for
+	// every authorization the client makes. The generated id is reported in
+	// TokenInfo; store it, or one from NewDeviceID, and set it here to keep
+	// the same device identity across restarts.
+	DeviceID string
 
-	// tokenURL is the url of the token request location for OAuth2.
-	tokenURL string
+	// Scopes are the OAuth2 scopes the app asks for, in place of the
+	// default identity, read, privatemessages, submit and history. Some
+	// calls need scopes which are not asked for by default, such as
+	// "flair" for UserFlair; list every scope the bot needs here to use
+	// them.
+	Scopes []string
+
+	// TokenURL is the url of the OAuth2 token endpoint, such as a fake one
+	// in tests or a gateway in front of Reddit. It is Reddit's if empty.
+	TokenURL string
+	// RevokeURL is the url of the OAuth2 token revocation endpoint. It is
+	// Reddit's if empty.
+	RevokeURL string
+}
+
+// withEndpoints returns the app with Reddit's OAuth2 endpoints in place of
+// any it does not set.
+func (a App) withEndpoints() App {
+	if a.TokenURL == "" {
+		a.TokenURL = tokenURL
+	}
+
+	if a.RevokeURL == "" {
+		a.RevokeURL = revokeURL
+	}
+
+	return a
 }
 
 func (a App) unauthenticated() bool {
-	return a.ID == "" || a.Secret == ""
+	return a.ID == "" || (a.Secret == "" && !a.Installed)
 }
 
 func (a App) validateAuth() error {
@@ -35,6 +93,10 @@ func (a App) validateAuth() error {
 		return errMissingOauthCredentials
 	}
 
+	if a.RefreshToken != "" && (a.Username != "" || a.Password != "") {
+		return errRefreshTokenAndPassword
+	}
+
 	if a.Password != "" && a.Username == "" {
 		return errMissingUsername
 	}
@@ -43,5 +105,52 @@ func (a App) validateAuth() error {
 		return errMissingPassword
 	}
 
+	if a.DeviceID != "" && (len(a.DeviceID) < 20 || len(a.DeviceID) > 30) {
+		return errInvalidDeviceID
+	}
+
 	return nil
 }
+
+// scopes returns the OAuth2 scopes the app asks for.
+func (a App) scopes() []string {
+	if len(a.Scopes) == 0 {
+		return oauthScopes
+	}
+	return a.Scopes
+}
+
+// renewsTokens returns whether the app's token source gets it new tokens as
+// they expire by itself. Only password grants need to be authorized again.
+func (a App) renewsTokens() bool {
+	return a.RefreshToken != "" || a.Installed ||
+		a.Username == "" || a.Password == ""
+}
+
+// password returns the password to authorize with, including the two factor
+// code if there is one.
+func (a App) password() (string, error) {
+	otp := a.OTP
+	if a.OTPFunc != nil {
+		var err error
+		if otp, err = a.OTPFunc(); err != nil {
+			return "", err
+		}
+	}
+
+	if otp == "" {
+		return a.Password, nil
+	}
+
+	return a.Password + ":" + otp, nil
+}
+
+// NewDeviceID returns a random 30 character device id for an installed app.
+func NewDeviceID() (string, error) {
+	b := make([]byte, 15)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
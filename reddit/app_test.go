@@ -1,6 +1,7 @@
 package reddit
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -9,12 +10,14 @@ func TestAppUnauthenticated(t *testing.T) {
 		input  App
 		output bool
 	}{
-		{App{"y", "", "", "", ""}, true},
-		{App{"", "y", "", "", ""}, true},
-		{App{"y", "y", "", "", ""}, false},
-		{App{"y", "y", "y", "", ""}, false},
-		{App{"y", "y", "", "y", ""}, false},
-		{App{"y", "y", "y", "y", ""}, false},
+		{App{ID: "y"}, true},
+		{App{Secret: "y"}, true},
+		{App{ID: "y", Secret: "y"}, false},
+		{App{ID: "y", Secret: "y", Username: "y"}, false},
+		{App{ID: "y", Secret: "y", Password: "y"}, false},
+		{App{ID: "y", Secret: "y", Username: "y", Password: "y"}, false},
+		{App{ID: "y", Installed: true}, false},
+		{App{Installed: true}, true},
 	} {
 		if actual := test.input.unauthenticated(); actual != test.output {
 			t.Errorf("wrong on %d; wanted %v", i, test.output)
@@ -27,16 +30,91 @@ func TestAppValidateAuth(t *testing.T) {
 		input  App
 		output error
 	}{
-		{App{"", "", "", "", ""}, errMissingOauthCredentials},
-		{App{"y", "", "", "", ""}, errMissingOauthCredentials},
-		{App{"", "y", "", "", ""}, errMissingOauthCredentials},
-		{App{"y", "y", "y", "", ""}, errMissingPassword},
-		{App{"y", "y", "", "y", ""}, errMissingUsername},
-		{App{"y", "y", "", "", ""}, nil},
-		{App{"y", "y", "y", "y", ""}, nil},
+		{App{}, errMissingOauthCredentials},
+		{App{ID: "y"}, errMissingOauthCredentials},
+		{App{Secret: "y"}, errMissingOauthCredentials},
+		{App{ID: "y", Secret: "y", Username: "y"}, errMissingPassword},
+		{App{ID: "y", Secret: "y", Password: "y"}, errMissingUsername},
+		{App{ID: "y", Secret: "y"}, nil},
+		{App{ID: "y", Secret: "y", Username: "y", Password: "y"}, nil},
+		{App{ID: "y", Installed: true}, nil},
+		{App{ID: "y", Installed: true, DeviceID: "short"}, errInvalidDeviceID},
+		{
+			App{
+				ID:        "y",
+				Installed: true,
+				DeviceID:  "0123456789012345678901234567890",
+			},
+			errInvalidDeviceID,
+		},
+		{
+			App{
+				ID:        "y",
+				Installed: true,
+				DeviceID:  "DO_NOT_TRACK_THIS_DEVICE",
+			},
+			nil,
+		},
 	} {
 		if actual := test.input.validateAuth(); actual != test.output {
 			t.Errorf("wrong on %d; wanted %v", i, test.output)
 		}
 	}
 }
+
+func TestNewDeviceID(t *testing.T) {
+	id, err := NewDeviceID()
+	if err != nil {
+		t.Fatalf("failed to generate device id: %v", err)
+	}
+
+	if err := (App{ID: "y", Installed: true, DeviceID: id}).validateAuth(); err != nil {
+		t.Errorf("generated device id %q is invalid: %v", id, err)
+	}
+}
+
+func TestAppPassword(t *testing.T) {
+	otpErr := fmt.Errorf("no code")
+	for i, test := range []struct {
+		input    App
+		password string
+		err      error
+	}{
+		{App{Password: "pass"}, "pass", nil},
+		{App{Password: "pass", OTP: "123456"}, "pass:123456", nil},
+		{
+			App{
+				Password: "pass",
+				OTP:      "123456",
+				OTPFunc:  func() (string, error) { return "654321", nil },
+			},
+			"pass:654321", nil,
+		},
+		{
+			App{
+				Password: "pass",
+				OTPFunc:  func() (string, error) { return "", otpErr },
+			},
+			"", otpErr,
+		},
+	} {
+		password, err := test.input.password()
+		if err != test.err {
+			t.Errorf("unexpected error on %d: %v", i, err)
+		} else if password != test.password {
+			t.Errorf("wrong on %d; got %q, wanted %q", i, password, test.password)
+		}
+	}
+}
+
+func TestWithEndpoints(t *testing.T) {
+	app := App{}.withEndpoints()
+	if app.TokenURL != tokenURL || app.RevokeURL != revokeURL {
+		t.Errorf("got endpoints %q, %q; wanted Reddit's", app.TokenURL, app.RevokeURL)
+	}
+
+	app = App{TokenURL: "http://localhost/token"}.withEndpoints()
+	if app.TokenURL != "http://localhost/token" || app.RevokeURL != revokeURL {
+		t.Errorf("got endpoints %q, %q; wanted the set one kept", app.TokenURL, app.RevokeURL)
+	}
+}
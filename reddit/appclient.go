@@ -1,7 +1,11 @@
 package reddit
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
@@ -17,74 +21,365 @@ var oauthScopes = []string{
 	"history",
 }
 
+// TokenInfo describes the OAuth2 token Reddit issued a bot, without the
+// token itself, for diagnosing authorization.
+type TokenInfo struct {
+	// Type is the token's type, usually "Bearer".
+	Type string
+	// Refreshable is whether Reddit issued a refresh token with the token.
+	// Script apps are not issued one; they reauthorize with the account's
+	// password when the token is about to expire instead.
+	Refreshable bool
+	// Expiry is when the token expires.
+	Expiry time.Time
+	// Scopes are the OAuth2 scopes Reddit granted the token.
+	Scopes []string
+	// DeviceID is the device id an installed app authorized with, which
+	// was generated if App.DeviceID was empty. It is empty for other apps.
+	DeviceID string
+}
+
 type appClient struct {
 	baseClient
-	cfg    clientConfig
-	cli    *http.Client
+	cfg clientConfig
+	cli *http.Client
+
+	// mu guards the authorization below and the client of baseClient,
+	// which requests made at once share.
+	mu     sync.Mutex
+	source oauth2.TokenSource
 	expiry time.Time
+	// revoked is set once the client's token is revoked, after which it
+	// refuses to make requests.
+	revoked bool
+}
+
+// credentialsKey is the context key which marks requests not to send the
+// client's credentials.
+type credentialsKey struct{}
+
+// withoutCredentials returns a context whose requests are sent without the
+// client's OAuth2 token, e.g. to hosts which are not trusted with it.
+func withoutCredentials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, true)
+}
+
+// sendsCredentials returns whether requests made with the context are sent
+// with the client's credentials.
+func sendsCredentials(ctx context.Context) bool {
+	without, _ := ctx.Value(credentialsKey{}).(bool)
+	return !without
 }
 
 func (a *appClient) Do(req *http.Request) ([]byte, error) {
-	if time.Until(a.expiry) < time.Minute*5 {
+	c, err := a.ready()
+	if err != nil {
+		return nil, err
+	}
+	if !sendsCredentials(req.Context()) {
+		c.cli = a.cli
+	}
+
+	resp, err := c.Do(req)
+	return resp, authError(err)
+}
+
+func (a *appClient) DoStream(req *http.Request, dst interface{}) error {
+	c, err := a.ready()
+	if err != nil {
+		return err
+	}
+	if !sendsCredentials(req.Context()) {
+		c.cli = a.cli
+	}
+
+	return authError(c.DoStream(req, dst))
+}
+
+// setHeader sets a header on the client's requests. The authorized client
+// wraps the transport of cli, so that is where the headers are forwarded.
+func (a *appClient) setHeader(key, value string) {
+	setForwardedHeader(a.cli, key, value)
+}
+
+// ready prepares the client to make a request, reauthorizing it if its token
+// is about to expire, and returns the client to make it with.
+func (a *appClient) ready() (*baseClient, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.revoked {
+		return nil, TokenRevokedErr
+	}
+
+	// Tokens from refresh tokens, installed app grants and client
+	// credentials are renewed by their source, which reuses each until it
+	// expires.
+	renewed := a.cfg.app.renewsTokens() && a.source != nil
+	if !renewed && time.Until(a.expiry) < time.Minute*5 {
 		if err := a.authorize(); err != nil {
 			return nil, err
 		}
 	}
 
-	return a.baseClient.Do(req)
+	c := a.baseClient
+	return &c, nil
 }
 
+// authorize gets the client a new token. The caller must hold mu, unless the
+// client is not yet shared.
 func (a *appClient) authorize() error {
 	ctx := context.WithValue(oauth2.NoContext, oauth2.HTTPClient, a.cli)
 
-	if a.cfg.app.Username == "" || a.cfg.app.Password == "" {
-		a.baseClient.cli = a.clientCredentialsClient(ctx)
+	if a.cfg.app.RefreshToken != "" {
+		a.source = a.refreshSource()
+		a.baseClient.cli = oauth2.NewClient(ctx, a.source)
 		return nil
 	}
 
-	cfg := &oauth2.Config{
-		ClientID:     a.cfg.app.ID,
-		ClientSecret: a.cfg.app.Secret,
-		Endpoint:     oauth2.Endpoint{TokenURL: a.cfg.app.tokenURL},
-		Scopes:       oauthScopes,
+	if a.cfg.app.Installed {
+		a.source = a.installedConfig().TokenSource(a.tokenContext())
+		a.baseClient.cli = oauth2.NewClient(ctx, a.source)
+		return nil
 	}
 
-	token, err := cfg.PasswordCredentialsToken(
-		ctx,
-		a.cfg.app.Username,
-		a.cfg.app.Password,
-	)
+	if a.cfg.app.Username == "" || a.cfg.app.Password == "" {
+		a.source = a.clientCredentialsConfig().TokenSource(a.tokenContext())
+		a.baseClient.cli = oauth2.NewClient(ctx, a.source)
+		return nil
+	}
+
+	token, err := a.token()
 	if err != nil {
 		return err
 	}
 
-	a.baseClient.cli = cfg.Client(ctx, token)
+	a.source = a.passwordConfig().TokenSource(ctx, token)
+	a.baseClient.cli = oauth2.NewClient(ctx, a.source)
 	a.expiry = token.Expiry
 	return err
 }
 
-func (a *appClient) clientCredentialsClient(ctx context.Context) *http.Client {
-	cfg := &clientcredentials.Config{
+// revoke revokes the client's token at Reddit and stops the client from
+// making further requests. Reddit accepts revocation of tokens which are
+// already invalid. Requests already being made when the token is revoked
+// fail or succeed as Reddit answers them.
+func (a *appClient) revoke(ctx context.Context) error {
+	a.mu.Lock()
+	source := a.source
+	revoked := a.revoked
+	a.revoked = true
+	a.source = nil
+	a.mu.Unlock()
+
+	if revoked || source == nil {
+		return nil
+	}
+
+	token, err := source.Token()
+	if err != nil {
+		return authError(err)
+	}
+
+	form := url.Values{
+		"token":           {token.AccessToken},
+		"token_type_hint": {"access_token"},
+	}
+	req, err := http.NewRequest(
+		"POST",
+		a.cfg.app.RevokeURL,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(a.cfg.app.ID, a.cfg.app.Secret)
+
+	resp, err := a.cli.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	}
+
+	return fmt.Errorf("failed to revoke token: %s", resp.Status)
+}
+
+// tokenInfo describes the client's current token.
+func (a *appClient) tokenInfo() (*TokenInfo, error) {
+	if _, err := a.ready(); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	source := a.source
+	a.mu.Unlock()
+	if source == nil {
+		return nil, TokenRevokedErr
+	}
+
+	token, err := source.Token()
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	scope, _ := token.Extra("scope").(string)
+	return &TokenInfo{
+		Type:        token.Type(),
+		Refreshable: token.RefreshToken != "",
+		Expiry:      token.Expiry,
+		Scopes:      strings.Fields(scope),
+		DeviceID:    a.cfg.app.DeviceID,
+	}, nil
+}
+
+// token requests a new token for the app from Reddit.
+func (a *appClient) token() (*oauth2.Token, error) {
+	ctx := a.tokenContext()
+
+	var token *oauth2.Token
+	var err error
+	switch {
+	case a.cfg.app.RefreshToken != "":
+		token, err = a.refreshSource().Token()
+	case a.cfg.app.Installed:
+		token, err = a.installedConfig().Token(ctx)
+	case a.cfg.app.Username == "" || a.cfg.app.Password == "":
+		token, err = a.clientCredentialsConfig().Token(ctx)
+	default:
+		var password string
+		if password, err = a.cfg.app.password(); err != nil {
+			return nil, err
+		}
+
+		token, err = a.passwordConfig().PasswordCredentialsToken(
+			ctx,
+			a.cfg.app.Username,
+			password,
+		)
+	}
+
+	return token, authError(err)
+}
+
+// tokenContext returns the context for requests to the token endpoint.
+func (a *appClient) tokenContext() context.Context {
+	return context.WithValue(
+		oauth2.NoContext,
+		oauth2.HTTPClient,
+		&http.Client{
+			Transport: &tokenErrorTransport{a.cli.Transport},
+		},
+	)
+}
+
+func (a *appClient) passwordConfig() *oauth2.Config {
+	return &oauth2.Config{
 		ClientID:     a.cfg.app.ID,
 		ClientSecret: a.cfg.app.Secret,
-		TokenURL:     a.cfg.app.tokenURL,
-		Scopes:       oauthScopes,
+		Endpoint:     oauth2.Endpoint{TokenURL: a.cfg.app.TokenURL},
+		Scopes:       a.cfg.app.scopes(),
 	}
+}
 
-	return cfg.Client(ctx)
+func (a *appClient) clientCredentialsConfig() *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID:     a.cfg.app.ID,
+		ClientSecret: a.cfg.app.Secret,
+		TokenURL:     a.cfg.app.TokenURL,
+		Scopes:       a.cfg.app.scopes(),
+	}
 }
 
-func newAppClient(c clientConfig) (*appClient, error) {
-	var client *http.Client
-	if c.client == nil {
-		client = clientWithAgent(c.agent)
-	} else {
-		client = patchWithAgent(c.client, c.agent)
+// refreshSource returns a source of the tokens the app's refresh token grants,
+// which gets the first when it is first asked for one.
+func (a *appClient) refreshSource() oauth2.TokenSource {
+	return AuthCodeOptions{}.config(a.cfg.app).TokenSource(
+		a.tokenContext(),
+		&oauth2.Token{RefreshToken: a.cfg.app.RefreshToken},
+	)
+}
+
+// installedConfig returns the config of an installed app, which identifies
+// itself with the app's device id instead of a secret.
+func (a *appClient) installedConfig() *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID: a.cfg.app.ID,
+		TokenURL: a.cfg.app.TokenURL,
+		Scopes:   a.cfg.app.scopes(),
+		EndpointParams: url.Values{
+			"grant_type": {installedGrant},
+			"device_id":  {a.cfg.app.DeviceID},
+		},
+		AuthStyle: oauth2.AuthStyleInHeader,
 	}
+}
 
-	a := &appClient{
-		cli: client,
-		cfg: c,
+// Authenticate requests a token from Reddit for the app, without making a bot
+// handle. It is useful for checking credentials. If Reddit rejects the
+// credentials, the error is one of InvalidCredentialsErr, InvalidClientErr,
+// TwoFactorRequiredErr, or AccountSuspendedErr; other errors, such as network
+// failures, are returned as they are.
+func Authenticate(agent string, app App) (*oauth2.Token, error) {
+	if err := app.validateAuth(); err != nil {
+		return nil, err
+	}
+
+	a, err := unauthorizedAppClient(
+		clientConfig{agent: agent, app: app.withEndpoints()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.token()
+}
+
+func newAppClient(c clientConfig) (*appClient, error) {
+	a, err := unauthorizedAppClient(c)
+	if err != nil {
+		return nil, err
 	}
+
 	return a, a.authorize()
 }
+
+// unauthorizedAppClient returns an appClient which has not yet authorized.
+func unauthorizedAppClient(c clientConfig) (*appClient, error) {
+	if c.app.Installed && c.app.DeviceID == "" {
+		id, err := NewDeviceID()
+		if err != nil {
+			return nil, err
+		}
+		c.app.DeviceID = id
+	}
+
+	client := c.client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	client, err := withTransport(client, c.tls, c.connections)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appClient{
+		baseClient: baseClient{
+			retryer:     c.retryer,
+			clock:       c.clock,
+			traceHeader: c.traceHeader,
+			onRequest:   c.onRequest,
+			quota:       newQuota(c.waitOnExhaustion),
+			inFlight:    newInFlight(c.maxConcurrentRequests),
+			codec:       c.codec,
+		},
+		cli: patchWithAgent(client, c.agent, c.headers),
+		cfg: c,
+	}, nil
+}
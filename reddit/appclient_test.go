@@ -0,0 +1,929 @@
+package reddit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServerWhich returns a fake OAuth2 token endpoint which reports the form
+// of each token request it receives on the given channel.
+func tokenServerWhich(forms chan<- url.Values) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				forms <- r.PostForm
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{
+					"access_token": "token",
+					"token_type": "bearer",
+					"expires_in": 3600
+				}`))
+			},
+		),
+	)
+}
+
+// jsonServerWhich is a serverWhich which labels its body as json, as Reddit's
+// token endpoint does.
+func jsonServerWhich(body []byte, code int) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(code)
+				w.Write(body)
+			},
+		),
+	)
+}
+
+func TestInstalledAppAuthorization(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	api := serverWhich([]byte("{}"), http.StatusOK)
+	defer api.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:        "id",
+				Installed: true,
+				TokenURL:  tokens.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+
+	req, err := http.NewRequest("GET", api.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	form := <-forms
+	if grant := form.Get("grant_type"); grant != installedGrant {
+		t.Errorf("got grant type %q; wanted %q", grant, installedGrant)
+	}
+
+	if id := form.Get("device_id"); id != c.cfg.app.DeviceID {
+		t.Errorf("got device id %q; wanted %q", id, c.cfg.app.DeviceID)
+	} else if len(id) != 30 {
+		t.Errorf("generated device id has wrong length: %q", id)
+	}
+
+	info, err := c.tokenInfo()
+	if err != nil {
+		t.Fatalf("failed to describe token: %v", err)
+	}
+	if info.DeviceID != form.Get("device_id") {
+		t.Errorf("got device id %q in token info; wanted %q", info.DeviceID, form.Get("device_id"))
+	}
+}
+
+func TestTokenReuse(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		app  App
+	}{
+		{"installed", App{ID: "id", Installed: true}},
+		{"client credentials", App{ID: "id", Secret: "secret"}},
+	} {
+		forms := make(chan url.Values, 10)
+		tokens := tokenServerWhich(forms)
+		api := serverWhich([]byte("{}"), http.StatusOK)
+
+		app := test.app
+		app.TokenURL = tokens.URL
+		c, err := newAppClient(clientConfig{agent: "agent", app: app})
+		if err != nil {
+			t.Fatalf("failed to make client: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			req, err := http.NewRequest("GET", api.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to prepare request for test: %v", err)
+			}
+			if _, err := c.Do(req); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+		}
+		if _, err := c.tokenInfo(); err != nil {
+			t.Fatalf("failed to describe token: %v", err)
+		}
+
+		if n := len(forms); n != 1 {
+			t.Errorf("[%s] requested %d tokens for two requests; wanted 1", test.name, n)
+		}
+		tokens.Close()
+		api.Close()
+	}
+}
+
+func TestAppScopes(t *testing.T) {
+	for _, test := range []struct {
+		scopes []string
+		scope  string
+	}{
+		{nil, "identity read privatemessages submit history"},
+		{[]string{"identity", "flair"}, "identity flair"},
+	} {
+		forms := make(chan url.Values, 1)
+		tokens := tokenServerWhich(forms)
+
+		_, err := newAppClient(
+			clientConfig{
+				agent: "agent",
+				app: App{
+					ID:       "id",
+					Secret:   "secret",
+					Username: "user",
+					Password: "password",
+					Scopes:   test.scopes,
+					TokenURL: tokens.URL,
+				},
+			},
+		)
+		tokens.Close()
+		if err != nil {
+			t.Fatalf("failed to make client: %v", err)
+		}
+
+		if scope := (<-forms).Get("scope"); scope != test.scope {
+			t.Errorf("asked for scopes %q; wanted %q", scope, test.scope)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		code  int
+		body  string
+		token string
+		err   error
+	}{
+		{
+			"success", http.StatusOK,
+			`{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`,
+			"token", nil,
+		},
+		{
+			"bad client", http.StatusUnauthorized,
+			`{"message": "Unauthorized", "error": 401}`,
+			"", InvalidClientErr,
+		},
+		{
+			"bad password", http.StatusOK,
+			`{"error": "invalid_grant"}`,
+			"", InvalidCredentialsErr,
+		},
+		{
+			"missing otp", http.StatusOK,
+			`{"error": "invalid_grant", "error_description": "missing two-factor code"}`,
+			"", TwoFactorRequiredErr,
+		},
+		{
+			"suspended", http.StatusForbidden,
+			`{"message": "account suspended", "error": 403}`,
+			"", AccountSuspendedErr,
+		},
+	} {
+		tokens := jsonServerWhich([]byte(test.body), test.code)
+		token, err := Authenticate(
+			"agent",
+			App{
+				ID:       "id",
+				Secret:   "secret",
+				Username: "user",
+				Password: "password",
+				TokenURL: tokens.URL,
+			},
+		)
+		tokens.Close()
+
+		if err != test.err {
+			t.Errorf("[%s] unexpected error: %v", test.name, err)
+		} else if err == nil && token.AccessToken != test.token {
+			t.Errorf("[%s] got token %q; wanted %q", test.name, token.AccessToken, test.token)
+		}
+	}
+}
+
+func TestAuthenticateNetworkError(t *testing.T) {
+	tokens := serverWhich(nil, http.StatusOK)
+	tokens.Close()
+
+	_, err := Authenticate(
+		"agent",
+		App{ID: "id", Secret: "secret", TokenURL: tokens.URL},
+	)
+	if err == nil || err == InvalidCredentialsErr {
+		t.Errorf("wanted network error; got %v", err)
+	}
+}
+
+func TestAppClientAuthError(t *testing.T) {
+	tokens := jsonServerWhich(
+		[]byte(`{"message": "Unauthorized", "error": 401}`),
+		http.StatusUnauthorized,
+	)
+	defer tokens.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app:   App{ID: "id", Secret: "secret", TokenURL: tokens.URL},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+
+	req, err := http.NewRequest("GET", tokens.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	if _, err := c.Do(req); err != InvalidClientErr {
+		t.Errorf("wanted InvalidClientErr; got %v", err)
+	}
+}
+
+func TestPasswordGrantOTP(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	if _, err := Authenticate(
+		"agent",
+		App{
+			ID:       "id",
+			Secret:   "secret",
+			Username: "user",
+			Password: "password",
+			OTPFunc:  func() (string, error) { return "123456", nil },
+			TokenURL: tokens.URL,
+		},
+	); err != nil {
+		t.Fatalf("failed to authenticate: %v", err)
+	}
+
+	form := <-forms
+	if password := form.Get("password"); password != "password:123456" {
+		t.Errorf("got password %q; wanted %q", password, "password:123456")
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	revocations := make(chan *http.Request, 1)
+	revoke := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				revocations <- r
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	defer revoke.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:        "id",
+				Secret:    "secret",
+				Username:  "user",
+				Password:  "password",
+				TokenURL:  tokens.URL,
+				RevokeURL: revoke.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+	<-forms
+
+	b := &bot{cli: c}
+	if err := b.RevokeToken(context.Background()); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+
+	r := <-revocations
+	if token := r.PostForm.Get("token"); token != "token" {
+		t.Errorf("revoked token %q; wanted %q", token, "token")
+	}
+	if hint := r.PostForm.Get("token_type_hint"); hint != "access_token" {
+		t.Errorf("sent token type hint %q", hint)
+	}
+	if id, secret, ok := r.BasicAuth(); !ok || id != "id" || secret != "secret" {
+		t.Errorf("revocation had wrong credentials: %s:%s", id, secret)
+	}
+
+	req, err := http.NewRequest("GET", tokens.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+	if _, err := c.Do(req); err != TokenRevokedErr {
+		t.Errorf("wanted TokenRevokedErr after revocation; got %v", err)
+	}
+}
+
+func TestRevokeFailure(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	revoke := serverWhich(nil, http.StatusUnauthorized)
+	defer revoke.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:        "id",
+				Secret:    "secret",
+				Username:  "user",
+				Password:  "password",
+				TokenURL:  tokens.URL,
+				RevokeURL: revoke.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+	<-forms
+
+	if err := c.revoke(context.Background()); err == nil {
+		t.Errorf("wanted error from failed revocation")
+	}
+
+	if err := c.revoke(context.Background()); err != nil {
+		t.Errorf("second revocation failed: %v", err)
+	}
+}
+
+func TestRevokeWhileRequesting(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	revoke := serverWhich(nil, http.StatusNoContent)
+	defer revoke.Close()
+
+	api := serverWhich([]byte("{}"), http.StatusOK)
+	defer api.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:        "id",
+				Secret:    "secret",
+				Username:  "user",
+				Password:  "password",
+				TokenURL:  tokens.URL,
+				RevokeURL: revoke.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+	<-forms
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				req, err := http.NewRequest("GET", api.URL, nil)
+				if err != nil {
+					t.Errorf("failed to prepare request for test: %v", err)
+					return
+				}
+				if _, err := c.Do(req); err != nil && err != TokenRevokedErr {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.revoke(ctx); err != nil {
+		t.Errorf("failed to revoke token: %v", err)
+	}
+	wg.Wait()
+}
+
+func TestRevokeContext(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	revoke := serverWhich(nil, http.StatusNoContent)
+	defer revoke.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:        "id",
+				Secret:    "secret",
+				Username:  "user",
+				Password:  "password",
+				TokenURL:  tokens.URL,
+				RevokeURL: revoke.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+	<-forms
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.revoke(ctx); err == nil {
+		t.Errorf("wanted error revoking with a done context")
+	}
+
+	req, err := http.NewRequest("GET", tokens.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+	if _, err := c.Do(req); err != TokenRevokedErr {
+		t.Errorf("wanted TokenRevokedErr after revocation; got %v", err)
+	}
+}
+
+func TestWithoutCredentials(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := tokenServerWhich(forms)
+	defer tokens.Close()
+
+	auths := make(chan string, 1)
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				auths <- r.Header.Get("Authorization")
+				w.Write([]byte("{}"))
+			},
+		),
+	)
+	defer serv.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:       "id",
+				Secret:   "secret",
+				Username: "user",
+				Password: "password",
+				TokenURL: tokens.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+	<-forms
+
+	for _, test := range []struct {
+		ctx  context.Context
+		auth bool
+	}{
+		{context.Background(), true},
+		{withoutCredentials(context.Background()), false},
+	} {
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+		if _, err := c.Do(req.WithContext(test.ctx)); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if auth := <-auths; (auth != "") != test.auth {
+			t.Errorf("got Authorization %q; wanted it sent: %t", auth, test.auth)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tokens := jsonServerWhich(
+		[]byte(`{
+			"access_token": "token",
+			"token_type": "bearer",
+			"expires_in": 3600,
+			"scope": "identity read submit"
+		}`),
+		http.StatusOK,
+	)
+	defer tokens.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:       "id",
+				Secret:   "secret",
+				Username: "user",
+				Password: "password",
+				TokenURL: tokens.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+
+	b := &bot{cli: c}
+	if ok, err := b.HasScope("submit"); err != nil || !ok {
+		t.Errorf("wanted submit scope; got %v, %v", ok, err)
+	}
+	if ok, err := b.HasScope("modposts"); err != nil || ok {
+		t.Errorf("wanted no modposts scope; got %v, %v", ok, err)
+	}
+
+	if err := b.RequireScopes("identity", "read"); err != nil {
+		t.Errorf("unexpected error for granted scopes: %v", err)
+	}
+
+	err = b.RequireScopes("read", "modposts", "flair")
+	if missing, ok := err.(*MissingScopesError); !ok {
+		t.Errorf("wanted MissingScopesError; got %v", err)
+	} else if strings.Join(missing.Scopes, " ") != "modposts flair" {
+		t.Errorf("got missing scopes %v; wanted [modposts flair]", missing.Scopes)
+	}
+}
+
+// scopedClient is a client whose token was granted the scopes.
+type scopedClient struct {
+	mockClient
+	scopes []string
+}
+
+func (s *scopedClient) tokenInfo() (*TokenInfo, error) {
+	return &TokenInfo{Scopes: s.scopes}, nil
+}
+
+// countedClient is a scopedClient which counts how often its token is looked
+// up, and whose token expires at expiry.
+type countedClient struct {
+	scopedClient
+	expiry  time.Time
+	lookups int
+}
+
+func (c *countedClient) tokenInfo() (*TokenInfo, error) {
+	c.lookups++
+	return &TokenInfo{Scopes: c.scopes, Expiry: c.expiry}, nil
+}
+
+func TestScopesCached(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	c := &countedClient{
+		scopedClient: scopedClient{scopes: []string{"read"}},
+		expiry:       clock.Now().Add(time.Hour),
+	}
+	b := &bot{cli: c, scopes: scopeCache{clock: clock}}
+
+	for i := 0; i < 3; i++ {
+		if err := b.RequireScopes("read"); err != nil {
+			t.Fatalf("unexpected error for granted scope: %v", err)
+		}
+	}
+	if ok, err := b.HasScope("modconfig"); err != nil || ok {
+		t.Errorf("wanted no modconfig scope; got %v, %v", ok, err)
+	}
+	if c.lookups != 1 {
+		t.Errorf("looked the token up %d times; wanted 1", c.lookups)
+	}
+
+	c.scopes = []string{"read", "modconfig"}
+	clock.Advance(time.Hour)
+	if ok, err := b.HasScope("modconfig"); err != nil || !ok {
+		t.Errorf("wanted the next token's modconfig scope; got %v, %v", ok, err)
+	}
+	if c.lookups != 2 {
+		t.Errorf("looked the token up %d times after it expired; wanted 2", c.lookups)
+	}
+
+	if err := b.RevokeToken(context.Background()); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+	b.HasScope("read")
+	if c.lookups != 3 {
+		t.Errorf("looked the token up %d times after revoking it; wanted 3", c.lookups)
+	}
+}
+
+func TestSubredditTraffic(t *testing.T) {
+	r := &mockReaper{raw: []byte(trafficResponse)}
+	b := &bot{cli: &scopedClient{scopes: []string{"read", "modconfig"}}, r: r}
+
+	traffic, err := b.SubredditTraffic("/r/golang")
+	if err != nil {
+		t.Fatalf("failed to get traffic: %v", err)
+	}
+	if r.path != "/r/golang/about/traffic" {
+		t.Errorf("requested %s; wanted /r/golang/about/traffic", r.path)
+	}
+	if len(traffic.Hours) != 2 || len(traffic.Days) != 2 || len(traffic.Months) != 1 {
+		t.Errorf("got traffic %+v; wanted 2 hours, 2 days and 1 month", traffic)
+	}
+
+	r = &mockReaper{err: PermissionDeniedErr}
+	b = &bot{cli: &scopedClient{scopes: []string{"modconfig"}}, r: r}
+	if _, err := b.SubredditTraffic("golang"); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+
+	r = &mockReaper{raw: []byte(trafficResponse)}
+	b = &bot{cli: &scopedClient{scopes: []string{"read"}}, r: r}
+	_, err = b.SubredditTraffic("golang")
+	if missing, ok := err.(*MissingScopesError); !ok || len(missing.Scopes) != 1 ||
+		missing.Scopes[0] != "modconfig" {
+		t.Errorf("wanted modconfig to be missing; got %v", err)
+	}
+	if r.path != "" {
+		t.Errorf("requested %s without the scope", r.path)
+	}
+}
+
+// requestReaper is a mockReaper which keeps the last Request it is sent.
+type requestReaper struct {
+	mockReaper
+	req Request
+}
+
+func (r *requestReaper) send(req Request) ([]byte, error) {
+	r.req = req
+	return r.mockReaper.send(req)
+}
+
+func TestPrefs(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{
+		"lang": "en",
+		"nightmode": true,
+		"min_comment_score": -4,
+		"accept_pms": "everyone",
+		"beta": false
+	}`)}
+	b := &bot{cli: &scopedClient{scopes: []string{"identity"}}, r: r}
+
+	prefs, err := b.Prefs()
+	if err != nil {
+		t.Fatalf("failed to read prefs: %v", err)
+	}
+	if r.path != "/api/v1/me/prefs" {
+		t.Errorf("read prefs from %s; wanted /api/v1/me/prefs", r.path)
+	}
+	if prefs.Lang == nil || *prefs.Lang != "en" ||
+		prefs.NightMode == nil || !*prefs.NightMode ||
+		prefs.MinCommentScore == nil || *prefs.MinCommentScore != -4 {
+		t.Errorf("prefs parsed incorrectly: %+v", prefs)
+	}
+	if prefs.Over18 != nil {
+		t.Errorf("wanted prefs Reddit did not send to be nil")
+	}
+}
+
+func TestUpdatePrefs(t *testing.T) {
+	r := &requestReaper{}
+	b := &bot{cli: &scopedClient{scopes: []string{"account"}}, r: r}
+
+	nightMode, sort := false, "new"
+	if err := b.UpdatePrefs(Prefs{
+		NightMode:          &nightMode,
+		DefaultCommentSort: &sort,
+	}); err != nil {
+		t.Fatalf("failed to update prefs: %v", err)
+	}
+
+	if r.req.Method != "PATCH" || r.req.Path != "/api/v1/me/prefs" {
+		t.Errorf("sent %s %s; wanted PATCH /api/v1/me/prefs", r.req.Method, r.req.Path)
+	}
+	if body := string(r.req.JSON); body != `{"default_comment_sort":"new","nightmode":false}` {
+		t.Errorf("sent %s; wanted only the set prefs", body)
+	}
+
+	b = &bot{cli: &scopedClient{scopes: []string{"identity"}}, r: r}
+	if _, ok := b.UpdatePrefs(Prefs{}).(*MissingScopesError); !ok {
+		t.Errorf("wanted MissingScopesError without the account scope")
+	}
+}
+
+func TestTokenInfo(t *testing.T) {
+	for _, test := range []struct {
+		token       string
+		refreshable bool
+	}{
+		{`{
+			"access_token": "token",
+			"token_type": "bearer",
+			"expires_in": 3600,
+			"scope": "identity read"
+		}`, false},
+		{`{
+			"access_token": "token",
+			"refresh_token": "refresh",
+			"token_type": "bearer",
+			"expires_in": 3600,
+			"scope": "identity read"
+		}`, true},
+	} {
+		tokens := jsonServerWhich([]byte(test.token), http.StatusOK)
+
+		c, err := newAppClient(
+			clientConfig{
+				agent: "agent",
+				app: App{
+					ID:       "id",
+					Secret:   "secret",
+					Username: "user",
+					Password: "password",
+					TokenURL: tokens.URL,
+				},
+			},
+		)
+		if err != nil {
+			tokens.Close()
+			t.Fatalf("failed to make client: %v", err)
+		}
+
+		info, err := (&bot{cli: c}).TokenInfo()
+		tokens.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if info.Type != "Bearer" ||
+			info.Refreshable != test.refreshable ||
+			strings.Join(info.Scopes, " ") != "identity read" {
+			t.Errorf("token info incorrect: %+v", info)
+		}
+
+		if until := time.Until(info.Expiry); until < 59*time.Minute || until > time.Hour {
+			t.Errorf("token expires in %v; wanted an hour", until)
+		}
+	}
+}
+
+func TestNewBotTokenURL(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	tokens := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				forms <- r.PostForm
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{
+					"access_token": "token",
+					"token_type": "bearer",
+					"expires_in": 3600,
+					"scope": "*"
+				}`))
+			},
+		),
+	)
+	defer tokens.Close()
+
+	b, err := NewBot(
+		BotConfig{
+			Agent: "agent",
+			App: App{
+				ID:       "id",
+				Secret:   "secret",
+				Username: "user",
+				Password: "password",
+				TokenURL: tokens.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make bot: %v", err)
+	}
+
+	form := <-forms
+	if form.Get("grant_type") != "password" || form.Get("username") != "user" {
+		t.Errorf("token endpoint got form %v; wanted a password grant", form)
+	}
+
+	if ok, err := b.HasScope("read"); err != nil || !ok {
+		t.Errorf("wanted the fake endpoint's token; got %v, %v", ok, err)
+	}
+}
+
+func TestRefreshTokenAuthorization(t *testing.T) {
+	forms := make(chan url.Values, 2)
+	tokens := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				forms <- r.PostForm
+				w.Header().Set("Content-Type", "application/json")
+				// Tokens expire within the oauth2 package's margin,
+				// so each request refreshes.
+				w.Write([]byte(`{
+					"access_token": "token",
+					"token_type": "bearer",
+					"expires_in": 1
+				}`))
+			},
+		),
+	)
+	defer tokens.Close()
+
+	auths := make(chan string, 2)
+	api := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				auths <- r.Header.Get("Authorization")
+				w.Write([]byte("{}"))
+			},
+		),
+	)
+	defer api.Close()
+
+	c, err := newAppClient(
+		clientConfig{
+			agent: "agent",
+			app: App{
+				ID:           "id",
+				Secret:       "secret",
+				RefreshToken: "refresh",
+				TokenURL:     tokens.URL,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to make client: %v", err)
+	}
+
+	select {
+	case form := <-forms:
+		t.Errorf("client refreshed before its first request: %v", form)
+	default:
+	}
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", api.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+
+		select {
+		case form := <-forms:
+			if form.Get("grant_type") != "refresh_token" ||
+				form.Get("refresh_token") != "refresh" {
+				t.Errorf("token endpoint got form %v; wanted a refresh", form)
+			}
+		default:
+			t.Errorf("request %d did not refresh the expired token", i)
+		}
+
+		if auth := <-auths; auth != "Bearer token" {
+			t.Errorf("got authorization %q; wanted the refreshed token", auth)
+		}
+	}
+
+	if err := (App{
+		ID:           "id",
+		Secret:       "secret",
+		RefreshToken: "refresh",
+		Username:     "user",
+		Password:     "password",
+	}).validateAuth(); err != errRefreshTokenAndPassword {
+		t.Errorf("wanted errRefreshTokenAndPassword; got %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBatchRetries is the most times a request in a batch is retried when
+// Reddit rate limits it.
+const maxBatchRetries = 3
+
+// defaultBatchConcurrency is the most requests VoteMany and SaveMany have in
+// flight at once by default.
+const defaultBatchConcurrency = 4
+
+// Request is a request to a Reddit endpoint, for making many in a batch.
+type Request struct {
+	// Method is "GET", "POST" or "PATCH". Batch only makes GETs and
+	// POSTs.
+	Method string
+	// Path is the path of the endpoint, e.g. "/api/remove".
+	Path string
+	// Values are the query parameters of a GET, or the form values of a
+	// POST or PATCH.
+	Values map[string]string
+	// Host, if set, is the host to send the request to instead of the
+	// bot's, e.g. "www.reddit.com" or a proxy. The bot's credentials are
+	// only sent to hosts in BotConfig.TrustedHosts; requests to others
+	// are sent without them. Hosts which only serve reads, like
+	// www.reddit.com, refuse POSTs and PATCHes before they are sent.
+	Host string
+	// JSON, if set, is sent as the json body of a POST or PATCH, for
+	// endpoints which take json rather than form values.
+	JSON []byte
+	// Context, if set, cancels the request when it is done.
+	Context context.Context
+	// TraceID, if set, tags the request for correlation with the caller's
+	// own traces, in place of any trace id in Context. See WithTraceID.
+	TraceID string
+}
+
+// Batcher makes many requests without exceeding the rate limit.
+type Batcher interface {
+	// Batch makes the requests one after another, each waiting its turn
+	// under the bot's Rate. A request Reddit rate limits is retried after
+	// waiting, up to a few times, for as long as Reddit asks if it says.
+	// Results and errors are returned in the order of the requests; result
+	// i is the response body of request i, or nil if error i is not. Error
+	// i holds the errors Reddit reports in the response body, such as a
+	// RateLimitError, as well as those of the request itself.
+	Batch(reqs []Request) ([][]byte, []error)
+
+	// VoteMany votes on many posts and comments, by full name: 1 is an
+	// upvote, -1 a downvote and 0 takes the vote back. Reddit has no
+	// endpoint for voting on many at once, so the votes are made
+	// separately, a few at a time, each waiting its turn under the bot's
+	// Rate. The result holds the error of each vote made, nil if it
+	// succeeded. If Reddit keeps rate limiting the votes or the retry
+	// budget is spent, the votes not yet made are left out of the result.
+	VoteMany(votes map[string]int) map[string]error
+	// SaveMany saves many posts and comments, by full name, as VoteMany
+	// votes on them.
+	SaveMany(names []string) map[string]error
+}
+
+// batcherConfig configures a Batcher.
+type batcherConfig struct {
+	// clock times the waits out of Reddit's rate limit. It is the system
+	// clock if nil.
+	clock Clock
+	// concurrency is the most requests VoteMany and SaveMany have in
+	// flight at once. It is defaultBatchConcurrency if zero.
+	concurrency int
+}
+
+type batcher struct {
+	r reaper
+	// sleep waits out Reddit's rate limit.
+	sleep func(time.Duration)
+	// concurrency is the most requests fanned out at once. It is
+	// defaultBatchConcurrency if zero.
+	concurrency int
+}
+
+func newBatcher(r reaper, c batcherConfig) Batcher {
+	clock := clockOrReal(c.clock)
+	return &batcher{
+		r:           r,
+		sleep:       func(d time.Duration) { <-clock.After(d) },
+		concurrency: c.concurrency,
+	}
+}
+
+func (b *batcher) Batch(reqs []Request) ([][]byte, []error) {
+	results := make([][]byte, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		results[i], errs[i] = b.do(req)
+	}
+	return results, errs
+}
+
+// do makes a request, retrying while Reddit rate limits it. It waits as long
+// as Reddit asks when its response says, and backs off otherwise.
+func (b *batcher) do(req Request) ([]byte, error) {
+	backoff := defaultRateLimitWait
+	for retries := 0; ; retries++ {
+		resp, err := b.send(req)
+		if _, limited := RateLimitScopeOf(err); !limited || retries == maxBatchRetries {
+			return resp, err
+		}
+
+		if limit, ok := err.(*RateLimitError); ok {
+			b.sleep(limit.Wait)
+			continue
+		}
+
+		b.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (b *batcher) VoteMany(votes map[string]int) map[string]error {
+	results := map[string]error{}
+	reqs := map[string]Request{}
+	for name, dir := range votes {
+		if dir < -1 || dir > 1 {
+			results[name] = fmt.Errorf("invalid vote direction %d", dir)
+			continue
+		}
+
+		reqs[name] = Request{
+			Method: "POST",
+			Path:   "/api/vote",
+			Values: map[string]string{"id": name, "dir": strconv.Itoa(dir)},
+		}
+	}
+
+	b.fanOut(reqs, results)
+	return results
+}
+
+func (b *batcher) SaveMany(names []string) map[string]error {
+	results := map[string]error{}
+	reqs := map[string]Request{}
+	for _, name := range names {
+		reqs[name] = Request{
+			Method: "POST",
+			Path:   "/api/save",
+			Values: map[string]string{"id": name},
+		}
+	}
+
+	b.fanOut(reqs, results)
+	return results
+}
+
+// fanOut makes the requests, by the name of the item each is for, a few at a
+// time, recording their errors in results. It stops making them once one
+// fails because Reddit will not take more.
+func (b *batcher) fanOut(reqs map[string]Request, results map[string]error) {
+	names := make([]string, 0, len(reqs))
+	for name := range reqs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	concurrency := b.concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	slots := make(chan struct{}, concurrency)
+	stopped := false
+	for _, name := range names {
+		slots <- struct{}{}
+
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop {
+			break
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			_, err := b.do(reqs[name])
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = err
+			if _, limited := RateLimitScopeOf(err); limited ||
+				err == RetryBudgetExhaustedErr {
+				stopped = true
+			}
+		}(name)
+	}
+
+	wg.Wait()
+}
+
+// send makes a request, returning the errors in its response's json errors
+// envelope, if it has one, as its error.
+func (b *batcher) send(req Request) ([]byte, error) {
+	switch req.Method {
+	case "GET", "POST":
+	default:
+		return nil, fmt.Errorf("unsupported batch request method %q", req.Method)
+	}
+
+	resp, err := b.r.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := envelopeError(b.r.bodyCodec(), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SplitRequest returns the requests it takes to send items to an endpoint
+// which takes a comma separated list of them in the param value, e.g. the
+// "id" of /api/hide, when the endpoint takes at most max items at once.
+// Each request has the values of req and up to max of the items, in order.
+// Reddit rejects or quietly truncates lists which are too long.
+func SplitRequest(req Request, param string, items []string, max int) []Request {
+	reqs := []Request{}
+	for _, chunk := range chunks(items, max) {
+		values := map[string]string{}
+		for key, value := range req.Values {
+			values[key] = value
+		}
+		values[param] = strings.Join(chunk, ",")
+
+		split := req
+		split.Values = values
+		reqs = append(reqs, split)
+	}
+	return reqs
+}
+
+// reapSplit reaps a listing endpoint which takes a comma separated list of
+// items in the param value, at most max per request, and merges the
+// harvests of the requests in order.
+func reapSplit(
+	r reaper,
+	path string,
+	values map[string]string,
+	param string,
+	items []string,
+	max int,
+) (Harvest, error) {
+	h := Harvest{}
+	for _, req := range SplitRequest(Request{Path: path, Values: values}, param, items, max) {
+		p, err := r.reap(req.Path, req.Values)
+		if err != nil {
+			return h, err
+		}
+		h = merge(h, p)
+	}
+	return h, nil
+}
+
+// chunks splits items into consecutive groups of at most max items. There is
+// one group, of all the items, if max is less than 1.
+func chunks(items []string, max int) [][]string {
+	if max < 1 {
+		max = len(items)
+	}
+
+	groups := [][]string{}
+	for start := 0; start < len(items); start += max {
+		end := start + max
+		if end > len(items) {
+			end = len(items)
+		}
+		groups = append(groups, items[start:end])
+	}
+	return groups
+}
+
+// merge appends the elements of the harvest p to those of h. The result has
+// the After of p.
+func merge(h, p Harvest) Harvest {
+	h.Comments = append(h.Comments, p.Comments...)
+	h.Posts = append(h.Posts, p.Posts...)
+	h.Messages = append(h.Messages, p.Messages...)
+	h.Mores = append(h.Mores, p.Mores...)
+	h.Subreddits = append(h.Subreddits, p.Subreddits...)
+	h.Users = append(h.Users, p.Users...)
+	h.After = p.After
+	return h
+}
@@ -0,0 +1,256 @@
+package reddit
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestBatchPacing(t *testing.T) {
+	rate := 20 * time.Millisecond
+	b := newBatcher(
+		&reaperImpl{
+			cli:      &mockClient{response: []byte("{}")},
+			hostname: "reddit.com",
+			scheme:   "https",
+			rate:     rate,
+			mu:       &sync.Mutex{},
+		},
+		batcherConfig{},
+	)
+
+	reqs := []Request{
+		Request{Method: "POST", Path: "/api/remove"},
+		Request{Method: "POST", Path: "/api/remove"},
+		Request{Method: "GET", Path: "/api/info"},
+		Request{Method: "POST", Path: "/api/approve"},
+	}
+
+	start := time.Now()
+	results, errs := b.Batch(reqs)
+	if elapsed := time.Since(start); elapsed < time.Duration(len(reqs)-1)*rate {
+		t.Errorf("batch was not paced; took %v", elapsed)
+	}
+
+	if len(results) != len(reqs) || len(errs) != len(reqs) {
+		t.Fatalf("wanted %d results and errors; got %d, %d", len(reqs), len(results), len(errs))
+	}
+
+	for i := range reqs {
+		if errs[i] != nil || string(results[i]) != "{}" {
+			t.Errorf("request %d: got %q, %v", i, results[i], errs[i])
+		}
+	}
+}
+
+// limitedReaper rate limits the first limited POSTs it receives.
+type limitedReaper struct {
+	mockReaper
+	limited int
+}
+
+func (l *limitedReaper) send(req Request) ([]byte, error) {
+	l.path = req.Path
+	if req.Method == "POST" && l.limited > 0 {
+		l.limited--
+		return nil, RateLimitErr
+	}
+	return []byte("{}"), nil
+}
+
+func TestBatchBackoff(t *testing.T) {
+	r := &limitedReaper{limited: 2}
+	waits := []time.Duration{}
+	b := &batcher{r: r, sleep: func(d time.Duration) { waits = append(waits, d) }}
+
+	results, errs := b.Batch(
+		[]Request{
+			Request{Method: "POST", Path: "/api/remove"},
+			Request{Method: "DELETE", Path: "/api/remove"},
+		},
+	)
+
+	if errs[0] != nil || string(results[0]) != "{}" {
+		t.Errorf("rate limited request was not retried: %q, %v", results[0], errs[0])
+	}
+
+	if len(waits) != 2 || waits[1] != 2*waits[0] {
+		t.Errorf("wanted two increasing waits; got %v", waits)
+	}
+
+	if errs[1] == nil || results[1] != nil {
+		t.Errorf("wanted error for unsupported method; got %q, %v", results[1], errs[1])
+	}
+
+	r.limited = maxBatchRetries + 1
+	if _, errs := b.Batch([]Request{Request{Method: "POST"}}); errs[0] != RateLimitErr {
+		t.Errorf("wanted RateLimitErr after retries; got %v", errs[0])
+	}
+}
+
+// envelopeReaper answers with its responses in order, then with the last one.
+type envelopeReaper struct {
+	mockReaper
+	responses []string
+}
+
+func (e *envelopeReaper) send(req Request) ([]byte, error) {
+	resp := e.responses[0]
+	if len(e.responses) > 1 {
+		e.responses = e.responses[1:]
+	}
+	return []byte(resp), nil
+}
+
+func TestBatchEnvelopeErrors(t *testing.T) {
+	r := &envelopeReaper{
+		responses: []string{
+			`{"json": {"errors": [["RATELIMIT", "you are doing that too much. try again in 9 seconds.", "ratelimit"]]}}`,
+			`{"json": {"errors": []}}`,
+		},
+	}
+	waits := []time.Duration{}
+	b := &batcher{r: r, sleep: func(d time.Duration) { waits = append(waits, d) }}
+
+	results, errs := b.Batch([]Request{Request{Method: "POST", Path: "/api/comment"}})
+	if errs[0] != nil || results[0] == nil {
+		t.Errorf("rate limited write was not retried: %q, %v", results[0], errs[0])
+	}
+	if len(waits) != 1 || waits[0] != 9*time.Second {
+		t.Errorf("wanted to wait the 9 seconds Reddit asked; got %v", waits)
+	}
+
+	r.responses = []string{`{"json": {"errors": [["SUBREDDIT_NOEXIST", "that subreddit doesn't exist", "sr"]]}}`}
+	results, errs = b.Batch([]Request{Request{Method: "POST", Path: "/api/submit"}})
+	if errs[0] == nil || results[0] != nil {
+		t.Errorf("wanted the envelope's error; got %q, %v", results[0], errs[0])
+	}
+}
+
+func TestSplitRequest(t *testing.T) {
+	items := []string{"t3_a", "t3_b", "t3_c", "t3_d", "t3_e"}
+	reqs := SplitRequest(
+		Request{Method: "POST", Path: "/api/hide", Values: map[string]string{"api_type": "json"}},
+		"id",
+		items,
+		2,
+	)
+
+	if diff := pretty.Compare(
+		reqs,
+		[]Request{
+			{Method: "POST", Path: "/api/hide", Values: map[string]string{"api_type": "json", "id": "t3_a,t3_b"}},
+			{Method: "POST", Path: "/api/hide", Values: map[string]string{"api_type": "json", "id": "t3_c,t3_d"}},
+			{Method: "POST", Path: "/api/hide", Values: map[string]string{"api_type": "json", "id": "t3_e"}},
+		},
+	); diff != "" {
+		t.Errorf("requests split incorrectly; diff: %s", diff)
+	}
+
+	if reqs := SplitRequest(Request{}, "id", nil, 2); len(reqs) != 0 {
+		t.Errorf("got %d requests for no items; wanted none", len(reqs))
+	}
+}
+
+// concurrentReaper counts the requests it is sent at once, failing those for
+// the items in fail.
+type concurrentReaper struct {
+	mockReaper
+	fail map[string]error
+
+	mu       sync.Mutex
+	inFlight int
+	most     int
+	sent     []Request
+}
+
+func (c *concurrentReaper) send(req Request) ([]byte, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.most {
+		c.most = c.inFlight
+	}
+	c.sent = append(c.sent, req)
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	return []byte("{}"), c.fail[req.Values["id"]]
+}
+
+func TestVoteMany(t *testing.T) {
+	r := &concurrentReaper{fail: map[string]error{"t3_c": PermissionDeniedErr}}
+	b := &batcher{r: r, sleep: func(time.Duration) {}, concurrency: 2}
+
+	votes := map[string]int{"t3_d": -1, "t1_e": 0, "t3_z": 2}
+	for _, name := range []string{"t3_a", "t3_b", "t3_c", "t1_f", "t1_g"} {
+		votes[name] = 1
+	}
+
+	results := b.VoteMany(votes)
+	if len(results) != len(votes) {
+		t.Fatalf("got %d results; wanted %d", len(results), len(votes))
+	}
+	for name := range votes {
+		switch err := results[name]; name {
+		case "t3_c":
+			if err != PermissionDeniedErr {
+				t.Errorf("wanted PermissionDeniedErr for t3_c; got %v", err)
+			}
+		case "t3_z":
+			if err == nil {
+				t.Errorf("wanted an error for an invalid vote direction")
+			}
+		default:
+			if err != nil {
+				t.Errorf("vote on %s failed: %v", name, err)
+			}
+		}
+	}
+
+	if len(r.sent) != len(votes)-1 {
+		t.Errorf("sent %d votes; wanted %d", len(r.sent), len(votes)-1)
+	}
+	for _, req := range r.sent {
+		if req.Method != "POST" || req.Path != "/api/vote" ||
+			req.Values["dir"] != strconv.Itoa(votes[req.Values["id"]]) {
+			t.Errorf("sent unexpected vote %+v", req)
+		}
+	}
+
+	if r.most != 2 {
+		t.Errorf("had %d votes in flight at once; wanted 2", r.most)
+	}
+}
+
+func TestSaveManyStopsWhenRateLimited(t *testing.T) {
+	r := &concurrentReaper{fail: map[string]error{"t3_b": RateLimitErr}}
+	b := &batcher{r: r, sleep: func(time.Duration) {}, concurrency: 1}
+
+	results := b.SaveMany([]string{"t3_a", "t3_b", "t3_c", "t3_d"})
+	if results["t3_a"] != nil || results["t3_b"] != RateLimitErr {
+		t.Errorf("got results %v; wanted t3_a saved and t3_b rate limited", results)
+	}
+	if _, ok := results["t3_c"]; ok {
+		t.Errorf("saved t3_c after Reddit stopped taking saves")
+	}
+	if len(results) != 2 {
+		t.Errorf("got %d results; wanted 2", len(results))
+	}
+
+	// t3_b was tried once and retried maxBatchRetries times.
+	if len(r.sent) != 2+maxBatchRetries {
+		t.Errorf("sent %d saves; wanted %d", len(r.sent), 2+maxBatchRetries)
+	}
+	for _, req := range r.sent {
+		if req.Path != "/api/save" {
+			t.Errorf("sent to %s; wanted /api/save", req.Path)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package reddit
+
+import (
+	"unicode/utf8"
+)
+
+// BodyLimits are the most characters the Account sends in the text of
+// submissions. Longer text fails with a BodyTooLongError before it is sent,
+// rather than with Reddit's less clear rejection. The zero value uses
+// Reddit's limits.
+type BodyLimits struct {
+	// Title is the most characters in a post's title. It is 300 if zero.
+	Title int
+	// SelfText is the most characters in a self post's text. It is 40000
+	// if zero.
+	SelfText int
+	// Comment is the most characters in a comment. It is 10000 if zero.
+	Comment int
+	// Message is the most characters in a private message. It is 10000 if
+	// zero.
+	Message int
+}
+
+const (
+	maxTitleLength    = 300
+	maxSelfTextLength = 40000
+	maxCommentLength  = 10000
+	maxMessageLength  = 10000
+)
+
+// withDefaults returns the limits with Reddit's limits in place of those
+// which are zero.
+func (l BodyLimits) withDefaults() BodyLimits {
+	if l.Title == 0 {
+		l.Title = maxTitleLength
+	}
+	if l.SelfText == 0 {
+		l.SelfText = maxSelfTextLength
+	}
+	if l.Comment == 0 {
+		l.Comment = maxCommentLength
+	}
+	if l.Message == 0 {
+		l.Message = maxMessageLength
+	}
+	return l
+}
+
+// checkLength returns a BodyTooLongError if text is longer than limit
+// characters.
+func checkLength(field, text string, limit int) error {
+	if length := utf8.RuneCountInString(text); length > limit {
+		return &BodyTooLongError{Field: field, Length: length, Limit: limit}
+	}
+	return nil
+}
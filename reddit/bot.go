@@ -1,14 +1,18 @@
 package reddit
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 )
 
 // BotConfig configures a Reddit bot's behavior with the Reddit package.
 type BotConfig struct {
 	// Agent is the user-agent sent in all requests the bot makes through
-	// this package.
+	// this package. NewUserAgent builds one in the format Reddit asks for.
 	Agent string
 	// App is the information for your registration on Reddit.
 	// If you are not familiar with this, read:
@@ -19,8 +23,94 @@ type BotConfig struct {
 	// rules cap OAuth2 clients at 60 requests per minute. See package
 	// overview for rate limit information.
 	Rate time.Duration
+	// RateLimits pace classes of endpoints Reddit limits more strictly,
+	// such as search, on top of Rate. Only Rate paces them if it is zero;
+	// DefaultRateLimits suit most bots.
+	RateLimits RateLimits
 	// Custom HTTP client
 	Client *http.Client
+	// Headers are set on every request made through this package. A header
+	// the request already sets, such as the form Content-Type on POSTs or
+	// the User-Agent (which is always Agent), takes precedence over the
+	// same header here.
+	Headers map[string]string
+	// TLS secures the connections made to Reddit.
+	TLS TLSOptions
+	// Connections tunes the pool of connections kept to Reddit.
+	Connections ConnectionOptions
+	// Retryer, if set, decides which failed requests are made again.
+	// BackoffRetryer is a reasonable policy for most bots.
+	Retryer Retryer
+	// DuplicateWindow, if set, makes the bot refuse to make the same
+	// submission (same subreddit, title, and text or url) twice within the
+	// window, returning DuplicateSubmissionErr instead. This guards against
+	// double posting when retrying a submission that timed out but went
+	// through anyway.
+	DuplicateWindow time.Duration
+	// BodyLimits are the most characters the bot sends in the text of
+	// posts, comments and messages. The zero value uses Reddit's limits.
+	BodyLimits BodyLimits
+	// StrictParsing makes parsing listings fail when Reddit sends fields
+	// the types in this package do not have, instead of ignoring them. It
+	// is for catching changes in Reddit's responses during development;
+	// Reddit sends many fields these types leave out, so bots should not
+	// run with it.
+	StrictParsing bool
+	// CleanURLs removes the TrackingParams from the urls of the posts the
+	// bot reads, with CleanURL.
+	CleanURLs bool
+	// PermalinkBase is the address the FullPermalink of the posts and
+	// comments the bot reads resolves relative permalinks against, e.g.
+	// "https://old.reddit.com" to link to the old interface. It is
+	// "https://www.reddit.com" if empty.
+	PermalinkBase string
+	// Codec encodes the json bodies the bot sends and decodes Reddit's
+	// responses. encoding/json is used if it is nil.
+	Codec Codec
+	// SplitLimits are the most items sent per request to endpoints which
+	// take lists of them, such as Info.
+	SplitLimits SplitLimits
+	// GraphQLURL is the url of the gateway GraphQL queries go to, such as
+	// a fake one in tests. It is Reddit's if empty. Queries are sent over
+	// https, like the bot's other requests, whatever its scheme.
+	GraphQLURL string
+	// Clock, if set, is the clock the bot paces its requests, waits between
+	// retries and remembers submissions by, in place of the system clock,
+	// such as a FakeClock in tests. OAuth2 token expiry is always judged by
+	// the system clock.
+	Clock Clock
+	// TraceHeader, if set, is the header the bot sends the trace id of each
+	// request tagged with one in, e.g. "X-Trace-Id" for a proxy. Requests
+	// are tagged through Request.TraceID or WithTraceID.
+	TraceHeader string
+	// OnRequest, if set, is called with each request the bot makes once it
+	// succeeds or fails for good, such as to log requests with their trace
+	// ids. It may be called from several goroutines at once.
+	OnRequest func(RequestEvent)
+	// BatchConcurrency is the most requests VoteMany and SaveMany have in
+	// flight at once. It is 4 if zero.
+	BatchConcurrency int
+	// URLRewriter, if set, rewrites the url of each API request the bot
+	// makes just before it is sent, such as to route it through a caching
+	// layer. The request goes to the host of the url it returns, with the
+	// bot's credentials. OAuth2 token requests are not rewritten; they go
+	// to App.TokenURL.
+	URLRewriter func(*url.URL) *url.URL
+	// TrustedHosts are the hosts, besides oauth.reddit.com and the GraphQL
+	// gateway, which are sent the bot's credentials with Requests whose
+	// Host they are, such as an authenticating proxy. Requests to other
+	// hosts are sent without them.
+	TrustedHosts []string
+	// WaitOnExhaustion makes the bot hold each request while Reddit's rate
+	// limit headers say its requests for the period are spent, until the
+	// period resets or the request's context is done, rather than send a
+	// request Reddit will refuse.
+	WaitOnExhaustion bool
+	// MaxConcurrentRequests is the most requests the bot has in flight at
+	// once, across all its goroutines. Requests beyond it wait for others
+	// to finish, or for their context to be done. A request is in flight
+	// until its response is read. There is no cap if it is zero.
+	MaxConcurrentRequests int
 }
 
 // Bot defines the behaviors of a logged in Reddit bot.
@@ -28,30 +118,289 @@ type Bot interface {
 	Account
 	Lurker
 	Scanner
+	Batcher
+	Decoder
+
+	// RevokeToken revokes the bot's OAuth2 token at Reddit, e.g. when
+	// shutting down. All requests the bot makes afterward fail with
+	// TokenRevokedErr. The request to revoke the token is abandoned if ctx
+	// is done first; the bot stops making requests either way.
+	RevokeToken(ctx context.Context) error
+
+	// HasScope returns whether Reddit granted the bot's token the OAuth2
+	// scope with the given id, e.g. "modposts".
+	HasScope(scope string) (bool, error)
+	// RequireScopes returns a MissingScopesError naming the scopes Reddit
+	// did not grant the bot's token, if any. Checking the scopes a bot
+	// needs when it starts saves finding out from a PermissionDeniedErr
+	// later.
+	RequireScopes(scopes ...string) error
+	// TokenInfo describes the bot's current OAuth2 token, such as when it
+	// expires and whether it can be refreshed.
+	TokenInfo() (*TokenInfo, error)
+	// SetHeader sets a header sent on every request the bot makes from
+	// then on, as BotConfig.Headers are, or stops sending it if value is
+	// empty. It is safe to call while the bot makes requests.
+	SetHeader(key, value string)
+	// InFlightRequests returns the number of requests the bot has in
+	// flight, for monitoring.
+	InFlightRequests() int
+
+	// GraphQL runs a query on Reddit's GraphQL gateway, for features the
+	// API does not have, with the bot's token, and decodes the data of the
+	// response into out. Errors Reddit reports for the query are returned
+	// as a GraphQLError.
+	GraphQL(
+		ctx context.Context,
+		query string,
+		variables map[string]interface{},
+		out interface{},
+	) error
+	// SubredditTraffic returns the traffic of a subreddit the account
+	// moderates by hour, day and month. It needs the "modconfig" scope in
+	// App.Scopes, and Reddit refuses it with PermissionDeniedErr if the
+	// account does not moderate the subreddit or its mod permissions do
+	// not cover traffic.
+	SubredditTraffic(subreddit string) (*Traffic, error)
+
+	// Prefs returns the account's preferences. It needs the "identity"
+	// scope.
+	Prefs() (*Prefs, error)
+	// UpdatePrefs changes the account's preferences which are set in
+	// patch, leaving the others as they are. It needs the "account" scope,
+	// which bots are not granted unless their App asks for it.
+	UpdatePrefs(patch Prefs) error
 }
 
 type bot struct {
 	Account
 	Lurker
 	Scanner
+	Batcher
+	Decoder
+
+	cli client
+	r   reaper
+	// graphQLURL is the url of the GraphQL gateway, if it is not Reddit's.
+	graphQLURL string
+	// scopes holds the scopes granted the bot's current token.
+	scopes scopeCache
+}
+
+// scopeCache holds the scopes granted a token until the token expires, so
+// checking scopes does not look the token up each time.
+type scopeCache struct {
+	mu sync.Mutex
+	// clock tells when the token expires. It is the system clock if nil.
+	clock   Clock
+	granted map[string]bool
+	expiry  time.Time
+}
+
+func (b *bot) RevokeToken(ctx context.Context) error {
+	b.scopes.mu.Lock()
+	b.scopes.granted = nil
+	b.scopes.mu.Unlock()
+
+	if r, ok := b.cli.(revoker); ok {
+		return r.revoke(ctx)
+	}
+
+	return nil
+}
+
+func (b *bot) TokenInfo() (*TokenInfo, error) {
+	t, ok := b.cli.(tokenHolder)
+	if !ok {
+		return nil, fmt.Errorf("the bot's client does not have a token")
+	}
+
+	return t.tokenInfo()
+}
+
+func (b *bot) SetHeader(key, value string) {
+	if s, ok := b.cli.(headerSetter); ok {
+		s.setHeader(key, value)
+	}
+}
+
+func (b *bot) InFlightRequests() int {
+	if c, ok := b.cli.(inFlightCounter); ok {
+		return c.inFlightRequests()
+	}
+
+	return 0
+}
+
+func (b *bot) HasScope(scope string) (bool, error) {
+	granted, err := b.grantedScopes()
+	if err != nil {
+		return false, err
+	}
+
+	return granted[scope] || granted[allScopes], nil
+}
+
+func (b *bot) RequireScopes(scopes ...string) error {
+	granted, err := b.grantedScopes()
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, scope := range scopes {
+		if !granted[scope] && !granted[allScopes] {
+			missing = append(missing, scope)
+		}
+	}
+
+	if len(missing) != 0 {
+		return &MissingScopesError{Scopes: missing}
+	}
+	return nil
+}
+
+func (b *bot) SubredditTraffic(subreddit string) (*Traffic, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.RequireScopes("modconfig"); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.r.raw_reap("/r/"+subreddit+"/about/traffic", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTraffic(b.r.bodyCodec(), resp)
+}
+
+// prefsPath is the endpoint of the account's preferences.
+const prefsPath = "/api/v1/me/prefs"
+
+func (b *bot) Prefs() (*Prefs, error) {
+	if err := b.RequireScopes("identity"); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.r.raw_reap(prefsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := &Prefs{}
+	if err := b.r.bodyCodec().Unmarshal(resp, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (b *bot) UpdatePrefs(patch Prefs) error {
+	if err := b.RequireScopes("account"); err != nil {
+		return err
+	}
+
+	body, err := b.r.bodyCodec().Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.r.send(Request{Method: "PATCH", Path: prefsPath, JSON: body})
+	return err
+}
+
+// allScopes is the scope Reddit grants to tokens which have every scope.
+const allScopes = "*"
+
+// grantedScopes returns the set of scopes Reddit granted the bot's token. The
+// set is looked up again only once the token it came from expires.
+func (b *bot) grantedScopes() (map[string]bool, error) {
+	b.scopes.mu.Lock()
+	defer b.scopes.mu.Unlock()
+
+	if b.scopes.granted != nil && (b.scopes.expiry.IsZero() ||
+		clockOrReal(b.scopes.clock).Now().Before(b.scopes.expiry)) {
+		return b.scopes.granted, nil
+	}
+
+	info, err := b.TokenInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+	for _, scope := range info.Scopes {
+		granted[scope] = true
+	}
+	b.scopes.granted = granted
+	b.scopes.expiry = info.Expiry
+	return granted, nil
 }
 
 // NewBot returns a logged in handle to the Reddit API.
 func NewBot(c BotConfig) (Bot, error) {
-	cli, err := newClient(clientConfig{agent: c.Agent, app: c.App, client: c.Client})
+	cli, err := newClient(
+		clientConfig{
+			agent:                 c.Agent,
+			app:                   c.App,
+			client:                c.Client,
+			headers:               c.Headers,
+			tls:                   c.TLS,
+			connections:           c.Connections,
+			retryer:               c.Retryer,
+			clock:                 c.Clock,
+			traceHeader:           c.TraceHeader,
+			onRequest:             c.OnRequest,
+			waitOnExhaustion:      c.WaitOnExhaustion,
+			maxConcurrentRequests: c.MaxConcurrentRequests,
+			codec:                 c.Codec,
+		},
+	)
+	p := newParserFromConfig(
+		parserConfig{
+			codec:         c.Codec,
+			strict:        c.StrictParsing,
+			cleanURLs:     c.CleanURLs,
+			permalinkBase: c.PermalinkBase,
+		},
+	)
 	r := newReaper(
 		reaperConfig{
 			client:   cli,
-			parser:   newParser(),
+			parser:   p,
 			hostname: "oauth.reddit.com",
 			tls:      true,
 			rate:     maxOf(c.Rate, time.Second),
+			limits:   c.RateLimits,
+			clock:    c.Clock,
+			rewrite:  c.URLRewriter,
+			codec:    c.Codec,
+			trustedHosts: append(
+				[]string{graphQLHost(c.GraphQLURL)}, c.TrustedHosts...,
+			),
 		},
 	)
 	return &bot{
-		Account: newAccount(r),
-		Lurker:  newLurker(r),
-		Scanner: newScanner(r),
+		Account: newAccount(
+			r,
+			accountConfig{
+				duplicateWindow: c.DuplicateWindow,
+				username:        c.App.Username,
+				limits:          c.BodyLimits,
+				clock:           c.Clock,
+			},
+		),
+		Lurker:     newLurkerFromConfig(r, lurkerConfig{limits: c.SplitLimits, clock: c.Clock}),
+		Scanner:    newScanner(r),
+		Batcher:    newBatcher(r, batcherConfig{clock: c.Clock, concurrency: c.BatchConcurrency}),
+		Decoder:    newDecoder(r),
+		cli:        cli,
+		r:          r,
+		graphQLURL: c.GraphQLURL,
+		scopes:     scopeCache{clock: c.Clock},
 	}, err
 }
 
@@ -0,0 +1,61 @@
+package reddit
+
+import (
+	"net/url"
+	"strings"
+)
+
+// TrackingParams are the query parameters CleanURL removes from urls. A name
+// ending in "*" matches every parameter beginning with the rest of it. Names
+// match regardless of case.
+var TrackingParams = []string{"utm_*", "share_id", "fbclid", "gclid"}
+
+// CleanURL returns the url without the query parameters in TrackingParams,
+// keeping its other parameters in their order. Urls which do not parse are
+// returned unchanged.
+func CleanURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+
+	kept := []string{}
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		name := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name = param[:i]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+
+		if !trackingParam(name) {
+			kept = append(kept, param)
+		}
+	}
+
+	u.RawQuery = strings.Join(kept, "&")
+	u.ForceQuery = false
+	return u.String()
+}
+
+// trackingParam returns whether the named query parameter is one of the
+// TrackingParams.
+func trackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range TrackingParams {
+		p = strings.ToLower(p)
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
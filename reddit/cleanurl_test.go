@@ -0,0 +1,78 @@
+package reddit
+
+import (
+	"testing"
+)
+
+func TestCleanURL(t *testing.T) {
+	for _, test := range []struct {
+		raw, clean string
+	}{
+		{"", ""},
+		{"https://golang.org/doc/", "https://golang.org/doc/"},
+		{
+			"https://blog.golang.org/go1.13?utm_source=reddit&utm_medium=social",
+			"https://blog.golang.org/go1.13",
+		},
+		{
+			"https://www.youtube.com/watch?v=dQw4w9WgXcQ&utm_campaign=share&t=42",
+			"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42",
+		},
+		{
+			"https://www.reddit.com/r/golang/comments/abc/title/?share_id=xyz&context=3",
+			"https://www.reddit.com/r/golang/comments/abc/title/?context=3",
+		},
+		{
+			"https://example.com/search?q=a+b&UTM_Term=go&fbclid=123#results",
+			"https://example.com/search?q=a+b#results",
+		},
+		{
+			"https://example.com/page?utm%5Fsource=reddit&id=7",
+			"https://example.com/page?id=7",
+		},
+		{
+			"https://example.com/page?utmost=1",
+			"https://example.com/page?utmost=1",
+		},
+		{"not a url\x7f?utm_source=x", "not a url\x7f?utm_source=x"},
+	} {
+		if clean := CleanURL(test.raw); clean != test.clean {
+			t.Errorf("CleanURL(%q) = %q; wanted %q", test.raw, clean, test.clean)
+		}
+	}
+}
+
+func TestCleanURLTrackingParams(t *testing.T) {
+	defer func(params []string) { TrackingParams = params }(TrackingParams)
+	TrackingParams = []string{"ref", "src_*"}
+
+	clean := CleanURL("https://example.com/?ref=bot&src_a=1&utm_source=reddit")
+	if want := "https://example.com/?utm_source=reddit"; clean != want {
+		t.Errorf("got %q; wanted %q", clean, want)
+	}
+}
+
+func TestParseCleanURLs(t *testing.T) {
+	listing := []byte(`{"kind": "Listing", "data": {"children": [
+		{"kind": "t3", "data": {
+			"name": "t3_abc",
+			"url": "https://blog.golang.org/go1.13?utm_source=reddit"
+		}}
+	]}}`)
+
+	h, err := newParserFromConfig(parserConfig{cleanURLs: true}).parse(listing)
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+	if url := h.Posts[0].URL; url != "https://blog.golang.org/go1.13" {
+		t.Errorf("got url %q; wanted it cleaned", url)
+	}
+
+	h, err = newParser().parse(listing)
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+	if url := h.Posts[0].URL; url != "https://blog.golang.org/go1.13?utm_source=reddit" {
+		t.Errorf("got url %q; wanted it as Reddit sent it", url)
+	}
+}
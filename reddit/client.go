@@ -1,13 +1,26 @@
 package reddit
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 )
 
-// tokenURL is the url of reddit's oauth2 authorization service.
-const tokenURL = "https://www.reddit.com/api/v1/access_token"
+const (
+	// tokenURL is the url of reddit's oauth2 authorization service.
+	tokenURL = "https://www.reddit.com/api/v1/access_token"
+	// revokeURL is the url of reddit's oauth2 token revocation service.
+	revokeURL = "https://www.reddit.com/api/v1/revoke_token"
+	// authorizeURL is the page where users grant web apps access to their
+	// accounts.
+	authorizeURL = "https://www.reddit.com/api/v1/authorize"
+)
 
 // clientConfig holds all the information needed to define Client behavior, such
 // as who the client will identify as externally and where to authorize.
@@ -21,58 +34,475 @@ type clientConfig struct {
 
 	// Custom http client, if nil default should be used
 	client *http.Client
+
+	// headers are set on all requests which do not already set them.
+	headers map[string]string
+
+	// tls secures the client's connections.
+	tls TLSOptions
+	// connections tunes the client's connection pool.
+	connections ConnectionOptions
+
+	// retryer decides which failed requests are retried. None are if it is
+	// nil.
+	retryer Retryer
+
+	// clock times the waits between retries. It is the system clock if
+	// nil.
+	clock Clock
+
+	// traceHeader, if set, is the header requests' trace ids are sent in.
+	traceHeader string
+	// onRequest, if set, is called with each request once it is done.
+	onRequest func(RequestEvent)
+
+	// waitOnExhaustion makes requests wait for Reddit's rate limit to
+	// reset when the last response said it was spent.
+	waitOnExhaustion bool
+
+	// maxConcurrentRequests is the most requests in flight at once. There
+	// is no cap if it is zero.
+	maxConcurrentRequests int
+
+	// codec decodes the bodies of error responses. encoding/json is used
+	// if it is nil.
+	codec Codec
 }
 
 // client executes http Requests and invisibly handles OAuth2 authorization.
 type client interface {
 	Do(*http.Request) ([]byte, error)
+	// DoStream executes a request and decodes its json response into dst
+	// as it is read, rather than reading the whole response first. The
+	// elements of arrays, like the children of a listing, are decoded one
+	// at a time.
+	DoStream(req *http.Request, dst interface{}) error
+}
+
+// revoker is a client which can revoke its OAuth2 authorization.
+type revoker interface {
+	revoke(ctx context.Context) error
+}
+
+// inFlightCounter is a client which counts the requests it has in flight.
+type inFlightCounter interface {
+	inFlightRequests() int
+}
+
+// headerSetter is a client whose forwarded headers can change while it is
+// used.
+type headerSetter interface {
+	setHeader(key, value string)
+}
+
+// tokenHolder is a client which can describe the OAuth2 token Reddit issued
+// it.
+type tokenHolder interface {
+	tokenInfo() (*TokenInfo, error)
 }
 
 type baseClient struct {
 	cli *http.Client
+	// retryer decides which failed requests are retried, if it is set.
+	retryer Retryer
+	// clock times the waits between retries. It is the system clock if
+	// nil.
+	clock Clock
+	// traceHeader, if set, is the header requests' trace ids are sent in.
+	traceHeader string
+	// onRequest, if set, is called with each request once it is done.
+	onRequest func(RequestEvent)
+	// quota, if set, makes requests wait out Reddit's rate limit once
+	// responses say it is spent.
+	quota *quota
+	// inFlight, if set, counts the requests in flight and caps them.
+	inFlight *inFlight
+	// codec decodes the bodies of error responses. encoding/json is used
+	// if it is nil.
+	codec Codec
 }
 
 func (b *baseClient) Do(req *http.Request) ([]byte, error) {
+	resp, err := b.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b *baseClient) DoStream(req *http.Request, dst interface{}) error {
+	resp, err := b.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return streamJSON(resp.Body, dst)
+}
+
+func (b *baseClient) setHeader(key, value string) {
+	setForwardedHeader(b.cli, key, value)
+}
+
+func (b *baseClient) inFlightRequests() int {
+	if b.inFlight == nil {
+		return 0
+	}
+	return b.inFlight.current()
+}
+
+// send executes a request and returns the response if Reddit answered it
+// successfully, retrying it as long as the client's retryer says to. The
+// caller must close the response's body.
+func (b *baseClient) send(req *http.Request) (*http.Response, error) {
+	trace := TraceID(req.Context())
+	if trace != "" && b.traceHeader != "" {
+		req = req.Clone(req.Context())
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		req.Header.Set(b.traceHeader, trace)
+	}
+
+	clock := clockOrReal(b.clock)
+	start := clock.Now()
+	resp, err := b.retry(req)
+
+	if b.onRequest != nil {
+		b.onRequest(RequestEvent{
+			Method:   req.Method,
+			URL:      req.URL.String(),
+			TraceID:  trace,
+			BodySize: bodySize(req),
+			Duration: clock.Now().Sub(start),
+			Err:      err,
+		})
+	}
+
+	return resp, err
+}
+
+// bodySize returns how many bytes of content a request sends, counting the
+// form values of writes sent in the url's query.
+func bodySize(req *http.Request) int64 {
+	if req.ContentLength > 0 {
+		return req.ContentLength
+	}
+	if req.Method == "GET" || req.URL == nil {
+		return 0
+	}
+	return int64(len(req.URL.RawQuery))
+}
+
+// retry executes a request until it succeeds or the client's retryer gives up
+// on it.
+func (b *baseClient) retry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := b.attempt(req)
+		if err == nil {
+			return resp, nil
+		} else if b.retryer == nil {
+			return nil, err
+		}
+
+		retry, delay := b.retryer.ShouldRetry(attempt, req, resp, err)
+		if !retry {
+			return nil, err
+		}
+
+		if budget, ok := b.retryer.(budgetedRetryer); ok && !budget.spendRetry() {
+			return nil, RetryBudgetExhaustedErr
+		}
+
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, err
+			}
+
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-clockOrReal(b.clock).After(delay):
+		}
+	}
+}
+
+// attempt executes a request once. If Reddit answers but not successfully,
+// the response is returned with the error, its body closed.
+func (b *baseClient) attempt(req *http.Request) (*http.Response, error) {
+	if b.quota != nil {
+		if err := b.quota.wait(req.Context(), clockOrReal(b.clock)); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.inFlight != nil {
+		if err := b.inFlight.acquire(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
 	resp, err := b.cli.Do(req)
-	if resp != nil && resp.Body != nil {
-		defer resp.Body.Close()
+	if b.inFlight != nil {
+		if resp != nil && resp.Body != nil {
+			resp.Body = &releasingBody{
+				ReadCloser: resp.Body,
+				release:    b.inFlight.release,
+			}
+		} else {
+			b.inFlight.release()
+		}
+	}
+	if b.quota != nil && resp != nil {
+		b.quota.observe(resp, clockOrReal(b.clock).Now())
 	}
 	if err != nil {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
 		return nil, err
 	}
 
+	if err := statusError(resp, codecOrJSON(b.codec)); err != nil {
+		resp.Body.Close()
+		return resp, err
+	}
+
+	if redirectedToSearch(req, resp) {
+		resp.Body.Close()
+		return resp, searchRedirectErr
+	}
+
+	if idempotent(req) {
+		if err := checkEmptyBody(resp); err != nil {
+			resp.Body.Close()
+			return resp, err
+		}
+	}
+
+	if err := checkHTMLBody(resp); err != nil {
+		resp.Body.Close()
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+const (
+	// htmlPeek is how much of a body is read to tell whether it is html.
+	htmlPeek = 512
+	// htmlRead is how much of an html page is read to classify it.
+	htmlRead = 4096
+	// htmlSnippet is the most of an html page kept in its error.
+	htmlSnippet = 200
+)
+
+// blockPageMarks are phrases of the pages Reddit serves in place of responses
+// to clients it blocks.
+var blockPageMarks = []string{
+	"blocked by network security",
+	"whoa there, pardner",
+}
+
+// checkHTMLBody returns an UnexpectedHTMLError if the body of a response is an
+// html page, which Reddit sometimes sends with a 200 in place of json. The
+// body is otherwise left unread.
+func checkHTMLBody(resp *http.Response) error {
+	body := bufio.NewReaderSize(resp.Body, htmlPeek)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+
+	start, err := body.Peek(htmlPeek)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "text/html") &&
+		!bytes.HasPrefix(bytes.TrimLeft(start, " \t\r\n"), []byte("<")) {
+		return nil
+	}
+
+	page, _ := ioutil.ReadAll(io.LimitReader(body, htmlRead))
+	text := strings.Join(strings.Fields(string(page)), " ")
+
+	blocked := false
+	for _, mark := range blockPageMarks {
+		if strings.Contains(strings.ToLower(text), mark) {
+			blocked = true
+		}
+	}
+
+	if len(text) > htmlSnippet {
+		// Cut at the start of a rune so the snippet stays valid UTF-8.
+		cut := htmlSnippet
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return &UnexpectedHTMLError{Snippet: text, Blocked: blocked}
+}
+
+// searchPath begins the path Reddit redirects requests for missing subreddits
+// to.
+const searchPath = "/subreddits/search"
+
+// redirectedToSearch returns whether Reddit redirected the request to a search
+// for subreddits.
+func redirectedToSearch(req *http.Request, resp *http.Response) bool {
+	return resp.Request != nil &&
+		resp.Request.URL.Path != req.URL.Path &&
+		strings.HasPrefix(resp.Request.URL.Path, searchPath)
+}
+
+// checkEmptyBody returns EmptyBodyErr if the body of a response is empty or
+// only whitespace. Otherwise the body is left to read from its first other
+// character, which is where json parsing would begin anyway.
+func checkEmptyBody(resp *http.Response) error {
+	body := bufio.NewReader(resp.Body)
+	for {
+		c, err := body.ReadByte()
+		if err == io.EOF {
+			return EmptyBodyErr
+		} else if err != nil {
+			return err
+		}
+
+		if !strings.ContainsRune(" \t\r\n", rune(c)) {
+			body.UnreadByte()
+			break
+		}
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+	return nil
+}
+
+// statusError returns the error for the status code of a response, or nil if
+// it is successful. Bodies which explain errors are decoded with c.
+func statusError(resp *http.Response, c Codec) error {
 	switch resp.StatusCode {
 	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		return badRequestError(c, resp.Body)
 	case http.StatusForbidden:
-		return nil, PermissionDeniedErr
+		return forbiddenError(c, resp.Body)
+	case http.StatusNotFound:
+		return notFoundErr
+	case http.StatusConflict:
+		return conflictErr
 	case http.StatusServiceUnavailable:
-		return nil, BusyErr
+		return BusyErr
 	case http.StatusTooManyRequests:
-		return nil, RateLimitErr
+		return tooManyRequestsError(resp)
 	case http.StatusBadGateway:
-		return nil, GatewayErr
+		return GatewayErr
 	case http.StatusGatewayTimeout:
-		return nil, GatewayTimeoutErr
-	default:
-		return nil, fmt.Errorf("bad response code: %d", resp.StatusCode)
+		return GatewayTimeoutErr
 	}
 
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(resp.Body); err != nil {
-		return nil, err
+	return fmt.Errorf("bad response code: %d", resp.StatusCode)
+}
+
+// insufficientCoinsReason begins the reason Reddit gives for refusing awards
+// the account cannot afford.
+const insufficientCoinsReason = "INSUFFICIENT_COINS"
+
+// badRequestError returns the error for a 400 response with the given body.
+func badRequestError(c Codec, body io.Reader) error {
+	var bad struct {
+		Reason string `json:"reason"`
+	}
+	if decodeBody(c, body, &bad) == nil &&
+		strings.HasPrefix(bad.Reason, insufficientCoinsReason) {
+		return InsufficientCoinsErr
 	}
 
-	return buf.Bytes(), nil
+	return fmt.Errorf("bad response code: %d", http.StatusBadRequest)
+}
+
+// forbiddenError returns the error for a 403 response with the given body.
+// Reddit explains some of these, such as quarantined subreddits, in the body.
+func forbiddenError(c Codec, body io.Reader) error {
+	var forbidden struct {
+		Reason            string `json:"reason"`
+		QuarantineMessage string `json:"quarantine_message"`
+	}
+	if err := decodeBody(c, body, &forbidden); err != nil {
+		return PermissionDeniedErr
+	}
+
+	if forbidden.Reason == quarantinedReason {
+		return &QuarantinedError{Message: forbidden.QuarantineMessage}
+	}
+
+	return PermissionDeniedErr
+}
+
+// decodeBody reads the json body of a response and decodes it into v with c.
+func decodeBody(c Codec, body io.Reader, v interface{}) error {
+	blob, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(blob, v)
+}
+
+// newQuota returns the quota of a client which waits on exhaustion, or nil if
+// it does not wait.
+func newQuota(waitOnExhaustion bool) *quota {
+	if !waitOnExhaustion {
+		return nil
+	}
+	return &quota{}
 }
 
 // newClient returns a new client using the given user to make requests.
 func newClient(c clientConfig) (client, error) {
-	if c.app.tokenURL == "" {
-		c.app.tokenURL = tokenURL
-	}
+	c.app = c.app.withEndpoints()
 
 	if c.app.unauthenticated() {
-		return &baseClient{clientWithAgent(c.agent)}, nil
+		cli := c.client
+		if cli == nil {
+			cli = &http.Client{}
+		}
+
+		cli, err := withTransport(cli, c.tls, c.connections)
+		if err != nil {
+			return nil, err
+		}
+		return &baseClient{
+			cli:         patchWithAgent(cli, c.agent, c.headers),
+			retryer:     c.retryer,
+			clock:       c.clock,
+			traceHeader: c.traceHeader,
+			onRequest:   c.onRequest,
+			quota:       newQuota(c.waitOnExhaustion),
+			inFlight:    newInFlight(c.maxConcurrentRequests),
+			codec:       c.codec,
+		}, nil
 	}
 
 	if err := c.app.validateAuth(); err != nil {
@@ -3,7 +3,9 @@ package reddit
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func serverWhich(body []byte, code int) *httptest.Server {
@@ -36,11 +38,13 @@ func TestDo(t *testing.T) {
 	}{
 		{[]byte("expected"), http.StatusOK, nil},
 		{nil, http.StatusForbidden, PermissionDeniedErr},
+		{nil, http.StatusNotFound, notFoundErr},
+		{nil, http.StatusConflict, conflictErr},
 		{nil, http.StatusServiceUnavailable, BusyErr},
 		{nil, http.StatusTooManyRequests, RateLimitErr},
 		{nil, http.StatusBadGateway, GatewayErr},
 		{nil, http.StatusGatewayTimeout, GatewayTimeoutErr},
-		{nil, http.StatusOK, nil},
+		{nil, http.StatusOK, EmptyBodyErr},
 	} {
 		serv := serverWhich(test.body, test.code)
 
@@ -70,3 +74,160 @@ func TestDo(t *testing.T) {
 		}
 	}
 }
+
+func TestDoStream(t *testing.T) {
+	r := &baseClient{cli: &http.Client{}}
+	for _, test := range []struct {
+		body  []byte
+		code  int
+		err   error
+		title string
+	}{
+		{[]byte(`{"title": "post"}`), http.StatusOK, nil, "post"},
+		{nil, http.StatusServiceUnavailable, BusyErr, ""},
+		{nil, http.StatusTooManyRequests, RateLimitErr, ""},
+	} {
+		serv := serverWhich(test.body, test.code)
+
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		var dst struct {
+			Title string `json:"title"`
+		}
+		if err := r.DoStream(req, &dst); err != test.err {
+			t.Errorf("unexpected error: %v", err)
+		} else if dst.Title != test.title {
+			t.Errorf("decoded title %q; wanted %q", dst.Title, test.title)
+		}
+		serv.Close()
+	}
+}
+
+func TestBadRequestError(t *testing.T) {
+	if err := badRequestError(
+		jsonCodec{},
+		strings.NewReader(`{"reason": "INSUFFICIENT_COINS_WITH_AMOUNT"}`),
+	); err != InsufficientCoinsErr {
+		t.Errorf("wanted InsufficientCoinsErr; got %v", err)
+	}
+
+	if err := badRequestError(jsonCodec{}, strings.NewReader(`{"reason": "BAD_THING"}`)); err == nil ||
+		err.Error() != "bad response code: 400" {
+		t.Errorf("wanted generic 400 error; got %v", err)
+	}
+}
+
+func TestDoSearchRedirect(t *testing.T) {
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, searchPath) {
+					w.Write([]byte(`{"kind": "Listing", "data": {"children": []}}`))
+					return
+				}
+				http.Redirect(w, r, searchPath+".json?q=nope", http.StatusFound)
+			},
+		),
+	)
+	defer serv.Close()
+
+	r := &baseClient{cli: &http.Client{}}
+	for _, test := range []struct {
+		path string
+		err  error
+	}{
+		{"/r/nope/new.json", searchRedirectErr},
+		{searchPath + ".json", nil},
+	} {
+		req, err := http.NewRequest("GET", serv.URL+test.path, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		if _, err := r.Do(req); err != test.err {
+			t.Errorf("[%s] wanted %v; got %v", test.path, test.err, err)
+		}
+	}
+}
+
+func TestDoHTML(t *testing.T) {
+	r := &baseClient{cli: &http.Client{}}
+	for _, test := range []struct {
+		name    string
+		body    string
+		blocked bool
+	}{
+		{
+			"interstitial",
+			"\n<!DOCTYPE html><html><head><title>reddit.com: over 18?</title></head>" +
+				"<body><p>You must be at least eighteen years old to view this content.</p></body></html>",
+			false,
+		},
+		{
+			"block page",
+			"<!doctype html><html><head><title>Blocked</title></head><body>" +
+				strings.Repeat("<div class=\"spacer\"></div>", 40) +
+				"<h1>whoa there, pardner!</h1><p>Your request has been blocked due to a network policy.</p>" +
+				"</body></html>",
+			true,
+		},
+		{
+			"multi-byte page",
+			"<!doctype html>" + strings.Repeat("é", 200),
+			false,
+		},
+	} {
+		serv := serverWhich([]byte(test.body), http.StatusOK)
+
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		_, err = r.Do(req)
+		htmlErr, ok := err.(*UnexpectedHTMLError)
+		if !ok {
+			t.Errorf("[%s] wanted UnexpectedHTMLError; got %v", test.name, err)
+		} else if htmlErr.Blocked != test.blocked {
+			t.Errorf("[%s] got blocked %v; wanted %v", test.name, htmlErr.Blocked, test.blocked)
+		} else if !strings.HasPrefix(htmlErr.Snippet, "<!") || len(htmlErr.Snippet) > htmlSnippet ||
+			!utf8.ValidString(htmlErr.Snippet) {
+			t.Errorf("[%s] got snippet %q", test.name, htmlErr.Snippet)
+		}
+		serv.Close()
+	}
+
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write([]byte("Service unavailable"))
+			},
+		),
+	)
+	defer serv.Close()
+
+	req, err := http.NewRequest("POST", serv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+	if _, err := r.Do(req); err == nil {
+		t.Errorf("wanted an error for a body served as html")
+	} else if _, ok := err.(*UnexpectedHTMLError); !ok {
+		t.Errorf("wanted UnexpectedHTMLError; got %v", err)
+	}
+
+	listing := serverWhich([]byte(`  {"kind": "Listing"}`), http.StatusOK)
+	defer listing.Close()
+
+	req, err = http.NewRequest("GET", listing.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+	if body, err := r.Do(req); err != nil || string(body) != `{"kind": "Listing"}` {
+		t.Errorf("wanted the json body; got %q, %v", body, err)
+	}
+}
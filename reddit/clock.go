@@ -0,0 +1,97 @@
+package reddit
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time to the parts of a bot which depend on it: the pacing of
+// requests, the waits between retries, the memory of recent submissions and
+// the expiry of the scopes its token was granted.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel which receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOrReal returns the clock, or the system clock if it is nil.
+func clockOrReal(c Clock) Clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
+
+// FakeClock is a Clock which only moves when it is advanced, so code which
+// waits on it can be tested without waiting. Its zero value is not usable;
+// make one with NewFakeClock.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter is a channel waiting for a FakeClock to reach a time.
+type fakeWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFakeClock returns a FakeClock stopped at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+
+	f.waiters = append(f.waiters, fakeWaiter{at: f.now.Add(d), c: c})
+	return c
+}
+
+// Advance moves the clock forward by d, releasing everything waiting for a
+// time it reaches.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	waiting := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+		w.c <- f.now
+	}
+	f.waiters = waiting
+}
+
+// Waiters returns how many calls to After are still waiting, so a test can
+// tell when the code it drives has begun to wait.
+func (f *FakeClock) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
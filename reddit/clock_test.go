@@ -0,0 +1,122 @@
+package reddit
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Unix(1570000000, 0)
+	clock := NewFakeClock(start)
+
+	soon, later := clock.After(time.Second), clock.After(time.Minute)
+	select {
+	case <-clock.After(0):
+	default:
+		t.Errorf("wait of zero did not end at once")
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case now := <-soon:
+		if !now.Equal(start.Add(30 * time.Second)) {
+			t.Errorf("first wait ended at %v", now)
+		}
+	default:
+		t.Errorf("first wait did not end")
+	}
+	select {
+	case <-later:
+		t.Errorf("second wait ended early")
+	default:
+	}
+	if clock.Waiters() != 1 {
+		t.Errorf("got %d waiters; wanted 1", clock.Waiters())
+	}
+
+	clock.Advance(30 * time.Second)
+	<-later
+	if !clock.Now().Equal(start.Add(time.Minute)) {
+		t.Errorf("clock is at %v; wanted a minute past the start", clock.Now())
+	}
+}
+
+// waitForWaiters waits until n calls are waiting on the clock.
+func waitForWaiters(t *testing.T, clock *FakeClock, n int) {
+	deadline := time.Now().Add(time.Second)
+	for clock.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d waiters; wanted %d", clock.Waiters(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFakeClockRate(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	r := &reaperImpl{
+		cli:      &mockClient{response: []byte("{}")},
+		hostname: "reddit.com",
+		scheme:   "https",
+		rate:     time.Hour,
+		mu:       &sync.Mutex{},
+		clock:    clock,
+	}
+
+	if _, err := r.raw_reap("/r/golang/about", nil); err != nil {
+		t.Fatalf("first request failed: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := r.raw_reap("/r/golang/about", nil)
+		done <- err
+	}()
+
+	waitForWaiters(t, clock, 1)
+	clock.Advance(59 * time.Minute)
+	select {
+	case <-done:
+		t.Fatalf("second request was made before the rate passed")
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Errorf("second request failed: %v", err)
+	}
+}
+
+func TestFakeClockRetryDelay(t *testing.T) {
+	serv, requests := flakyServerWhich(1, http.StatusServiceUnavailable, "ok")
+	defer serv.Close()
+
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	c := &baseClient{
+		cli:     &http.Client{},
+		retryer: BackoffRetryer{Base: time.Hour, Max: time.Hour},
+		clock:   clock,
+	}
+
+	req, err := http.NewRequest("GET", serv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Do(req)
+		done <- err
+	}()
+
+	waitForWaiters(t, clock, 1)
+	clock.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Errorf("retried request failed: %v", err)
+	}
+	if *requests != 2 {
+		t.Errorf("made %d requests; wanted 2", *requests)
+	}
+}
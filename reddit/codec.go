@@ -0,0 +1,50 @@
+package reddit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Codec encodes the json bodies sent to Reddit and decodes its json
+// responses. It can be set to use a faster json implementation than
+// encoding/json, such as jsoniter, for listings and other large responses.
+// Codecs which decode numbers into interface values as json.Number, as the
+// default does, keep large integers exact.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// errTrailingData is returned for json which has more after its value.
+var errTrailingData = fmt.Errorf("invalid character after top-level value")
+
+// jsonCodec is the default Codec, which uses encoding/json, decoding numbers
+// as json.Number.
+type jsonCodec struct{}
+
+// codecOrJSON returns the codec, or the default codec if it is nil.
+func codecOrJSON(c Codec) Codec {
+	if c == nil {
+		return jsonCodec{}
+	}
+	return c
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+
+	if d.Decode(&json.RawMessage{}) != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package reddit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/turnage/graw/reddit/internal/testdata"
+)
+
+// decoderCodec is a Codec which streams through a json.Decoder, standing in for
+// an alternative json implementation.
+type decoderCodec struct {
+	calls    int
+	marshals int
+}
+
+func (d *decoderCodec) Marshal(v interface{}) ([]byte, error) {
+	d.marshals++
+	return json.Marshal(v)
+}
+
+func (d *decoderCodec) Unmarshal(data []byte, v interface{}) error {
+	d.calls++
+	return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func TestParserCodec(t *testing.T) {
+	codec := &decoderCodec{}
+	p := newParserFromConfig(parserConfig{codec: codec})
+
+	h, err := p.parse(testdata.MustAsset("subreddit.json"))
+	if err != nil {
+		t.Fatalf("failed to parse with codec: %v", err)
+	}
+
+	if len(h.Posts) != 27 {
+		t.Errorf("wanted 27 posts; got %d", len(h.Posts))
+	}
+
+	if codec.calls == 0 {
+		t.Errorf("parser did not use its codec")
+	}
+}
+
+func TestReaperCodec(t *testing.T) {
+	codec := &decoderCodec{}
+	r := newReaper(reaperConfig{codec: codec})
+	if r.bodyCodec() != codec {
+		t.Fatalf("reaper does not decode with its configured codec")
+	}
+
+	if _, err := parseTrophies(r.bodyCodec(), []byte(
+		`{"kind": "TrophyList", "data": {"trophies": []}}`,
+	)); err != nil {
+		t.Fatalf("failed to parse with codec: %v", err)
+	}
+	if codec.calls == 0 {
+		t.Errorf("parse helper did not use the codec")
+	}
+
+	if _, ok := (&reaperImpl{}).bodyCodec().(jsonCodec); !ok {
+		t.Errorf("reaper without a codec does not use encoding/json")
+	}
+}
+
+func TestClientCodec(t *testing.T) {
+	codec := &decoderCodec{}
+	serv := serverWhich(
+		[]byte(`{"reason": "INSUFFICIENT_COINS_WITH_AMOUNT"}`),
+		http.StatusBadRequest,
+	)
+	defer serv.Close()
+
+	c := &baseClient{cli: &http.Client{}, codec: codec}
+	req, err := http.NewRequest("GET", serv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+	if _, err := c.Do(req); err != InsufficientCoinsErr {
+		t.Errorf("wanted InsufficientCoinsErr; got %v", err)
+	}
+	if codec.calls != 1 {
+		t.Errorf("decoded the error body with the codec %d times; wanted 1", codec.calls)
+	}
+}
+
+func TestMarshalCodec(t *testing.T) {
+	codec := &decoderCodec{}
+	r := &codecReaper{mockReaper: &mockReaper{}, codec: codec}
+	b := &bot{
+		cli:     &scopedClient{scopes: []string{"account"}},
+		r:       r,
+		Account: newAccount(r, accountConfig{}),
+	}
+
+	if err := b.UpdatePrefs(Prefs{}); err != nil {
+		t.Fatalf("failed to update prefs: %v", err)
+	}
+	if _, err := b.ReplyRichText("t1_abc", &RichText{
+		Document: []RichTextNode{RichParagraph(RichSpan("hi"))},
+	}); err != nil {
+		t.Fatalf("failed to reply: %v", err)
+	}
+
+	if codec.marshals != 2 {
+		t.Errorf("encoded %d bodies with the codec; wanted 2", codec.marshals)
+	}
+}
+
+// codecReaper is a mockReaper which encodes and decodes with codec.
+type codecReaper struct {
+	*mockReaper
+	codec Codec
+}
+
+func (c *codecReaper) bodyCodec() Codec {
+	return c.codec
+}
+
+func benchmarkParse(b *testing.B, codec Codec) {
+	p := newParserFromConfig(parserConfig{codec: codec})
+	blob := testdata.MustAsset("subreddit.json")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.parse(blob); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDefaultCodec(b *testing.B) {
+	benchmarkParse(b, nil)
+}
+
+func BenchmarkParseStubCodec(b *testing.B) {
+	benchmarkParse(b, &decoderCodec{})
+}
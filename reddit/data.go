@@ -1,6 +1,11 @@
 package reddit
 
-import "strings"
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
 
 // Comment represents a comment on Reddit (Reddit type t1_).
 // https://github.com/reddit/reddit/wiki/JSON#comment-implements-votable--created
@@ -12,10 +17,18 @@ type Comment struct {
 	CreatedUTC uint64 `mapstructure:"created_utc"`
 	Edited     uint64 `mapstructure:"edited"`
 	Deleted    bool   `mapstructure:"deleted"`
+	// Archived is set once the comment is too old to vote on or reply to.
+	Archived bool `mapstructure:"archived"`
 
 	Ups   int32 `mapstructure:"ups"`
 	Downs int32 `mapstructure:"downs"`
 	Likes bool  `mapstructure:"likes"`
+	// Score is the comment's score as Reddit shows it, which Reddit fuzzes.
+	// It is meaningless while ScoreHidden is set.
+	Score int32 `mapstructure:"score"`
+	// ScoreHidden is set while the subreddit hides the scores of new
+	// comments.
+	ScoreHidden bool `mapstructure:"score_hidden"`
 
 	Author              string `mapstructure:"author"`
 	AuthorFlairCSSClass string `mapstructure:"author_flair_css_class"`
@@ -37,6 +50,31 @@ type Comment struct {
 
 	Gilded        int32  `mapstructure:"gilded"`
 	Distinguished string `mapstructure:"distinguished"`
+
+	// Awardings are the awards given to the comment, or nil if it has
+	// none.
+	Awardings []*Awarding `mapstructure:"all_awardings"`
+
+	// permalinkBase is the BotConfig.PermalinkBase of the bot which read
+	// the comment.
+	permalinkBase string
+}
+
+// TotalAwardCoins returns the coins spent on the comment's awards.
+func (c *Comment) TotalAwardCoins() int {
+	return awardCoins(c.Awardings)
+}
+
+// DisplayedScore returns the comment's score and whether it is hidden, in
+// which case the score is meaningless. Reddit fuzzes the scores it shows, so
+// they are approximate even when they are not hidden.
+func (c *Comment) DisplayedScore() (value int, hidden bool) {
+	return int(c.Score), c.ScoreHidden
+}
+
+// FullPermalink returns the comment's permalink as an absolute url.
+func (c *Comment) FullPermalink() string {
+	return fullPermalink(c.permalinkBase, c.Permalink)
 }
 
 // IsTopLevel is true when the comment is a top level comment.
@@ -45,6 +83,51 @@ func (c *Comment) IsTopLevel() bool {
 	return parentType == postKind
 }
 
+// defaultPermalinkBase is the address FullPermalink resolves relative
+// permalinks against if the bot has no PermalinkBase.
+const defaultPermalinkBase = "https://www.reddit.com"
+
+// fullPermalink returns the permalink resolved against base, or against
+// defaultPermalinkBase if base is empty. Reddit returns most permalinks
+// relative to its host, but permalinks which are already absolute are
+// returned unchanged.
+func fullPermalink(base, permalink string) string {
+	lower := strings.ToLower(permalink)
+	switch {
+	case permalink == "":
+		return ""
+	case strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "http://"):
+		return permalink
+	case strings.HasPrefix(permalink, "//"):
+		return "https:" + permalink
+	}
+
+	if base == "" {
+		base = defaultPermalinkBase
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(permalink, "/")
+}
+
+// Awarding is an award given to a post or comment, and how many times it was
+// given.
+type Awarding struct {
+	ID          string `mapstructure:"id"`
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	IconURL     string `mapstructure:"icon_url"`
+	Count       int    `mapstructure:"count"`
+	// CoinPrice is the coins each of the awards cost.
+	CoinPrice int `mapstructure:"coin_price"`
+}
+
+func awardCoins(awardings []*Awarding) int {
+	coins := 0
+	for _, a := range awardings {
+		coins += a.Count * a.CoinPrice
+	}
+	return coins
+}
+
 // Media represents a subfield in the response about posts
 type Media struct {
 	Type   string `mapstructure:"type"`
@@ -83,7 +166,9 @@ type Post struct {
 	Permalink string `mapstructure:"permalink"`
 
 	CreatedUTC uint64 `mapstructure:"created_utc"`
-	Deleted    bool   `mapstructure:"deleted"`
+	// Edited is when the post was last edited, or zero if it has not been.
+	Edited  uint64 `mapstructure:"edited"`
+	Deleted bool   `mapstructure:"deleted"`
 
 	Ups   int32 `mapstructure:"ups"`
 	Downs int32 `mapstructure:"downs"`
@@ -93,11 +178,21 @@ type Post struct {
 	AuthorFlairCSSClass string `mapstructure:"author_flair_css_class"`
 	AuthorFlairText     string `mapstructure:"author_flair_text"`
 
-	Title  string `mapstructure:"title"`
-	Score  int32  `mapstructure:"score"`
-	URL    string `mapstructure:"url"`
-	Domain string `mapstructure:"domain"`
-	NSFW   bool   `mapstructure:"over_18"`
+	Title string `mapstructure:"title"`
+	// Score is the post's score as Reddit shows it, which Reddit fuzzes.
+	// It is meaningless while ScoreHidden is set.
+	Score int32 `mapstructure:"score"`
+	// ScoreHidden is set while the subreddit hides the scores of new
+	// posts.
+	ScoreHidden bool    `mapstructure:"hide_score"`
+	UpvoteRatio float64 `mapstructure:"upvote_ratio"`
+	URL         string  `mapstructure:"url"`
+	Domain      string  `mapstructure:"domain"`
+	NSFW        bool    `mapstructure:"over_18"`
+	// PostHint is Reddit's guess at what the post's link is, e.g. "image",
+	// "link", "hosted:video" or "rich:video". It is empty if Reddit has not
+	// guessed.
+	PostHint string `mapstructure:"post_hint"`
 
 	Subreddit   string `mapstructure:"subreddit"`
 	SubredditID string `mapstructure:"subreddit_id"`
@@ -106,9 +201,20 @@ type Post struct {
 	SelfText     string `mapstructure:"selftext"`
 	SelfTextHTML string `mapstructure:"selftext_html"`
 
+	// IsCrosspostable is whether the post may be crossposted to other
+	// subreddits.
+	IsCrosspostable bool `mapstructure:"is_crosspostable"`
+	// CrosspostParent is the full name of the post this post crossposts,
+	// or empty if it is not a crosspost.
+	CrosspostParent string `mapstructure:"crosspost_parent"`
+
 	Replies []*Comment `mapstructure:"reply_tree"`
 	More    *More
 
+	// permalinkBase is the BotConfig.PermalinkBase of the bot which read
+	// the post.
+	permalinkBase string
+
 	Hidden            bool   `mapstructure:"hidden"`
 	LinkFlairCSSClass string `mapstructure:"link_flair_css_class"`
 	LinkFlairText     string `mapstructure:"link_flair_text"`
@@ -116,14 +222,268 @@ type Post struct {
 	NumComments int32  `mapstructure:"num_comments"`
 	Locked      bool   `mapstructure:"locked"`
 	Thumbnail   string `mapstructure:"thumbnail"`
+	// Archived is set once the post is too old to vote on or comment on,
+	// usually six months after it was made.
+	Archived bool `mapstructure:"archived"`
 
 	Gilded        int32  `mapstructure:"gilded"`
 	Distinguished string `mapstructure:"distinguished"`
 	Stickied      bool   `mapstructure:"stickied"`
 
+	// Awardings are the awards given to the post, or nil if it has none.
+	Awardings []*Awarding `mapstructure:"all_awardings"`
+
 	IsRedditMediaDomain bool  `mapstructure:"is_reddit_media_domain"`
 	Media               Media `mapstructure:"media"`
 	SecureMedia         Media `mapstructure:"secure_media"`
+
+	// Preview holds the images Reddit generated from the post's link, or
+	// is nil if it made none.
+	Preview *Preview `mapstructure:"preview"`
+
+	// MediaMetadata describes the media of gallery posts and media inlined
+	// in their text, by media id.
+	MediaMetadata map[string]MediaMetadata `mapstructure:"media_metadata"`
+	// GalleryData orders the media of gallery posts, or is nil for posts
+	// which are not galleries.
+	GalleryData *GalleryData `mapstructure:"gallery_data"`
+
+	// PollData describes the poll of poll posts, or is nil for posts which
+	// are not polls.
+	PollData *PollData `mapstructure:"poll_data"`
+
+	// SubredditDetail describes the post's subreddit. It is only set for
+	// listings requested with the "sr_detail" parameter set to "true".
+	SubredditDetail *SubredditDetail `mapstructure:"sr_detail"`
+}
+
+// TotalAwardCoins returns the coins spent on the post's awards.
+func (p *Post) TotalAwardCoins() int {
+	return awardCoins(p.Awardings)
+}
+
+// FullPermalink returns the post's permalink as an absolute url.
+func (p *Post) FullPermalink() string {
+	return fullPermalink(p.permalinkBase, p.Permalink)
+}
+
+// DisplayedScore returns the post's score and whether it is hidden, in which
+// case the score is meaningless. Reddit fuzzes the scores it shows, so they
+// are approximate even when they are not hidden.
+func (p *Post) DisplayedScore() (value int, hidden bool) {
+	return int(p.Score), p.ScoreHidden
+}
+
+// BestThumbnail returns the url of the widest preview image of the post no
+// wider than maxWidth, or false if the post has no preview that narrow.
+func (p *Post) BestThumbnail(maxWidth int) (string, bool) {
+	if p.Preview == nil {
+		return "", false
+	}
+
+	best := ImageVariant{}
+	for _, image := range p.Preview.Images {
+		variants := append([]ImageVariant{image.Source}, image.Resolutions...)
+		for _, v := range variants {
+			if v.URL == "" || v.Width > maxWidth {
+				continue
+			}
+			if best.URL == "" || v.Width > best.Width {
+				best = v
+			}
+		}
+	}
+
+	return best.URL, best.URL != ""
+}
+
+// embedSrc matches the source of the iframe in an oembed's html.
+var embedSrc = regexp.MustCompile(`\bsrc="([^"]+)"`)
+
+// EmbedURL returns the url of the media embedded in the post: the video of
+// posts of videos Reddit hosts, or the source of the embed of posts linking
+// to media elsewhere, such as on YouTube. It is false if the post has no
+// media. The secure media is preferred to the media where both are set.
+func (p *Post) EmbedURL() (string, bool) {
+	for _, m := range []Media{p.SecureMedia, p.Media} {
+		if m.RedditVideo.FallbackURL != "" {
+			return m.RedditVideo.FallbackURL, true
+		}
+
+		match := embedSrc.FindStringSubmatch(html.UnescapeString(m.OEmbed.HTML))
+		if match != nil {
+			return html.UnescapeString(match[1]), true
+		}
+	}
+
+	return "", false
+}
+
+// Preview holds the images Reddit generates to preview a post's link.
+type Preview struct {
+	Images  []PreviewImage `mapstructure:"images"`
+	Enabled bool           `mapstructure:"enabled"`
+}
+
+// PreviewImage is one image of a post's preview, at its original size and
+// in the smaller resolutions Reddit scales it to.
+type PreviewImage struct {
+	ID          string         `mapstructure:"id"`
+	Source      ImageVariant   `mapstructure:"source"`
+	Resolutions []ImageVariant `mapstructure:"resolutions"`
+}
+
+// ImageVariant is an image Reddit hosts at one size.
+type ImageVariant struct {
+	URL    string `mapstructure:"url"`
+	Width  int    `mapstructure:"width"`
+	Height int    `mapstructure:"height"`
+}
+
+// GalleryImages returns the images of a gallery post in the gallery's order,
+// or none if the post is not a gallery. Images Reddit has not finished
+// processing are left out.
+func (p *Post) GalleryImages() []GalleryImage {
+	images := []GalleryImage{}
+	if p.GalleryData == nil {
+		return images
+	}
+
+	for _, item := range p.GalleryData.Items {
+		media, ok := p.MediaMetadata[item.MediaID]
+		if !ok || media.Status != validMediaStatus {
+			continue
+		}
+
+		url := media.Source.URL
+		if url == "" {
+			url = media.Source.GIF
+		}
+
+		images = append(images, GalleryImage{
+			MediaID:     item.MediaID,
+			URL:         url,
+			Width:       media.Source.Width,
+			Height:      media.Source.Height,
+			Caption:     item.Caption,
+			OutboundURL: item.OutboundURL,
+		})
+	}
+
+	return images
+}
+
+// validMediaStatus is the status of media Reddit has finished processing.
+const validMediaStatus = "valid"
+
+// MediaMetadata describes media hosted by Reddit for a post.
+type MediaMetadata struct {
+	ID     string `mapstructure:"id"`
+	Status string `mapstructure:"status"`
+	// Type is the kind of media, e.g. "Image" or "AnimatedImage".
+	Type string `mapstructure:"e"`
+	MIME string `mapstructure:"m"`
+
+	Source   MediaVariant   `mapstructure:"s"`
+	Previews []MediaVariant `mapstructure:"p"`
+}
+
+// MediaVariant is media Reddit hosts at one size. Animated media have a GIF
+// and MP4 url instead of a URL.
+type MediaVariant struct {
+	URL    string `mapstructure:"u"`
+	GIF    string `mapstructure:"gif"`
+	MP4    string `mapstructure:"mp4"`
+	Width  int    `mapstructure:"x"`
+	Height int    `mapstructure:"y"`
+}
+
+// GalleryData lists the media of a gallery post in order.
+type GalleryData struct {
+	Items []GalleryItem `mapstructure:"items"`
+}
+
+// GalleryItem is one entry of a gallery, referring to its media by id.
+type GalleryItem struct {
+	ID          int64  `mapstructure:"id"`
+	MediaID     string `mapstructure:"media_id"`
+	Caption     string `mapstructure:"caption"`
+	OutboundURL string `mapstructure:"outbound_url"`
+}
+
+// GalleryImage is an image of a gallery post.
+type GalleryImage struct {
+	MediaID     string
+	URL         string
+	Width       int
+	Height      int
+	Caption     string
+	OutboundURL string
+}
+
+// PollData is the poll of a poll post.
+type PollData struct {
+	Options        []PollOption `mapstructure:"options"`
+	TotalVoteCount int          `mapstructure:"total_vote_count"`
+	// VotingEndTimestamp is when voting closes, in milliseconds since the
+	// epoch.
+	VotingEndTimestamp int64 `mapstructure:"voting_end_timestamp"`
+	// UserSelection is the id of the option the account voted for, if it
+	// has voted.
+	UserSelection string `mapstructure:"user_selection"`
+}
+
+// VotingEnd returns when voting in the poll closes.
+func (p *PollData) VotingEnd() time.Time {
+	return time.Unix(0, p.VotingEndTimestamp*int64(time.Millisecond))
+}
+
+// PollOption is an option voters may choose in a poll. Reddit only counts the
+// votes for an option once voting closes or the account has voted.
+type PollOption struct {
+	ID        string `mapstructure:"id"`
+	Text      string `mapstructure:"text"`
+	VoteCount int    `mapstructure:"vote_count"`
+}
+
+// SubredditDetail is the summary of a subreddit Reddit expands into posts in
+// listings requested with "sr_detail", and shows on the subreddit's about
+// page.
+type SubredditDetail struct {
+	Name              string `mapstructure:"name"`
+	DisplayName       string `mapstructure:"display_name"`
+	Title             string `mapstructure:"title"`
+	PublicDescription string `mapstructure:"public_description"`
+	URL               string `mapstructure:"url"`
+	Type              string `mapstructure:"subreddit_type"`
+
+	Subscribers uint64 `mapstructure:"subscribers"`
+	// ActiveUsers is how many users are viewing the subreddit. Reddit only
+	// counts them on its about page.
+	ActiveUsers int  `mapstructure:"active_user_count"`
+	NSFW        bool `mapstructure:"over_18"`
+
+	IconImg  string `mapstructure:"icon_img"`
+	KeyColor string `mapstructure:"key_color"`
+
+	// ModPermissions are the account's moderator permissions in the
+	// subreddit, e.g. "posts" or "all", in listings of the subreddits it
+	// moderates.
+	ModPermissions []string `mapstructure:"mod_permissions"`
+}
+
+// allModPermissions is the moderator permission which grants all the others.
+const allModPermissions = "all"
+
+// HasModPermission returns whether the account's moderator permissions in the
+// subreddit, as listed by ModeratedSubreddits, include the permission.
+func (s *SubredditDetail) HasModPermission(permission string) bool {
+	for _, p := range s.ModPermissions {
+		if p == permission || p == allModPermissions {
+			return true
+		}
+	}
+	return false
 }
 
 // Message represents messages on Reddit (Reddit type t4_).
@@ -164,6 +524,14 @@ type More struct {
 	Children []string `mapstructure:"children"`
 }
 
+// ContinuesThread returns whether the More is a "continue this thread" link,
+// which Reddit leaves where a thread is too deep to nest further. These have
+// no Children to expand with MoreChildren; the comments under the More's
+// parent are fetched with ContinueThread instead.
+func (m *More) ContinuesThread() bool {
+	return len(m.Children) == 0
+}
+
 // Harvest is a set of all possible elements that Reddit could return in a
 // listing.
 //
@@ -175,7 +543,20 @@ type Harvest struct {
 	Comments []*Comment
 	Posts    []*Post
 	Messages []*Message
-	Mores    []*More
+	// Mores stand in for comments Reddit left out of the listing. Give
+	// their Children to the Lurker's MoreChildren to expand them.
+	Mores []*More
+	// Subreddits and Users are the results of searches for them.
+	Subreddits []*SubredditDetail
+	Users      []*User
+
+	// After is the name of the element Reddit suggests as the "after"
+	// reference point for the next page of the listing. It is empty if
+	// there are no more pages, or the harvest is not from a listing.
+	After string
+	// Dist is the number of elements Reddit reports the listing page held,
+	// or 0 if it did not say.
+	Dist int
 }
 
 type Submission struct {
@@ -183,3 +564,313 @@ type Submission struct {
 	Name string `mapstructure:"name"`
 	URL  string `mapstructure:"url"`
 }
+
+// Trophy represents a trophy on a user's profile (Reddit type t6_).
+type Trophy struct {
+	ID          string `mapstructure:"id"`
+	AwardID     string `mapstructure:"award_id"`
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	URL         string `mapstructure:"url"`
+
+	Icon40    string `mapstructure:"icon_40"`
+	Icon70    string `mapstructure:"icon_70"`
+	GrantedAt uint64 `mapstructure:"granted_at"`
+}
+
+// User represents the profile of a Reddit user (Reddit type t2_).
+type User struct {
+	ID         string  `mapstructure:"id"`
+	Name       string  `mapstructure:"name"`
+	CreatedUTC float64 `mapstructure:"created_utc"`
+
+	LinkKarma    int `mapstructure:"link_karma"`
+	CommentKarma int `mapstructure:"comment_karma"`
+
+	IconImg      string `mapstructure:"icon_img"`
+	SnoovatarImg string `mapstructure:"snoovatar_img"`
+
+	IsGold           bool `mapstructure:"is_gold"`
+	IsMod            bool `mapstructure:"is_mod"`
+	IsEmployee       bool `mapstructure:"is_employee"`
+	Verified         bool `mapstructure:"verified"`
+	HasVerifiedEmail bool `mapstructure:"has_verified_email"`
+	IsSuspended      bool `mapstructure:"is_suspended"`
+
+	// Subreddit is the user's profile, which Reddit keeps as a subreddit
+	// users can post to. It is nil for users without one.
+	Subreddit *UserSubreddit `mapstructure:"subreddit"`
+}
+
+// UserSubreddit is the subreddit of a user's profile.
+type UserSubreddit struct {
+	Name                string `mapstructure:"name"`
+	DisplayName         string `mapstructure:"display_name"`
+	DisplayNamePrefixed string `mapstructure:"display_name_prefixed"`
+	Title               string `mapstructure:"title"`
+	PublicDescription   string `mapstructure:"public_description"`
+	URL                 string `mapstructure:"url"`
+
+	IconImg   string `mapstructure:"icon_img"`
+	BannerImg string `mapstructure:"banner_img"`
+
+	Subscribers uint64 `mapstructure:"subscribers"`
+	NSFW        bool   `mapstructure:"over_18"`
+}
+
+// Scope describes an OAuth2 scope a Reddit app may be granted.
+type Scope struct {
+	ID          string `mapstructure:"id"`
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+}
+
+// Rule is one of the rules of a subreddit.
+type Rule struct {
+	// ShortName is the rule's title, which is also the reason Reddit
+	// offers to report things which break it.
+	ShortName   string `mapstructure:"short_name"`
+	Description string `mapstructure:"description"`
+	// ViolationReason is the reason reports for breaking the rule are
+	// given. It is the ShortName unless moderators set another.
+	ViolationReason string `mapstructure:"violation_reason"`
+	// Kind is what the rule applies to: "link", "comment" or "all".
+	Kind     string  `mapstructure:"kind"`
+	Priority int     `mapstructure:"priority"`
+	Created  float64 `mapstructure:"created_utc"`
+}
+
+// Multi is a multireddit, a custom feed of subreddits a user gathered.
+type Multi struct {
+	Name        string `mapstructure:"name"`
+	DisplayName string `mapstructure:"display_name"`
+	// Path is the multireddit's path, e.g. "/user/gopher/m/languages".
+	Path        string `mapstructure:"path"`
+	Owner       string `mapstructure:"owner"`
+	Description string `mapstructure:"description_md"`
+	// Visibility is "private", "public" or "hidden".
+	Visibility string  `mapstructure:"visibility"`
+	NSFW       bool    `mapstructure:"over_18"`
+	Created    float64 `mapstructure:"created_utc"`
+
+	// Subreddits are the names of the subreddits in the multireddit.
+	Subreddits []string `mapstructure:"-"`
+}
+
+// PostRequirements are the rules a subreddit sets for the posts submitted to
+// it. Lengths of zero are not enforced.
+type PostRequirements struct {
+	// TitleRegexes are patterns of which titles must match at least one.
+	TitleRegexes            []string `mapstructure:"title_regexes"`
+	TitleRequiredStrings    []string `mapstructure:"title_required_strings"`
+	TitleBlacklistedStrings []string `mapstructure:"title_blacklisted_strings"`
+	TitleMinLength          int      `mapstructure:"title_text_min_length"`
+	TitleMaxLength          int      `mapstructure:"title_text_max_length"`
+
+	// BodyRestrictionPolicy is "required" if self posts must have text,
+	// "notAllowed" if they must not, and "none" otherwise.
+	BodyRestrictionPolicy  string   `mapstructure:"body_restriction_policy"`
+	BodyRegexes            []string `mapstructure:"body_regexes"`
+	BodyRequiredStrings    []string `mapstructure:"body_required_strings"`
+	BodyBlacklistedStrings []string `mapstructure:"body_blacklisted_strings"`
+	BodyMinLength          int      `mapstructure:"body_text_min_length"`
+	BodyMaxLength          int      `mapstructure:"body_text_max_length"`
+
+	// LinkRestrictionPolicy is "whitelist" if link posts may only link to
+	// the DomainWhitelist, "blacklist" if they may not link to the
+	// DomainBlacklist, and "none" otherwise.
+	LinkRestrictionPolicy string   `mapstructure:"link_restriction_policy"`
+	DomainWhitelist       []string `mapstructure:"domain_whitelist"`
+	DomainBlacklist       []string `mapstructure:"domain_blacklist"`
+	// LinkRepostAge is how many days must pass before a link may be posted
+	// again.
+	LinkRepostAge int `mapstructure:"link_repost_age"`
+
+	FlairRequired bool `mapstructure:"is_flair_required"`
+	// Guidelines are the subreddit's posting guidelines, in markdown.
+	Guidelines string `mapstructure:"guidelines_text"`
+}
+
+// LiveThread is a live thread, a page of short updates posted as an event
+// unfolds.
+type LiveThread struct {
+	ID          string `mapstructure:"id"`
+	Name        string `mapstructure:"name"`
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+	// State is "live" while updates are being posted and "complete" once
+	// the thread is closed.
+	State        string  `mapstructure:"state"`
+	NSFW         bool    `mapstructure:"nsfw"`
+	ViewerCount  int     `mapstructure:"viewer_count"`
+	CreatedUTC   float64 `mapstructure:"created_utc"`
+	Announcement string  `mapstructure:"announcement_url"`
+}
+
+// Complete returns whether the live thread is closed to new updates.
+func (l *LiveThread) Complete() bool {
+	return l.State == "complete"
+}
+
+// LiveUpdate is an update posted to a live thread.
+type LiveUpdate struct {
+	// ID is the update's uuid.
+	ID       string  `mapstructure:"id"`
+	Name     string  `mapstructure:"name"`
+	Body     string  `mapstructure:"body"`
+	BodyHTML string  `mapstructure:"body_html"`
+	Author   string  `mapstructure:"author"`
+	Created  float64 `mapstructure:"created_utc"`
+	// Stricken is whether the update was struck through as mistaken.
+	Stricken bool `mapstructure:"stricken"`
+
+	Embeds []LiveEmbed `mapstructure:"embeds"`
+}
+
+// LiveEmbed is media embedded in a live update.
+type LiveEmbed struct {
+	URL    string `mapstructure:"url"`
+	Width  int    `mapstructure:"width"`
+	Height int    `mapstructure:"height"`
+}
+
+// Traffic is the traffic of a subreddit, as its moderators see it. Each
+// series is oldest first.
+type Traffic struct {
+	Hours  []TrafficPoint
+	Days   []TrafficPoint
+	Months []TrafficPoint
+}
+
+// TrafficPoint is the traffic of a subreddit in one hour, day or month.
+type TrafficPoint struct {
+	// Time is the start of the period.
+	Time      time.Time
+	Uniques   int
+	Pageviews int
+	// Subscriptions is the number of new subscribers in the period,
+	// which Reddit counts only by day.
+	Subscriptions int
+}
+
+// Collection is a collection of posts moderators gathered in a subreddit.
+type Collection struct {
+	ID          string `mapstructure:"collection_id"`
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+	Permalink   string `mapstructure:"permalink"`
+
+	SubredditID string `mapstructure:"subreddit_id"`
+	Author      string `mapstructure:"author_name"`
+
+	CreatedUTC    float64 `mapstructure:"created_at_utc"`
+	LastUpdateUTC float64 `mapstructure:"last_update_utc"`
+
+	// LinkIDs are the full names of the posts in the collection.
+	LinkIDs []string `mapstructure:"link_ids"`
+	// Posts are the posts in the collection, in its order.
+	Posts []*Post `mapstructure:"-"`
+}
+
+// SubredditKarma is the karma an account has earned in one subreddit.
+type SubredditKarma struct {
+	Subreddit    string `mapstructure:"sr"`
+	CommentKarma int    `mapstructure:"comment_karma"`
+	LinkKarma    int    `mapstructure:"link_karma"`
+}
+
+// Flair represents a user's flair in a subreddit.
+type Flair struct {
+	TemplateID string `mapstructure:"flair_template_id"`
+	Text       string `mapstructure:"flair_text"`
+	CSSClass   string `mapstructure:"flair_css_class"`
+	Position   string `mapstructure:"flair_position"`
+}
+
+// FlairRow is the flair to give one user in a bulk flair update.
+type FlairRow struct {
+	User     string
+	Text     string
+	CSSClass string
+}
+
+// FlairResult is Reddit's result of updating one user's flair in a bulk
+// flair update.
+type FlairResult struct {
+	// User is the user of the row the result is for.
+	User     string            `mapstructure:"-"`
+	OK       bool              `mapstructure:"ok"`
+	Status   string            `mapstructure:"status"`
+	Errors   map[string]string `mapstructure:"errors"`
+	Warnings map[string]string `mapstructure:"warnings"`
+}
+
+// SubredditSettings represents the settings moderators configure for a
+// subreddit. Fields are pointers so an update can change only the ones which
+// are set; nil fields are left as they are.
+type SubredditSettings struct {
+	// ID is the subreddit's full name. It is read only.
+	ID string `mapstructure:"subreddit_id"`
+
+	Title             *string `mapstructure:"title"`
+	PublicDescription *string `mapstructure:"public_description"`
+	Description       *string `mapstructure:"description"`
+	SubmitText        *string `mapstructure:"submit_text"`
+	// Type is the subreddit's visibility, e.g. "public" or "restricted".
+	Type *string `mapstructure:"subreddit_type"`
+	// LinkType is the kind of posts allowed: "any", "link" or "self".
+	LinkType *string `mapstructure:"content_options"`
+
+	NSFW                  *bool `mapstructure:"over_18"`
+	ShowMedia             *bool `mapstructure:"show_media"`
+	AllowImages           *bool `mapstructure:"allow_images"`
+	SpoilersEnabled       *bool `mapstructure:"spoilers_enabled"`
+	ExcludeBannedModqueue *bool `mapstructure:"exclude_banned_modqueue"`
+
+	CommentScoreHideMins *int `mapstructure:"comment_score_hide_mins"`
+	WikiEditKarma        *int `mapstructure:"wiki_edit_karma"`
+}
+
+// Prefs are an account's preferences. Fields are pointers so an update can
+// change only the ones which are set; nil fields are left as they are, and
+// are nil when read if Reddit did not send them.
+type Prefs struct {
+	// Lang is the account's interface language, e.g. "en".
+	Lang *string `json:"lang,omitempty"`
+	// DefaultCommentSort is how comment trees are sorted, e.g.
+	// "confidence" or "new".
+	DefaultCommentSort *string `json:"default_comment_sort,omitempty"`
+	// MinCommentScore and MinLinkScore hide comments and posts scored
+	// below them.
+	MinCommentScore *int `json:"min_comment_score,omitempty"`
+	MinLinkScore    *int `json:"min_link_score,omitempty"`
+	NumComments     *int `json:"num_comments,omitempty"`
+	NumSites        *int `json:"numsites,omitempty"`
+
+	Over18              *bool `json:"over_18,omitempty"`
+	SearchIncludeOver18 *bool `json:"search_include_over_18,omitempty"`
+	LabelNSFW           *bool `json:"label_nsfw,omitempty"`
+	ShowLinkFlair       *bool `json:"show_link_flair,omitempty"`
+	ShowFlair           *bool `json:"show_flair,omitempty"`
+	HideUps             *bool `json:"hide_ups,omitempty"`
+	HideDowns           *bool `json:"hide_downs,omitempty"`
+	HideFromRobots      *bool `json:"hide_from_robots,omitempty"`
+
+	// ShowPresence is whether others see the account online.
+	ShowPresence *bool `json:"show_presence,omitempty"`
+	// AcceptPMs is who may message the account: "everyone" or
+	// "whitelisted".
+	AcceptPMs *string `json:"accept_pms,omitempty"`
+
+	EmailMessages                 *bool `json:"email_messages,omitempty"`
+	EmailDigests                  *bool `json:"email_digests,omitempty"`
+	ThreadedMessages              *bool `json:"threaded_messages,omitempty"`
+	MarkMessagesRead              *bool `json:"mark_messages_read,omitempty"`
+	MonitorMentions               *bool `json:"monitor_mentions,omitempty"`
+	EnableFollowers               *bool `json:"enable_followers,omitempty"`
+	ShowTwitter                   *bool `json:"show_twitter,omitempty"`
+	NightMode                     *bool `json:"nightmode,omitempty"`
+	PrivateFeeds                  *bool `json:"private_feeds,omitempty"`
+	ProfileOptOut                 *bool `json:"profile_opt_out,omitempty"`
+	ThirdPartyDataPersonalizedAds *bool `json:"third_party_data_personalized_ads,omitempty"`
+}
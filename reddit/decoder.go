@@ -0,0 +1,59 @@
+package reddit
+
+// Decoder decodes responses from Reddit into caller provided types.
+type Decoder interface {
+	// Decode makes the request and decodes Reddit's json response into
+	// dst, which is anything encoding/json can decode into, as the
+	// response is read instead of after reading all of it. The arrays in
+	// the response which dst decodes into slices, like the children of a
+	// listing, are decoded an element at a time, so a big comment tree is
+	// never held in memory whole, only the fields of it dst keeps. Since
+	// it decodes the response as it is read, Decode uses encoding/json
+	// rather than the bot's Codec, but decodes as the default Codec does:
+	// numbers in interface values are json.Number, and a response with
+	// more after its json value is an error.
+	//
+	// HTTP errors are returned as they are for other requests, but Reddit
+	// also reports some errors in a json envelope in a successful
+	// response, and Decode cannot see those without reading the whole
+	// response. Give dst a field for the envelope, such as
+	//
+	//   JSON struct { Errors [][]string `json:"errors"` } `json:"json"`
+	//
+	// to check for them on write endpoints.
+	Decode(req Request, dst interface{}) error
+	// Capture makes the request, decodes Reddit's json response into dst
+	// and returns the response as Reddit sent it, so it can be kept, e.g.
+	// for auditing, without fetching it again. Unlike Decode it reads the
+	// whole response first, so errors Reddit reports in a json envelope
+	// are returned, along with the response.
+	Capture(req Request, dst interface{}) ([]byte, error)
+}
+
+type decoder struct {
+	r reaper
+}
+
+func newDecoder(r reaper) Decoder {
+	return &decoder{r: r}
+}
+
+func (d *decoder) Decode(req Request, dst interface{}) error {
+	return d.r.decode(req, dst)
+}
+
+func (d *decoder) Capture(req Request, dst interface{}) ([]byte, error) {
+	resp, err := d.r.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := envelopeError(d.r.bodyCodec(), resp); err != nil {
+		return resp, err
+	}
+
+	if err := d.r.bodyCodec().Unmarshal(resp, dst); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
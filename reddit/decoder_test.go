@@ -0,0 +1,213 @@
+package reddit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	r := &mockReaper{}
+	d := newDecoder(r)
+
+	var dst map[string]interface{}
+	if err := d.Decode(
+		Request{Method: "GET", Path: "/r/golang/comments/abc"}, &dst,
+	); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	if r.path != "/r/golang/comments/abc" {
+		t.Errorf("decoded wrong path: %s", r.path)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	raw := []byte(`{"kind": "t2", "data": {"name": "gopher", "link_karma": 5}}`)
+	r := &mockReaper{raw: raw}
+	d := newDecoder(r)
+
+	var dst struct {
+		Kind string `json:"kind"`
+		Data struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	got, err := d.Capture(Request{Method: "GET", Path: "/user/gopher/about"}, &dst)
+	if err != nil {
+		t.Fatalf("failed to capture: %v", err)
+	}
+
+	if !bytes.Equal(got, raw) {
+		t.Errorf("captured %s; wanted %s", got, raw)
+	}
+
+	if dst.Kind != "t2" || dst.Data.Name != "gopher" {
+		t.Errorf("decoded capture incorrectly: %+v", dst)
+	}
+
+	if r.path != "/user/gopher/about" {
+		t.Errorf("captured wrong path: %s", r.path)
+	}
+
+	r.raw = []byte(`{"json": {"errors": [["TOO_OLD", "that's a piece of history now", "parent"]]}}`)
+	got, err = d.Capture(Request{Method: "POST", Path: "/api/comment"}, &dst)
+	if err != ArchivedErr {
+		t.Errorf("wanted ArchivedErr from the errors envelope; got %v", err)
+	}
+	if !bytes.Equal(got, r.raw) {
+		t.Errorf("wanted the envelope captured with its error; got %s", got)
+	}
+
+	r.raw = []byte(`[{"kind": "Listing"}, {"kind": "Listing"}]`)
+	var listings []map[string]interface{}
+	if _, err := d.Capture(Request{Method: "GET", Path: "/comments/abc"}, &listings); err != nil || len(listings) != 2 {
+		t.Errorf("wanted two listings captured; got %v, %v", listings, err)
+	}
+
+	r.err = PermissionDeniedErr
+	if got, err := d.Capture(Request{Method: "GET", Path: "/about"}, &dst); err != PermissionDeniedErr || got != nil {
+		t.Errorf("wanted PermissionDeniedErr and no capture; got %s, %v", got, err)
+	}
+}
+
+func TestDecodeMatchesCapture(t *testing.T) {
+	blob := []byte(`{"kind": "Listing", "data": {"children": [
+		{"kind": "t1", "data": {"name": "t1_a", "score": 12345678901234567890,
+		"edited": 1.5, "gildings": {"gid_1": 2}}}
+	], "dist": 1}}`)
+	serv := serverWhich(blob, http.StatusOK)
+	defer serv.Close()
+
+	d := newDecoder(serverReaper(t, serv))
+	for _, dst := range []func() interface{}{
+		func() interface{} { return &map[string]interface{}{} },
+		func() interface{} { var v interface{}; return &v },
+		func() interface{} {
+			return &struct {
+				Data struct {
+					Children []struct {
+						Data map[string]interface{} `json:"data"`
+					} `json:"children"`
+					Dist interface{} `json:"dist"`
+				} `json:"data"`
+			}{}
+		},
+	} {
+		decoded, captured := dst(), dst()
+		if err := d.Decode(Request{Method: "GET", Path: "/comments"}, decoded); err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if _, err := d.Capture(Request{Method: "GET", Path: "/comments"}, captured); err != nil {
+			t.Fatalf("failed to capture: %v", err)
+		}
+
+		if !reflect.DeepEqual(decoded, captured) {
+			t.Errorf("decoded %#v; captured %#v", decoded, captured)
+		}
+	}
+}
+
+func TestDecodeTrailingData(t *testing.T) {
+	serv := serverWhich([]byte(`{"kind": "t2"} {"kind": "t3"}`), http.StatusOK)
+	defer serv.Close()
+
+	d := newDecoder(serverReaper(t, serv))
+	for _, dst := range []interface{}{
+		&map[string]interface{}{},
+		&struct {
+			Kind string `json:"kind"`
+		}{},
+	} {
+		if err := d.Decode(Request{Method: "GET", Path: "/about"}, dst); err != errTrailingData {
+			t.Errorf("wanted errTrailingData decoding into %T; got %v", dst, err)
+		}
+	}
+}
+
+// serverReaper returns a reaper which sends its requests to serv.
+func serverReaper(t *testing.T, serv *httptest.Server) *reaperImpl {
+	host, err := url.Parse(serv.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server url: %v", err)
+	}
+
+	return &reaperImpl{
+		cli:      &baseClient{cli: &http.Client{}},
+		parser:   newParser(),
+		hostname: host.Host,
+		scheme:   "http",
+		mu:       &sync.Mutex{},
+	}
+}
+
+// largeComments returns a json listing of n comments.
+func largeComments(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"kind": "Listing", "data": {"children": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(
+			&buf,
+			`{"kind": "t1", "data": {"name": "t1_%d", "body": "%s"}}`,
+			i, bytes.Repeat([]byte("comment "), 64),
+		)
+	}
+	buf.WriteString(`]}}`)
+	return buf.Bytes()
+}
+
+// dump is the part of a comment listing decoded in the benchmarks.
+type dump struct {
+	Data struct {
+		Children []struct {
+			Data struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+func benchmarkDecode(b *testing.B, stream bool) {
+	serv := serverWhich(largeComments(5000), http.StatusOK)
+	defer serv.Close()
+	c := &baseClient{cli: &http.Client{}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var d dump
+		if stream {
+			err = c.DoStream(req, &d)
+		} else {
+			var body []byte
+			if body, err = c.Do(req); err == nil {
+				err = json.Unmarshal(body, &d)
+			}
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBuffered(b *testing.B) {
+	benchmarkDecode(b, false)
+}
+
+func BenchmarkDecodeStream(b *testing.B) {
+	benchmarkDecode(b, true)
+}
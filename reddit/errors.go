@@ -2,6 +2,7 @@ package reddit
 
 import (
 	"fmt"
+	"strings"
 )
 
 var (
@@ -11,4 +12,220 @@ var (
 	GatewayErr            = fmt.Errorf("502 bad gateway code from Reddit")
 	GatewayTimeoutErr     = fmt.Errorf("504 gateway timeout from Reddit")
 	ThreadDoesNotExistErr = fmt.Errorf("The requested post does not exist.")
+	// CommentDoesNotExistErr is returned when a requested comment does not
+	// exist.
+	CommentDoesNotExistErr = fmt.Errorf("the requested comment does not exist")
+	// InvalidCredentialsErr is returned when Reddit rejects the username
+	// or password of a bot's account while authorizing.
+	InvalidCredentialsErr = fmt.Errorf("Reddit rejected the account's username or password")
+	// InvalidClientErr is returned when Reddit rejects the ID or Secret of
+	// an app while authorizing.
+	InvalidClientErr = fmt.Errorf("Reddit rejected the app's id or secret")
+	// TwoFactorRequiredErr is returned when the bot's account has two
+	// factor authentication enabled and the app's OTP is missing or wrong.
+	TwoFactorRequiredErr = fmt.Errorf("Reddit requires a two factor code for the account")
+	// AccountSuspendedErr is returned when the bot's account is suspended.
+	AccountSuspendedErr = fmt.Errorf("the account is suspended")
+	// TokenRevokedErr is returned for requests made by a bot after its
+	// token is revoked.
+	TokenRevokedErr = fmt.Errorf("the bot's token was revoked")
+	// DuplicateSubmissionErr is returned instead of making a submission
+	// identical to one made within the bot's DuplicateWindow.
+	DuplicateSubmissionErr = fmt.Errorf("identical submission was already made")
+	// StickyConflictErr is returned when Reddit refuses to change a post's
+	// sticky state, because it is already in that state or there is no
+	// stickied post to replace in the slot.
+	StickyConflictErr = fmt.Errorf("Reddit refused to change the post's sticky state")
+	// ProfilePostingErr is returned when submitting to a user profile which
+	// does not accept submissions from the account, because it belongs to
+	// someone else, does not exist, or has posting disabled.
+	ProfilePostingErr = fmt.Errorf("the profile does not accept submissions from the account")
+	// NotPollErr is returned when voting in the poll of a post which is not
+	// a poll.
+	NotPollErr = fmt.Errorf("the post is not a poll")
+	// PollClosedErr is returned when voting in a poll after voting closed.
+	PollClosedErr = fmt.Errorf("voting in the poll has closed")
+	// InsufficientCoinsErr is returned when giving an award the account
+	// does not have the coins for.
+	InsufficientCoinsErr = fmt.Errorf("the account does not have enough coins for the award")
+	// MissingRefreshTokenErr is returned with the token when exchanging a
+	// code for a permanent grant yields no refresh token, usually because
+	// the authorization url asked for a temporary one.
+	MissingRefreshTokenErr = fmt.Errorf("Reddit issued no refresh token; the grant is temporary")
+	// EmptyBodyErr is returned when Reddit answers a GET successfully but
+	// with nothing in the body, which happens now and then and usually
+	// passes.
+	EmptyBodyErr = fmt.Errorf("Reddit sent an empty response")
+	// RetryBudgetExhaustedErr is returned in place of a request's error
+	// when the request would have been retried, but its retryer's
+	// RetryBudget is spent.
+	RetryBudgetExhaustedErr = fmt.Errorf("the retry budget is spent")
+	// ArchivedErr is returned when Reddit refuses a write to a post or
+	// comment because it is archived. The Archived field of posts and
+	// comments tells before writing.
+	ArchivedErr = fmt.Errorf("the post or comment is archived")
+	// AppRateLimitErr is returned in place of RateLimitErr for 429s which
+	// come after Reddit's rate limit headers say the app's requests for the
+	// period are spent. It is retried and batched as RateLimitErr is.
+	AppRateLimitErr = fmt.Errorf("Reddit is rate limiting the app's requests")
 )
+
+// notFoundErr is returned for 404 responses, so readers of resources which
+// may not exist can tell them apart from other failures.
+var notFoundErr = fmt.Errorf("bad response code: 404")
+
+// searchRedirectErr is returned for requests Reddit redirected to a search for
+// subreddits, which it does for listings of subreddits that do not exist.
+var searchRedirectErr = fmt.Errorf("redirected to subreddit search")
+
+// conflictErr is returned for 409 responses, which Reddit sends when a
+// request conflicts with the current state of what it changes.
+var conflictErr = fmt.Errorf("bad response code: 409")
+
+// quarantinedReason is the reason Reddit gives for refusing to serve a
+// quarantined subreddit to an account which has not opted in to it.
+const quarantinedReason = "quarantined"
+
+// QuarantinedError is returned when reading a quarantined subreddit the bot's
+// account has not opted in to. Opt in with the Account's QuarantineOptIn, and
+// the same read will succeed.
+type QuarantinedError struct {
+	// Message is Reddit's warning about the subreddit's content.
+	Message string
+}
+
+// MissingScopesError is returned when the bot's token lacks OAuth2 scopes it
+// requires.
+type MissingScopesError struct {
+	// Scopes are the required scopes Reddit did not grant.
+	Scopes []string
+}
+
+func (m *MissingScopesError) Error() string {
+	return "the bot's token is missing scopes: " + strings.Join(m.Scopes, ", ")
+}
+
+func (q *QuarantinedError) Error() string {
+	return "the subreddit is quarantined and requires opting in: " + q.Message
+}
+
+// UnexpectedHTMLError is returned when Reddit answers with an html page where
+// json was expected, which it does with a 200 for some interstitials and for
+// clients it blocks.
+type UnexpectedHTMLError struct {
+	// Snippet is the start of the page, with its whitespace collapsed.
+	Snippet string
+	// Blocked is set if the page is the one Reddit serves to clients it
+	// blocks, usually for their user agent or network.
+	Blocked bool
+}
+
+func (u *UnexpectedHTMLError) Error() string {
+	if u.Blocked {
+		return "Reddit blocked the request with an html page: " + u.Snippet
+	}
+	return "Reddit sent an html page instead of json: " + u.Snippet
+}
+
+// UserSuspendedError is returned when reading the profile of a suspended
+// user.
+type UserSuspendedError struct {
+	// User is the name of the suspended user.
+	User string
+}
+
+func (u *UserSuspendedError) Error() string {
+	return "the user is suspended: " + u.User
+}
+
+// UserNotFoundError is returned when reading the profile of a user who does
+// not exist, which includes users who deleted their account and users Reddit
+// shadowbanned.
+type UserNotFoundError struct {
+	// User is the name of the missing user.
+	User string
+}
+
+func (u *UserNotFoundError) Error() string {
+	return "the user does not exist: " + u.User
+}
+
+// SubredditNotFoundError is returned when reading a listing of a subreddit
+// which does not exist, which Reddit answers with a 404 or by redirecting to
+// a search for subreddits with the name. Listings of subreddits which exist
+// but have nothing in them are empty instead.
+type SubredditNotFoundError struct {
+	// Subreddit is the name of the missing subreddit.
+	Subreddit string
+}
+
+func (s *SubredditNotFoundError) Error() string {
+	return "the subreddit does not exist: " + s.Subreddit
+}
+
+// NoStickyError is returned when reading the sticky post of a subreddit's
+// sticky slot which is empty.
+type NoStickyError struct {
+	// Subreddit is the name of the subreddit.
+	Subreddit string
+	// Slot is the empty slot, 1 or 2.
+	Slot int
+}
+
+func (n *NoStickyError) Error() string {
+	return fmt.Sprintf("r/%s has no sticky in slot %d", n.Subreddit, n.Slot)
+}
+
+// GraphQLError is returned when Reddit's GraphQL gateway reports errors for a
+// query. Data it returned alongside them is still decoded.
+type GraphQLError struct {
+	// Messages are the messages of the errors, in the order Reddit gave
+	// them.
+	Messages []string
+}
+
+func (g *GraphQLError) Error() string {
+	return "GraphQL errors: " + strings.Join(g.Messages, "; ")
+}
+
+// UnrecordedRequestError is returned by a replay transport for requests which
+// were not recorded.
+type UnrecordedRequestError struct {
+	Method string
+	URL    string
+}
+
+func (u *UnrecordedRequestError) Error() string {
+	return "no recorded response for " + u.Method + " " + u.URL
+}
+
+// BodyTooLongError is returned for submissions with text longer than Reddit
+// accepts, before they are sent.
+type BodyTooLongError struct {
+	// Field is the text which is too long, e.g. "title" or "comment".
+	Field string
+	// Length is how many characters the text has.
+	Length int
+	// Limit is the most characters the field may have.
+	Limit int
+}
+
+func (b *BodyTooLongError) Error() string {
+	return fmt.Sprintf(
+		"%s is %d characters; the limit is %d", b.Field, b.Length, b.Limit,
+	)
+}
+
+// PostRequirementError is returned by ValidateSubmission for posts which break
+// a subreddit's post requirements.
+type PostRequirementError struct {
+	// Field is the part of the post which breaks the requirement: "title",
+	// "body", "url" or "flair".
+	Field string
+	// Reason describes the requirement.
+	Reason string
+}
+
+func (p *PostRequirementError) Error() string {
+	return "the post's " + p.Field + " " + p.Reason
+}
@@ -0,0 +1,96 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// graphQLURL is the url of Reddit's GraphQL gateway.
+const graphQLURL = "https://gql.reddit.com/"
+
+// graphQLRequest is the body of a GraphQL query.
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the body of the gateway's answer to a query.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQLHost returns the host of the GraphQL gateway at gatewayURL, or of
+// Reddit's if gatewayURL is empty, to trust with the bot's credentials.
+func graphQLHost(gatewayURL string) string {
+	if gatewayURL == "" {
+		gatewayURL = graphQLURL
+	}
+
+	gateway, err := url.Parse(gatewayURL)
+	if err != nil {
+		return ""
+	}
+	return gateway.Host
+}
+
+func (b *bot) GraphQL(
+	ctx context.Context,
+	query string,
+	variables map[string]interface{},
+	out interface{},
+) error {
+	gatewayURL := b.graphQLURL
+	if gatewayURL == "" {
+		gatewayURL = graphQLURL
+	}
+
+	gateway, err := url.Parse(gatewayURL)
+	if err != nil || gateway.Host == "" {
+		return fmt.Errorf("invalid GraphQL url %q", gatewayURL)
+	}
+
+	path := gateway.Path
+	if path == "" {
+		path = "/"
+	}
+
+	body, err := b.r.bodyCodec().Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	var resp graphQLResponse
+	if err := b.r.decode(
+		Request{
+			Method:  "POST",
+			Host:    gateway.Host,
+			Path:    path,
+			JSON:    body,
+			Context: ctx,
+		},
+		&resp,
+	); err != nil {
+		return err
+	}
+
+	if out != nil && len(resp.Data) != 0 && string(resp.Data) != "null" {
+		if err := b.r.bodyCodec().Unmarshal(resp.Data, out); err != nil {
+			return err
+		}
+	}
+
+	if len(resp.Errors) != 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return &GraphQLError{Messages: messages}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// graphQLServerWhich answers GraphQL queries with the body, sending each
+// query it gets on queries.
+func graphQLServerWhich(body string, queries chan<- graphQLRequest) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/json" {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+
+				var query graphQLRequest
+				if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				queries <- query
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+			},
+		),
+	)
+}
+
+// graphQLBot returns a bot which sends GraphQL queries to the server.
+func graphQLBot(serv *httptest.Server) *bot {
+	return &bot{
+		r: &reaperImpl{
+			cli:      &baseClient{cli: &http.Client{}},
+			parser:   newParser(),
+			hostname: "oauth.reddit.com",
+			scheme:   "http",
+			mu:       &sync.Mutex{},
+			trustedHosts: hostSet(
+				[]string{graphQLHost(serv.URL)},
+			),
+		},
+		graphQLURL: serv.URL,
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	queries := make(chan graphQLRequest, 1)
+	serv := graphQLServerWhich(
+		`{"data": {"subredditInfoByName": {"id": "t5_2rc7j", "title": "Go"}}}`,
+		queries,
+	)
+	defer serv.Close()
+
+	var out struct {
+		Subreddit struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"subredditInfoByName"`
+	}
+	if err := graphQLBot(serv).GraphQL(
+		context.Background(),
+		"query($name: String!) { subredditInfoByName(name: $name) { id title } }",
+		map[string]interface{}{"name": "golang"},
+		&out,
+	); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+
+	query := <-queries
+	if !strings.Contains(query.Query, "subredditInfoByName") ||
+		query.Variables["name"] != "golang" {
+		t.Errorf("gateway got query %+v", query)
+	}
+
+	if out.Subreddit.ID != "t5_2rc7j" || out.Subreddit.Title != "Go" {
+		t.Errorf("decoded %+v from the response", out)
+	}
+}
+
+func TestGraphQLHost(t *testing.T) {
+	for gatewayURL, host := range map[string]string{
+		"":                           "gql.reddit.com",
+		"http://127.0.0.1:8080/gql/": "127.0.0.1:8080",
+	} {
+		if got := graphQLHost(gatewayURL); got != host {
+			t.Errorf("gateway %q has host %q; wanted %q", gatewayURL, got, host)
+		}
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	queries := make(chan graphQLRequest, 1)
+	serv := graphQLServerWhich(
+		`{"data": {"a": 1}, "errors": [{"message": "b is unknown"}, {"message": "c is unknown"}]}`,
+		queries,
+	)
+	defer serv.Close()
+
+	var out struct{ A int }
+	err := graphQLBot(serv).GraphQL(context.Background(), "{ a b c }", nil, &out)
+
+	gqlErr, ok := err.(*GraphQLError)
+	if !ok {
+		t.Fatalf("wanted GraphQLError; got %v", err)
+	}
+
+	if diff := pretty.Compare(gqlErr.Messages, []string{"b is unknown", "c is unknown"}); diff != "" {
+		t.Errorf("messages incorrect; diff: %s", diff)
+	}
+
+	if out.A != 1 {
+		t.Errorf("wanted the partial data decoded; got %+v", out)
+	}
+}
+
+func TestGraphQLContext(t *testing.T) {
+	release := make(chan bool)
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-release:
+				}
+			},
+		),
+	)
+	defer serv.Close()
+	// The server cannot tell the client gave up while the query's body is
+	// unread, so the handler is released before the server is closed.
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := graphQLBot(serv).GraphQL(ctx, "{ a }", nil, nil); err == nil {
+		t.Errorf("wanted the query canceled")
+	}
+}
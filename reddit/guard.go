@@ -0,0 +1,81 @@
+package reddit
+
+import (
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+)
+
+// submissionKey identifies a submission by a hash of its contents.
+type submissionKey [sha256.Size]byte
+
+// submissionGuard remembers recent submissions so they are not made twice.
+type submissionGuard struct {
+	window time.Duration
+	clock  Clock
+	mu     *sync.Mutex
+	seen   map[submissionKey]time.Time
+}
+
+// newSubmissionGuard returns a guard that refuses duplicates within window on
+// the clock, or nil if window is not positive.
+func newSubmissionGuard(window time.Duration, clock Clock) *submissionGuard {
+	if window <= 0 {
+		return nil
+	}
+
+	return &submissionGuard{
+		window: window,
+		clock:  clockOrReal(clock),
+		mu:     &sync.Mutex{},
+		seen:   make(map[submissionKey]time.Time),
+	}
+}
+
+// claim records a submission made of the given parts, or returns
+// DuplicateSubmissionErr if it was already made within the window.
+func (g *submissionGuard) claim(parts ...string) (submissionKey, error) {
+	key := submissionKey(sha256.Sum256([]byte(strings.Join(parts, "\x00"))))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	for k, t := range g.seen {
+		if now.Sub(t) >= g.window {
+			delete(g.seen, k)
+		}
+	}
+
+	if _, ok := g.seen[key]; ok {
+		return key, DuplicateSubmissionErr
+	}
+
+	g.seen[key] = now
+	return key, nil
+}
+
+// release forgets a claimed submission so it may be made again.
+func (g *submissionGuard) release(key submissionKey) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.seen, key)
+}
+
+// refused is true if err means Reddit answered and did not take the
+// submission, so retrying it cannot make a duplicate. For other errors, such
+// as timeouts, the submission may have gone through.
+func refused(err error) bool {
+	switch err.(type) {
+	case *RateLimitError:
+		return true
+	}
+
+	switch err {
+	case PermissionDeniedErr, BusyErr, RateLimitErr, AppRateLimitErr, ProfilePostingErr:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package reddit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDuplicateSubmissionAfterTimeout(t *testing.T) {
+	r := reaperWhich(Harvest{}, fmt.Errorf("request timed out"))
+	a := newAccount(r, accountConfig{duplicateWindow: time.Minute})
+
+	if err := a.PostSelf("self", "title", "text"); err != r.err {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The timed out submission may have gone through, so the retry must
+	// not reach Reddit.
+	r.path = ""
+	r.err = nil
+	if err := a.PostSelf("self", "title", "text"); err != DuplicateSubmissionErr {
+		t.Errorf("wanted DuplicateSubmissionErr; got %v", err)
+	}
+	if _, err := a.GetPostSelf("self", "title", "text"); err != DuplicateSubmissionErr {
+		t.Errorf("wanted DuplicateSubmissionErr; got %v", err)
+	}
+	if r.path != "" {
+		t.Errorf("duplicate submission was sent to %s", r.path)
+	}
+
+	if err := a.PostSelf("self", "title", "other text"); err != nil {
+		t.Errorf("distinct submission refused: %v", err)
+	}
+}
+
+func TestDuplicateSubmissionAfterRefusal(t *testing.T) {
+	r := reaperWhich(Harvest{}, BusyErr)
+	a := newAccount(r, accountConfig{duplicateWindow: time.Minute})
+
+	if err := a.PostLink("link", "title", "url"); err != BusyErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.err = nil
+	if err := a.PostLink("link", "title", "url"); err != nil {
+		t.Errorf("retry of refused submission failed: %v", err)
+	}
+}
+
+func TestDuplicateSubmissionWindow(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	g := newSubmissionGuard(time.Minute, clock)
+	if _, err := g.claim("a"); err != nil {
+		t.Fatalf("first claim failed: %v", err)
+	}
+
+	if _, err := g.claim("a"); err != DuplicateSubmissionErr {
+		t.Errorf("wanted DuplicateSubmissionErr; got %v", err)
+	}
+
+	clock.Advance(59 * time.Second)
+	if _, err := g.claim("a"); err != DuplicateSubmissionErr {
+		t.Errorf("wanted DuplicateSubmissionErr within the window; got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if _, err := g.claim("a"); err != nil {
+		t.Errorf("claim after window failed: %v", err)
+	}
+
+	if newSubmissionGuard(0, nil) != nil {
+		t.Errorf("wanted no guard for a zero window")
+	}
+}
@@ -0,0 +1,67 @@
+package reddit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// inFlight counts the requests a client has in flight and, if it has a cap,
+// holds requests beyond it until others finish.
+type inFlight struct {
+	// slots holds a token for each request in flight, if there is a cap.
+	slots chan struct{}
+	count int32
+}
+
+// newInFlight returns the in flight count of a client which has at most max
+// requests in flight at once, or any number if max is not positive.
+func newInFlight(max int) *inFlight {
+	f := &inFlight{}
+	if max > 0 {
+		f.slots = make(chan struct{}, max)
+	}
+	return f
+}
+
+// acquire waits until the request may be sent, or until its context is done.
+func (f *inFlight) acquire(ctx context.Context) error {
+	if f.slots != nil {
+		select {
+		case f.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	atomic.AddInt32(&f.count, 1)
+	return nil
+}
+
+// release ends a request acquire let through.
+func (f *inFlight) release() {
+	atomic.AddInt32(&f.count, -1)
+	if f.slots != nil {
+		<-f.slots
+	}
+}
+
+// current returns the number of requests in flight.
+func (f *inFlight) current() int {
+	return int(atomic.LoadInt32(&f.count))
+}
+
+// releasingBody is the body of a response which releases its request's place
+// in flight when it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,84 @@
+package reddit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	serving, most := 0, 0
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				serving++
+				if serving > most {
+					most = serving
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				serving--
+				mu.Unlock()
+				w.Write([]byte("{}"))
+			},
+		),
+	)
+	defer serv.Close()
+
+	c := &baseClient{cli: &http.Client{}, inFlight: newInFlight(3)}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequest("GET", serv.URL, nil)
+			if err != nil {
+				t.Errorf("failed to prepare request for test: %v", err)
+				return
+			}
+			if _, err := c.Do(req); err != nil {
+				t.Errorf("request failed: %v", err)
+			}
+			if n := c.inFlightRequests(); n > 3 {
+				t.Errorf("%d requests in flight; wanted at most 3", n)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if most > 3 {
+		t.Errorf("%d requests were served at once; wanted at most 3", most)
+	}
+	if n := c.inFlightRequests(); n != 0 {
+		t.Errorf("%d requests in flight after all finished", n)
+	}
+}
+
+func TestMaxConcurrentRequestsContext(t *testing.T) {
+	f := newInFlight(1)
+	if err := f.acquire(context.Background()); err != nil {
+		t.Fatalf("failed to acquire a free slot: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.acquire(ctx); err != context.Canceled {
+		t.Errorf("wanted context.Canceled; got %v", err)
+	}
+	if n := f.current(); n != 1 {
+		t.Errorf("%d requests in flight; wanted 1", n)
+	}
+
+	f.release()
+	if n := f.current(); n != 0 {
+		t.Errorf("%d requests in flight after release; wanted 0", n)
+	}
+}
@@ -1,17 +1,185 @@
 package reddit
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
 // Lurker defines browsing behavior.
 type Lurker interface {
 	// Thread returns a Reddit post with a fully parsed comment tree.
 	Thread(permalink string) (*Post, error)
+
+	// UserTrophies returns the trophies on a user's profile.
+	UserTrophies(user string) ([]*Trophy, error)
+	// UserAbout returns a user's profile. It returns a UserSuspendedError
+	// for suspended users and a UserNotFoundError for users who do not
+	// exist.
+	UserAbout(user string) (*User, error)
+
+	// Post returns the post with the given full name (t3_xxxxxx), without
+	// its comments.
+	Post(name string) (*Post, error)
+	// Comment returns the comment with the given full name (t1_xxxxxx),
+	// without its replies.
+	Comment(name string) (*Comment, error)
+
+	// UsernameAvailable returns whether a username can be registered.
+	UsernameAvailable(name string) (bool, error)
+	// SearchSubredditNames returns the names of subreddits which begin
+	// with the query.
+	SearchSubredditNames(query string, includeOver18 bool) ([]string, error)
+	// SubredditAutocomplete returns the subreddits Reddit suggests for a
+	// partly typed name, as its search box does. With includeProfiles, the
+	// profiles of matching users are suggested too, as subreddits of Type
+	// "user". An empty query has no suggestions.
+	SubredditAutocomplete(
+		query string,
+		includeOver18, includeProfiles bool,
+	) ([]*SubredditDetail, error)
+
+	// Sticky returns the post stickied in a subreddit's sticky slot, 1 or
+	// 2, with its comments. It returns a NoStickyError if the slot is
+	// empty.
+	Sticky(subreddit string, slot int) (*Post, error)
+
+	// SubredditAbout returns the description of a subreddit on its about
+	// page, with its subscriber and active user counts.
+	SubredditAbout(subreddit string) (*SubredditDetail, error)
+	// SubredditStats returns the subscriber and active user counts of a
+	// subreddit. The about page they are read from is kept for ttl, and
+	// calls within it return the kept counts without a request.
+	SubredditStats(subreddit string, ttl time.Duration) (
+		subscribers, activeUsers int,
+		err error,
+	)
+
+	// SubredditRules returns the rules of a subreddit in their order. It
+	// returns no rules for subreddits which have none.
+	SubredditRules(subreddit string) ([]*Rule, error)
+	// PostRequirements returns the rules a subreddit sets for posts
+	// submitted to it. Check a post against them with ValidateSubmission
+	// before submitting it.
+	PostRequirements(subreddit string) (*PostRequirements, error)
+	// CrosspostTargets returns the candidate subreddits a post, by full
+	// name, could be crossposted to without flair: those whose post
+	// requirements its title meets and which can be read. It returns none
+	// if the post is not crosspostable.
+	CrosspostTargets(postName string, candidates []string) ([]string, error)
+
+	// Multireddit returns the multireddit at the path, e.g.
+	// "/user/gopher/m/languages".
+	Multireddit(path string) (*Multi, error)
+
+	// Collection returns the collection of posts with the given id.
+	Collection(id string) (*Collection, error)
+
+	// LiveThread returns the live thread with the given id.
+	LiveThread(id string) (*LiveThread, error)
+	// LiveUpdates returns the newest updates to a live thread, newest
+	// first.
+	LiveUpdates(id string) ([]*LiveUpdate, error)
+
+	// Scopes returns the descriptions of the OAuth2 scopes Reddit offers
+	// apps, by scope id.
+	Scopes() (map[string]Scope, error)
+
+	// Info returns the posts and comments with the given full names, in the
+	// order Reddit returns them. Names of things which do not exist are
+	// left out. Many names are looked up over several requests.
+	Info(names []string) (Harvest, error)
+	// MoreChildren returns the comments a More in the thread of a post, by
+	// its full name, stands in for, given the More's Children. The harvest
+	// has Mores for the comments Reddit did not expand. Many children are
+	// expanded over several requests. Mores which ContinuesThread have no
+	// children; use ContinueThread for those.
+	MoreChildren(postName string, children []string) (Harvest, error)
+	// ContinueThread returns the replies to a comment in the thread of a
+	// post, for Mores which ContinuesThread. It takes the full name or id
+	// of the post and comment, such as a More's ParentID.
+	ContinueThread(postName, commentName string) ([]*Comment, error)
+	// CommentContext returns a post and the focused tree of one of its
+	// comments, as a permalink to the comment shows it: the comment's
+	// replies, and up to context (0 to 10) of its ancestors, in the given
+	// sort, or Reddit's default if sort is empty. The post's Replies are
+	// the tree too. It takes the full name or id of the post and comment,
+	// and returns CommentDoesNotExistErr if the comment is not in the
+	// thread.
+	CommentContext(postName, commentName string, context int, sort string) (
+		*Post,
+		[]*Comment,
+		error,
+	)
+}
+
+const (
+	// maxInfoNames is the most full names Reddit looks up in one
+	// /api/info request.
+	maxInfoNames = 100
+	// maxMoreChildren is the most comments Reddit expands in one
+	// /api/morechildren request.
+	maxMoreChildren = 100
+)
+
+// SplitLimits are the most items the Lurker sends in each request to
+// endpoints which take lists of them. Longer lists are split across several
+// requests. The zero value uses Reddit's limits.
+type SplitLimits struct {
+	// Info is the most names looked up per request by Info. It is 100 if
+	// zero.
+	Info int
+	// MoreChildren is the most children expanded per request by
+	// MoreChildren. It is 100 if zero.
+	MoreChildren int
+}
+
+// lurkerConfig configures the behavior of a Lurker.
+type lurkerConfig struct {
+	limits SplitLimits
+	// clock times how long subreddit stats are kept. It is the system
+	// clock if nil.
+	clock Clock
 }
 
 type lurker struct {
-	r reaper
+	r      reaper
+	limits SplitLimits
+	clock  Clock
+
+	// statsMu guards stats, the about pages kept by SubredditStats by
+	// subreddit.
+	statsMu sync.Mutex
+	stats   map[string]keptStats
+}
+
+// keptStats are the counts of a subreddit's about page, kept until expiry.
+type keptStats struct {
+	subscribers int
+	activeUsers int
+	expiry      time.Time
 }
 
 func newLurker(r reaper) Lurker {
-	return &lurker{r: r}
+	return newLurkerFromConfig(r, lurkerConfig{})
+}
+
+func newLurkerFromConfig(r reaper, c lurkerConfig) Lurker {
+	if c.limits.Info == 0 {
+		c.limits.Info = maxInfoNames
+	}
+	if c.limits.MoreChildren == 0 {
+		c.limits.MoreChildren = maxMoreChildren
+	}
+
+	return &lurker{
+		r:      r,
+		limits: c.limits,
+		clock:  clockOrReal(c.clock),
+		stats:  map[string]keptStats{},
+	}
 }
 
 func (s *lurker) Thread(permalink string) (*Post, error) {
@@ -29,3 +197,452 @@ func (s *lurker) Thread(permalink string) (*Post, error) {
 
 	return harvest.Posts[0], nil
 }
+
+func (s *lurker) ContinueThread(postName, commentName string) (
+	[]*Comment,
+	error,
+) {
+	postID := strings.TrimPrefix(postName, postKind+"_")
+	commentID := strings.TrimPrefix(commentName, commentKind+"_")
+
+	harvest, err := s.r.reap(
+		"/comments/"+postID+"/_/"+commentID,
+		map[string]string{"raw_json": "1"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(harvest.Posts) != 1 {
+		return nil, ThreadDoesNotExistErr
+	}
+
+	for _, c := range harvest.Posts[0].Replies {
+		if c.ID == commentID {
+			return c.Replies, nil
+		}
+	}
+
+	return nil, CommentDoesNotExistErr
+}
+
+// maxCommentContext is the most ancestors Reddit shows of a focused comment.
+const maxCommentContext = 10
+
+// commentSorts are the orders Reddit sorts comment trees in.
+var commentSorts = map[string]bool{
+	"confidence":    true,
+	"top":           true,
+	"new":           true,
+	"controversial": true,
+	"old":           true,
+	"random":        true,
+	"qa":            true,
+	"live":          true,
+}
+
+func (s *lurker) CommentContext(
+	postName, commentName string,
+	context int,
+	sort string,
+) (*Post, []*Comment, error) {
+	if context < 0 || context > maxCommentContext {
+		return nil, nil, fmt.Errorf(
+			"comment context %d is not 0 to %d", context, maxCommentContext,
+		)
+	}
+
+	if sort != "" && !commentSorts[sort] {
+		return nil, nil, fmt.Errorf("unknown comment sort %q", sort)
+	}
+
+	postID := strings.TrimPrefix(postName, postKind+"_")
+	commentID := strings.TrimPrefix(commentName, commentKind+"_")
+
+	params := map[string]string{
+		"raw_json": "1",
+		"context":  strconv.Itoa(context),
+	}
+	if sort != "" {
+		params["sort"] = sort
+	}
+
+	harvest, err := s.r.reap("/comments/"+postID+"/_/"+commentID, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(harvest.Posts) != 1 {
+		return nil, nil, ThreadDoesNotExistErr
+	}
+
+	post := harvest.Posts[0]
+	if !hasComment(post.Replies, commentID) {
+		return nil, nil, CommentDoesNotExistErr
+	}
+
+	return post, post.Replies, nil
+}
+
+// hasComment returns whether the comment with the given id is in a tree.
+func hasComment(tree []*Comment, id string) bool {
+	for _, c := range tree {
+		if c.ID == id || hasComment(c.Replies, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *lurker) UserTrophies(user string) ([]*Trophy, error) {
+	resp, err := s.r.raw_reap("/api/v1/user/"+user+"/trophies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTrophies(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) UserAbout(user string) (*User, error) {
+	resp, err := s.r.raw_reap(
+		"/user/"+user+"/about",
+		map[string]string{"raw_json": "1"},
+	)
+	if err == notFoundErr {
+		return nil, &UserNotFoundError{User: user}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return parseUser(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) Post(name string) (*Post, error) {
+	harvest, err := s.info(name, postKind)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(harvest.Posts) != 1 {
+		return nil, ThreadDoesNotExistErr
+	}
+
+	return harvest.Posts[0], nil
+}
+
+func (s *lurker) Comment(name string) (*Comment, error) {
+	harvest, err := s.info(name, commentKind)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(harvest.Comments) != 1 {
+		return nil, CommentDoesNotExistErr
+	}
+
+	return harvest.Comments[0], nil
+}
+
+func (s *lurker) UsernameAvailable(name string) (bool, error) {
+	resp, err := s.r.raw_reap(
+		"/api/username_available", map[string]string{
+			"user": name,
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return parseAvailability(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) SearchSubredditNames(query string, includeOver18 bool) (
+	[]string,
+	error,
+) {
+	resp, err := s.r.raw_reap(
+		"/api/search_reddit_names", map[string]string{
+			"query":           query,
+			"include_over_18": strconv.FormatBool(includeOver18),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNames(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) SubredditAutocomplete(
+	query string,
+	includeOver18, includeProfiles bool,
+) ([]*SubredditDetail, error) {
+	if strings.TrimSpace(query) == "" {
+		return []*SubredditDetail{}, nil
+	}
+
+	harvest, err := s.r.reap(
+		"/api/subreddit_autocomplete_v2", map[string]string{
+			"query":            query,
+			"include_over_18":  strconv.FormatBool(includeOver18),
+			"include_profiles": strconv.FormatBool(includeProfiles),
+			"typeahead_active": "true",
+			"raw_json":         "1",
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if harvest.Subreddits == nil {
+		return []*SubredditDetail{}, nil
+	}
+	return harvest.Subreddits, nil
+}
+
+func (s *lurker) Sticky(subreddit string, slot int) (*Post, error) {
+	if slot != 1 && slot != 2 {
+		return nil, errStickySlot
+	}
+
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reddit redirects to the thread of the sticky, so the response is a
+	// thread rather than a listing.
+	harvest, err := s.r.reap(
+		"/r/"+subreddit+"/about/sticky", map[string]string{
+			"num":      strconv.Itoa(slot),
+			"raw_json": "1",
+		},
+	)
+	if err == notFoundErr {
+		return nil, &NoStickyError{Subreddit: subreddit, Slot: slot}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(harvest.Posts) != 1 {
+		return nil, &NoStickyError{Subreddit: subreddit, Slot: slot}
+	}
+
+	return harvest.Posts[0], nil
+}
+
+func (s *lurker) SubredditAbout(subreddit string) (*SubredditDetail, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.r.raw_reap(
+		"/r/"+subreddit+"/about",
+		map[string]string{"raw_json": "1"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSubredditAbout(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) SubredditStats(subreddit string, ttl time.Duration) (
+	int,
+	int,
+	error,
+) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return 0, 0, err
+	}
+	key := strings.ToLower(subreddit)
+
+	s.statsMu.Lock()
+	kept, ok := s.stats[key]
+	s.statsMu.Unlock()
+	if ok && s.clock.Now().Before(kept.expiry) {
+		return kept.subscribers, kept.activeUsers, nil
+	}
+
+	about, err := s.SubredditAbout(subreddit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	kept = keptStats{
+		subscribers: int(about.Subscribers),
+		activeUsers: about.ActiveUsers,
+		expiry:      s.clock.Now().Add(ttl),
+	}
+	s.statsMu.Lock()
+	s.stats[key] = kept
+	s.statsMu.Unlock()
+
+	return kept.subscribers, kept.activeUsers, nil
+}
+
+func (s *lurker) SubredditRules(subreddit string) ([]*Rule, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.r.raw_reap("/r/"+subreddit+"/about/rules", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRules(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) Multireddit(path string) (*Multi, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("empty multireddit path")
+	}
+
+	resp, err := s.r.raw_reap("/api/multi/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMulti(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) Collection(id string) (*Collection, error) {
+	resp, err := s.r.raw_reap(
+		"/api/v1/collections/collection", map[string]string{
+			"collection_id": id,
+			"include_links": "true",
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCollection(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) PostRequirements(subreddit string) (*PostRequirements, error) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.r.raw_reap(
+		"/api/v1/"+subreddit+"/post_requirements", nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePostRequirements(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) CrosspostTargets(
+	postName string,
+	candidates []string,
+) ([]string, error) {
+	post, err := s.Post(postName)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []string{}
+	if !post.IsCrosspostable {
+		return targets, nil
+	}
+
+	for _, candidate := range candidates {
+		req, err := s.PostRequirements(candidate)
+		if err == PermissionDeniedErr || err == notFoundErr {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if acceptsCrosspost(*req, post) {
+			targets = append(targets, candidate)
+		}
+	}
+
+	return targets, nil
+}
+
+func (s *lurker) LiveThread(id string) (*LiveThread, error) {
+	resp, err := s.r.raw_reap(
+		"/live/"+id+"/about.json",
+		map[string]string{"raw_json": "1"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLiveThread(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) LiveUpdates(id string) ([]*LiveUpdate, error) {
+	resp, err := s.r.raw_reap(
+		"/live/"+id+".json",
+		map[string]string{"raw_json": "1"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLiveUpdates(s.r.bodyCodec(), resp)
+}
+
+// info looks up a thing of the given kind by its full name.
+func (s *lurker) info(name, kind string) (Harvest, error) {
+	if !strings.HasPrefix(name, kind+"_") {
+		return Harvest{}, fmt.Errorf("%q is not the name of a %s", name, kind)
+	}
+
+	return s.r.reap(
+		"/api/info", map[string]string{
+			"raw_json": "1",
+			"id":       name,
+		},
+	)
+}
+
+func (s *lurker) Scopes() (map[string]Scope, error) {
+	resp, err := s.r.raw_reap("/api/v1/scopes", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseScopes(s.r.bodyCodec(), resp)
+}
+
+func (s *lurker) Info(names []string) (Harvest, error) {
+	return reapSplit(
+		s.r,
+		"/api/info",
+		map[string]string{"raw_json": "1"},
+		"id",
+		names,
+		s.limits.Info,
+	)
+}
+
+func (s *lurker) MoreChildren(postName string, children []string) (
+	Harvest,
+	error,
+) {
+	return reapSplit(
+		s.r,
+		"/api/morechildren",
+		map[string]string{
+			"api_type": "json",
+			"raw_json": "1",
+			"link_id":  postName,
+		},
+		"children",
+		children,
+		s.limits.MoreChildren,
+	)
+}
@@ -1,7 +1,10 @@
 package reddit
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kylelemons/godebug/pretty"
 )
@@ -26,6 +29,153 @@ func TestThread(t *testing.T) {
 	}
 }
 
+func TestContinueThread(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing", "data": {"children": [
+			{"kind": "more", "data": {
+				"id": "_", "name": "t1__", "count": 0,
+				"parent_id": "t1_ghi", "children": []
+			}},
+			{"kind": "more", "data": {
+				"id": "jkl", "name": "t1_jkl", "count": 2,
+				"parent_id": "t1_def", "children": ["jkl", "mno"]
+			}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("error parsing mores: %v", err)
+	}
+
+	if len(h.Mores) != 2 ||
+		!h.Mores[0].ContinuesThread() ||
+		h.Mores[1].ContinuesThread() {
+		t.Fatalf("mores classified incorrectly: %+v", h.Mores)
+	}
+
+	r := reaperWhich(
+		Harvest{
+			Posts: []*Post{
+				&Post{
+					Replies: []*Comment{
+						&Comment{
+							ID:      "ghi",
+							Replies: []*Comment{&Comment{ID: "pqr"}},
+						},
+					},
+				},
+			},
+		},
+		nil,
+	)
+	replies, err := newLurker(r).ContinueThread("t3_abc", h.Mores[0].ParentID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.path != "/comments/abc/_/ghi" {
+		t.Errorf("continued thread from %s", r.path)
+	}
+
+	if len(replies) != 1 || replies[0].ID != "pqr" {
+		t.Errorf("wanted the replies to t1_ghi; got %v", replies)
+	}
+
+	if _, err := newLurker(r).ContinueThread("abc", "xyz"); err != CommentDoesNotExistErr {
+		t.Errorf("wanted CommentDoesNotExistErr; got %v", err)
+	}
+}
+
+func TestCommentContext(t *testing.T) {
+	r := reaperWhich(
+		Harvest{
+			Posts: []*Post{
+				&Post{
+					ID: "abc",
+					Replies: []*Comment{
+						&Comment{
+							ID: "def",
+							Replies: []*Comment{
+								&Comment{
+									ID:      "ghi",
+									Replies: []*Comment{&Comment{ID: "jkl"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		nil,
+	)
+
+	post, tree, err := newLurker(r).CommentContext("t3_abc", "t1_ghi", 1, "new")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.path != "/comments/abc/_/ghi" {
+		t.Errorf("read context from %s", r.path)
+	}
+
+	if post.ID != "abc" || len(tree) != 1 || tree[0].Replies[0].ID != "ghi" {
+		t.Errorf("wanted the tree around t1_ghi; got %v, %v", post, tree)
+	}
+
+	if _, _, err := newLurker(r).CommentContext("abc", "xyz", 3, ""); err != CommentDoesNotExistErr {
+		t.Errorf("wanted CommentDoesNotExistErr; got %v", err)
+	}
+
+	for _, test := range []struct {
+		context int
+		sort    string
+	}{
+		{-1, ""},
+		{11, ""},
+		{3, "hot"},
+	} {
+		if _, _, err := newLurker(r).CommentContext("abc", "ghi", test.context, test.sort); err == nil {
+			t.Errorf("wanted an error for context %d and sort %q", test.context, test.sort)
+		}
+	}
+}
+
+func TestSticky(t *testing.T) {
+	h, err := newParser().parse([]byte(`[
+		{"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_abc", "title": "Weekly thread", "stickied": true}}
+		]}},
+		{"kind": "Listing", "data": {"children": [
+			{"kind": "t1", "data": {"name": "t1_def", "body": "hi", "replies": ""}}
+		]}}
+	]`))
+	if err != nil {
+		t.Fatalf("failed to parse thread: %v", err)
+	}
+
+	r := reaperWhich(h, nil)
+	post, err := newLurker(r).Sticky("golang", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.path != "/r/golang/about/sticky" {
+		t.Errorf("read sticky from %s", r.path)
+	}
+
+	if post.Name != "t3_abc" || len(post.Replies) != 1 {
+		t.Errorf("wanted the sticky thread; got %+v", post)
+	}
+
+	_, err = newLurker(reaperWhich(Harvest{}, notFoundErr)).Sticky("golang", 1)
+	if noSticky, ok := err.(*NoStickyError); !ok || noSticky.Slot != 1 {
+		t.Errorf("wanted NoStickyError for slot 1; got %v", err)
+	}
+
+	if _, err := newLurker(r).Sticky("golang", 3); err != errStickySlot {
+		t.Errorf("wanted errStickySlot; got %v", err)
+	}
+}
+
 func TestThreadReturnsEmpty(t *testing.T) {
 	s := newLurker(reaperWhich(Harvest{}, nil))
 	_, err := s.Thread("")
@@ -33,3 +183,488 @@ func TestThreadReturnsEmpty(t *testing.T) {
 		t.Errorf("err unexpected; wanted DoesNotExistErr; got %v", err)
 	}
 }
+
+func TestPost(t *testing.T) {
+	h := Harvest{Posts: []*Post{&Post{Name: "t3_abc"}}}
+	r := reaperWhich(h, nil)
+	s := newLurker(r)
+
+	post, err := s.Post("t3_abc")
+	if err != nil {
+		t.Fatalf("error fetching post: %v", err)
+	}
+
+	if diff := pretty.Compare(post, h.Posts[0]); diff != "" {
+		t.Errorf("post incorrect; diff: %s", diff)
+	}
+
+	if r.path != "/api/info" {
+		t.Errorf("fetched post from wrong path: %s", r.path)
+	}
+
+	if _, err := newLurker(reaperWhich(Harvest{}, nil)).Post("t3_abc"); err != ThreadDoesNotExistErr {
+		t.Errorf("wanted ThreadDoesNotExistErr; got %v", err)
+	}
+
+	if _, err := s.Post("t1_abc"); err == nil {
+		t.Errorf("wanted error for comment name")
+	}
+}
+
+func TestComment(t *testing.T) {
+	h := Harvest{Comments: []*Comment{&Comment{Name: "t1_abc"}}}
+	s := newLurker(reaperWhich(h, nil))
+
+	comment, err := s.Comment("t1_abc")
+	if err != nil {
+		t.Fatalf("error fetching comment: %v", err)
+	}
+
+	if diff := pretty.Compare(comment, h.Comments[0]); diff != "" {
+		t.Errorf("comment incorrect; diff: %s", diff)
+	}
+
+	if _, err := newLurker(reaperWhich(Harvest{}, nil)).Comment("t1_abc"); err != CommentDoesNotExistErr {
+		t.Errorf("wanted CommentDoesNotExistErr; got %v", err)
+	}
+
+	if _, err := s.Comment("t3_abc"); err == nil {
+		t.Errorf("wanted error for post name")
+	}
+}
+
+func TestUsernameAvailable(t *testing.T) {
+	for i, test := range []struct {
+		response  string
+		available bool
+		err       bool
+	}{
+		{`true`, true, false},
+		{`false`, false, false},
+		{
+			`{"json": {"errors": [["BAD_USERNAME", "invalid user name", "user"]]}}`,
+			false, true,
+		},
+		{`{"something": "else"}`, false, true},
+	} {
+		r := &mockReaper{raw: []byte(test.response)}
+		available, err := newLurker(r).UsernameAvailable("name")
+		if (err != nil) != test.err {
+			t.Errorf("unexpected error on %d: %v", i, err)
+		} else if available != test.available {
+			t.Errorf("wrong on %d; got %v", i, available)
+		}
+
+		if r.path != "/api/username_available" {
+			t.Errorf("checked availability at wrong path: %s", r.path)
+		}
+	}
+}
+
+func TestSearchSubredditNames(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"names": ["golang", "golang_jobs"]}`)}
+	names, err := newLurker(r).SearchSubredditNames("golang", false)
+	if err != nil {
+		t.Fatalf("failed to search names: %v", err)
+	}
+
+	if diff := pretty.Compare(names, []string{"golang", "golang_jobs"}); diff != "" {
+		t.Errorf("names incorrect; diff: %s", diff)
+	}
+
+	r.raw = []byte(`{"names": []}`)
+	if names, err := newLurker(r).SearchSubredditNames("zzzz", true); err != nil || len(names) != 0 {
+		t.Errorf("wanted no names; got %v, %v", names, err)
+	}
+}
+
+func TestSubredditAutocomplete(t *testing.T) {
+	r := &mockReaper{h: Harvest{Subreddits: []*SubredditDetail{
+		{Name: "t5_2rc7j", DisplayName: "golang", Subscribers: 200000},
+		{Name: "t5_abcde", DisplayName: "u_gopher", Type: "user"},
+	}}}
+
+	subreddits, err := newLurker(r).SubredditAutocomplete("go", false, true)
+	if err != nil {
+		t.Fatalf("failed to autocomplete: %v", err)
+	}
+
+	if r.path != "/api/subreddit_autocomplete_v2" {
+		t.Errorf("autocompleted from wrong path: %s", r.path)
+	}
+
+	if diff := pretty.Compare(subreddits, r.h.Subreddits); diff != "" {
+		t.Errorf("subreddits incorrect; diff: %s", diff)
+	}
+
+	r = &mockReaper{err: fmt.Errorf("should not be called")}
+	if subreddits, err := newLurker(r).SubredditAutocomplete(" ", true, true); err != nil || subreddits == nil || len(subreddits) != 0 {
+		t.Errorf("wanted no subreddits for an empty query; got %v, %v", subreddits, err)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{
+		"collection_id": "37f1e52d-7ec9-466b-b4cc-59e86e071ed7",
+		"title": "Release threads",
+		"description": "Every release",
+		"permalink": "https://www.reddit.com/r/golang/collection/37f1e52d",
+		"subreddit_id": "t5_2qh1i",
+		"author_name": "gopher",
+		"created_at_utc": 1570000000.5,
+		"last_update_utc": 1570000100.5,
+		"display_layout": null,
+		"link_ids": ["t3_abc", "t3_def"],
+		"sorted_links": {"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_abc", "title": "Go 1.13"}},
+			{"kind": "t3", "data": {"name": "t3_def", "title": "Go 1.14"}}
+		]}}
+	}`)}
+
+	c, err := newLurker(r).Collection("37f1e52d-7ec9-466b-b4cc-59e86e071ed7")
+	if err != nil {
+		t.Fatalf("failed to fetch collection: %v", err)
+	}
+
+	if r.path != "/api/v1/collections/collection" {
+		t.Errorf("fetched collection from wrong path: %s", r.path)
+	}
+
+	if c.Title != "Release threads" || c.Author != "gopher" || len(c.LinkIDs) != 2 {
+		t.Errorf("collection parsed incorrectly: %+v", c)
+	}
+
+	if len(c.Posts) != 2 || c.Posts[1].Title != "Go 1.14" {
+		t.Errorf("collection posts parsed incorrectly: %v", c.Posts)
+	}
+
+	r.err = PermissionDeniedErr
+	if _, err := newLurker(r).Collection("id"); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+
+	r.err = nil
+	r.raw = []byte(`{}`)
+	if _, err := newLurker(r).Collection("id"); err == nil {
+		t.Errorf("wanted error for response which is not a collection")
+	}
+}
+
+func TestLiveThread(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"kind": "LiveUpdateEvent", "data": {
+		"id": "ta535s1hq2je",
+		"name": "LiveUpdateEvent_ta535s1hq2je",
+		"title": "Launch day",
+		"description": "Updates as they happen",
+		"state": "complete",
+		"viewer_count": 42,
+		"created_utc": 1570000000.0
+	}}`)}
+
+	l, err := newLurker(r).LiveThread("ta535s1hq2je")
+	if err != nil {
+		t.Fatalf("failed to fetch live thread: %v", err)
+	}
+
+	if r.path != "/live/ta535s1hq2je/about.json" {
+		t.Errorf("fetched live thread from wrong path: %s", r.path)
+	}
+
+	if l.Title != "Launch day" || l.ViewerCount != 42 || !l.Complete() {
+		t.Errorf("live thread parsed incorrectly: %+v", l)
+	}
+
+	r.raw = []byte(`{"kind": "t3", "data": {}}`)
+	if _, err := newLurker(r).LiveThread("id"); err == nil {
+		t.Errorf("wanted error for response which is not a live thread")
+	}
+}
+
+func TestLiveUpdates(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"kind": "Listing", "data": {"children": [
+		{"kind": "LiveUpdate", "data": {
+			"id": "b3f3a7c2-0000-11ea-8d71-362b9e155667",
+			"name": "LiveUpdate_b3f3a7c2-0000-11ea-8d71-362b9e155667",
+			"body": "Liftoff!",
+			"body_html": "<p>Liftoff!</p>",
+			"author": "gopher",
+			"created_utc": 1570000100.0,
+			"stricken": false,
+			"embeds": [{"url": "https://example.com/launch.png", "width": 640, "height": 480}]
+		}},
+		{"kind": "LiveUpdate", "data": {
+			"id": "a1e2c3d4-0000-11ea-8d71-362b9e155667",
+			"body": "T minus ten",
+			"author": "gopher",
+			"created_utc": 1570000000.0,
+			"stricken": true,
+			"embeds": []
+		}}
+	]}}`)}
+
+	updates, err := newLurker(r).LiveUpdates("ta535s1hq2je")
+	if err != nil {
+		t.Fatalf("failed to fetch live updates: %v", err)
+	}
+
+	if r.path != "/live/ta535s1hq2je.json" {
+		t.Errorf("fetched live updates from wrong path: %s", r.path)
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("got %d updates; wanted 2", len(updates))
+	}
+
+	if u := updates[0]; u.Body != "Liftoff!" || u.BodyHTML != "<p>Liftoff!</p>" ||
+		u.Author != "gopher" || len(u.Embeds) != 1 || u.Embeds[0].Width != 640 {
+		t.Errorf("live update parsed incorrectly: %+v", u)
+	}
+
+	if !updates[1].Stricken || len(updates[1].Embeds) != 0 {
+		t.Errorf("stricken live update parsed incorrectly: %+v", updates[1])
+	}
+
+	r.raw = []byte(`{"kind": "LiveUpdateEvent", "data": {}}`)
+	if _, err := newLurker(r).LiveUpdates("id"); err == nil {
+		t.Errorf("wanted error for response which is not a listing")
+	}
+}
+
+// countingReaper is a mockReaper which counts the requests it is sent.
+type countingReaper struct {
+	mockReaper
+	requests int
+}
+
+func (c *countingReaper) raw_reap(path string, values map[string]string) ([]byte, error) {
+	c.requests++
+	return c.mockReaper.raw_reap(path, values)
+}
+
+func TestSubredditStats(t *testing.T) {
+	r := &countingReaper{mockReaper: mockReaper{raw: []byte(`{"kind": "t5", "data": {
+		"display_name": "golang",
+		"subscribers": 150000,
+		"active_user_count": 420
+	}}`)}}
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	l := newLurkerFromConfig(r, lurkerConfig{clock: clock})
+
+	for i := 0; i < 2; i++ {
+		subscribers, active, err := l.SubredditStats("r/golang", time.Minute)
+		if err != nil {
+			t.Fatalf("failed to read stats: %v", err)
+		}
+		if subscribers != 150000 || active != 420 {
+			t.Errorf("got %d subscribers and %d active; wanted 150000 and 420", subscribers, active)
+		}
+	}
+
+	if r.path != "/r/golang/about" {
+		t.Errorf("read stats from wrong path: %s", r.path)
+	}
+	if r.requests != 1 {
+		t.Errorf("made %d requests within the ttl; wanted 1", r.requests)
+	}
+
+	clock.Advance(time.Minute)
+	if _, _, err := l.SubredditStats("golang", time.Minute); err != nil {
+		t.Fatalf("failed to read stats: %v", err)
+	}
+	if r.requests != 2 {
+		t.Errorf("made %d requests after the ttl; wanted 2", r.requests)
+	}
+
+	r.raw = []byte(`{"kind": "t2", "data": {}}`)
+	if _, _, err := l.SubredditStats("rust", time.Minute); err == nil {
+		t.Errorf("wanted error for an about page which is not a subreddit's")
+	}
+}
+
+func TestSubredditRules(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{
+		"rules": [
+			{
+				"kind": "link",
+				"description": "Posts must be about Go.",
+				"short_name": "On topic",
+				"violation_reason": "Off topic",
+				"created_utc": 1500000000.0,
+				"priority": 0
+			},
+			{
+				"kind": "all",
+				"description": "",
+				"short_name": "Be kind",
+				"violation_reason": "Be kind",
+				"created_utc": 1500000001.0,
+				"priority": 1
+			}
+		],
+		"site_rules": ["Spam"],
+		"site_rules_flow": []
+	}`)}
+
+	rules, err := newLurker(r).SubredditRules("r/golang")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.path != "/r/golang/about/rules" {
+		t.Errorf("read rules from %s", r.path)
+	}
+
+	if diff := pretty.Compare(
+		rules,
+		[]*Rule{
+			&Rule{
+				ShortName:       "On topic",
+				Description:     "Posts must be about Go.",
+				ViolationReason: "Off topic",
+				Kind:            "link",
+				Created:         1500000000,
+			},
+			&Rule{
+				ShortName:       "Be kind",
+				ViolationReason: "Be kind",
+				Kind:            "all",
+				Priority:        1,
+				Created:         1500000001,
+			},
+		},
+	); diff != "" {
+		t.Errorf("rules incorrect; diff: %s", diff)
+	}
+
+	r.raw = []byte(`{"rules": [], "site_rules": []}`)
+	if rules, err := newLurker(r).SubredditRules("golang"); err != nil || rules == nil || len(rules) != 0 {
+		t.Errorf("wanted no rules; got %v, %v", rules, err)
+	}
+}
+
+func TestUserAbout(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"kind": "t2", "data": {
+		"id": "abc",
+		"name": "gopher",
+		"link_karma": 12,
+		"comment_karma": 34
+	}}`)}
+
+	user, err := newLurker(r).UserAbout("gopher")
+	if err != nil {
+		t.Fatalf("failed to read profile: %v", err)
+	}
+
+	if r.path != "/user/gopher/about" {
+		t.Errorf("read profile from wrong path: %s", r.path)
+	}
+
+	if user.Name != "gopher" || user.LinkKarma != 12 || user.CommentKarma != 34 {
+		t.Errorf("user parsed incorrectly: %+v", user)
+	}
+}
+
+func TestUserAboutSuspended(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"kind": "t2", "data": {
+		"name": "gopher",
+		"is_suspended": true
+	}}`)}
+
+	_, err := newLurker(r).UserAbout("gopher")
+	if e, ok := err.(*UserSuspendedError); !ok || e.User != "gopher" {
+		t.Errorf("wanted UserSuspendedError; got %v", err)
+	}
+}
+
+func TestUserAboutNotFound(t *testing.T) {
+	r := &mockReaper{err: notFoundErr}
+
+	_, err := newLurker(r).UserAbout("gopher")
+	if e, ok := err.(*UserNotFoundError); !ok || e.User != "gopher" {
+		t.Errorf("wanted UserNotFoundError; got %v", err)
+	}
+
+	r.err = BusyErr
+	if _, err := newLurker(r).UserAbout("gopher"); err != BusyErr {
+		t.Errorf("wanted BusyErr; got %v", err)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{"read": {"id": "read", "name": "Read Content"}}`)}
+
+	scopes, err := newLurker(r).Scopes()
+	if err != nil {
+		t.Fatalf("failed to fetch scopes: %v", err)
+	}
+
+	if r.path != "/api/v1/scopes" {
+		t.Errorf("fetched scopes from wrong path: %s", r.path)
+	}
+
+	if scopes["read"].Name != "Read Content" {
+		t.Errorf("scopes parsed incorrectly: %v", scopes)
+	}
+}
+
+func TestInfoSplitsNames(t *testing.T) {
+	names := []string{}
+	for i := 0; i < 250; i++ {
+		names = append(names, fmt.Sprintf("t3_%d", i))
+	}
+
+	r := &pagingReaper{
+		pages: []Harvest{
+			postPage(0, 100, ""),
+			postPage(100, 100, ""),
+			postPage(200, 50, ""),
+		},
+	}
+
+	h, err := newLurker(r).Info(names)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(r.params) != 3 {
+		t.Fatalf("made %d requests; wanted 3", len(r.params))
+	}
+
+	for i, want := range []int{100, 100, 50} {
+		if ids := strings.Split(r.params[i]["id"], ","); len(ids) != want {
+			t.Errorf("request %d asked for %d names; wanted %d", i, len(ids), want)
+		}
+	}
+
+	if !strings.HasPrefix(r.params[1]["id"], "t3_100,t3_101,") {
+		t.Errorf("second request began with wrong names: %s", r.params[1]["id"][:20])
+	}
+
+	if len(h.Posts) != 250 {
+		t.Fatalf("got %d posts; wanted 250", len(h.Posts))
+	}
+	for i, p := range h.Posts {
+		if p.Name != names[i] {
+			t.Fatalf("post %d is %s; wanted %s", i, p.Name, names[i])
+		}
+	}
+}
+
+func TestMoreChildrenLimit(t *testing.T) {
+	r := &pagingReaper{}
+	l := newLurkerFromConfig(r, lurkerConfig{limits: SplitLimits{MoreChildren: 2}})
+
+	if _, err := l.MoreChildren("t3_abc", []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(r.params) != 2 {
+		t.Fatalf("made %d requests; wanted 2", len(r.params))
+	}
+
+	if r.params[0]["children"] != "a,b" ||
+		r.params[1]["children"] != "c" ||
+		r.params[1]["link_id"] != "t3_abc" {
+		t.Errorf("requests had wrong params: %v", r.params)
+	}
+}
@@ -1,15 +1,23 @@
 package reddit
 
 import (
+	"encoding/json"
 	"net/http"
 )
 
-// mockClient stores the request it receives.
+// mockClient stores the request it receives and returns a preconfigured
+// response.
 type mockClient struct {
-	request *http.Request
+	request  *http.Request
+	response []byte
 }
 
 func (m *mockClient) Do(r *http.Request) ([]byte, error) {
 	m.request = r
-	return nil, nil
+	return m.response, nil
+}
+
+func (m *mockClient) DoStream(r *http.Request, dst interface{}) error {
+	m.request = r
+	return json.Unmarshal(m.response, dst)
 }
@@ -12,10 +12,13 @@ type mockParser struct {
 	submission Submission
 }
 
-func (m *mockParser) parse(
-	blob json.RawMessage,
-) ([]*Comment, []*Post, []*Message, []*More, error) {
-	return m.comments, m.posts, m.messages, m.mores, nil
+func (m *mockParser) parse(blob json.RawMessage) (Harvest, error) {
+	return Harvest{
+		Comments: m.comments,
+		Posts:    m.posts,
+		Messages: m.messages,
+		Mores:    m.mores,
+	}, nil
 }
 
 func (m *mockParser) parse_submitted(
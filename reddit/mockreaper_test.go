@@ -7,6 +7,7 @@ type mockReaper struct {
 
 	h   Harvest
 	s   Submission
+	raw []byte
 	err error
 }
 
@@ -15,6 +16,11 @@ func (m *mockReaper) reap(path string, _ map[string]string) (Harvest, error) {
 	return m.h, m.err
 }
 
+func (m *mockReaper) raw_reap(path string, _ map[string]string) ([]byte, error) {
+	m.path = path
+	return m.raw, m.err
+}
+
 func (m *mockReaper) sow(path string, _ map[string]string) error {
 	m.path = path
 	return m.err
@@ -25,6 +31,25 @@ func (m *mockReaper) get_sow(path string, _ map[string]string) (Submission, erro
 	return m.s, m.err
 }
 
+func (m *mockReaper) raw_sow(path string, _ map[string]string) ([]byte, error) {
+	m.path = path
+	return m.raw, m.err
+}
+
+func (m *mockReaper) send(req Request) ([]byte, error) {
+	m.path = req.Path
+	return m.raw, m.err
+}
+
+func (m *mockReaper) decode(req Request, _ interface{}) error {
+	m.path = req.Path
+	return m.err
+}
+
+func (m *mockReaper) bodyCodec() Codec {
+	return jsonCodec{}
+}
+
 func reaperWhich(h Harvest, err error) *mockReaper {
 	return &mockReaper{
 		h:   h,
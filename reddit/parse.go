@@ -3,6 +3,10 @@ package reddit
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -12,7 +16,21 @@ const (
 	postKind    = "t3"
 	commentKind = "t1"
 	messageKind = "t4"
-	moreKind    = "more"
+	userKind    = "t2"
+	// subredditKind is the kind of subreddits, which listings of search
+	// results and subreddits' about pages hold.
+	subredditKind = "t5"
+	moreKind      = "more"
+	trophyKind    = "TrophyList"
+	karmaKind     = "KarmaList"
+	multiKind     = "LabeledMulti"
+	// liveThreadKind and liveUpdateKind are the kinds of live threads and
+	// the updates posted to them.
+	liveThreadKind = "LiveUpdateEvent"
+	liveUpdateKind = "LiveUpdate"
+	// settingsKind is the kind of a subreddit's settings, which Reddit
+	// wraps like a thing though they are not one.
+	settingsKind = "subreddit_settings"
 )
 
 // author fields and body fields are set to the deletedKey if the user deletes
@@ -44,36 +62,105 @@ type comment struct {
 // parser parses Reddit responses..
 type parser interface {
 	// parse parses any Reddit response and provides the elements in it.
-	parse(blob json.RawMessage) ([]*Comment, []*Post, []*Message, []*More, error)
+	parse(blob json.RawMessage) (Harvest, error)
 	parse_submitted(blob json.RawMessage) (Submission, error)
 }
 
-type parserImpl struct{}
+// parserConfig configures a parser.
+type parserConfig struct {
+	// codec decodes the responses the parser parses. encoding/json is used
+	// if it is nil.
+	codec Codec
+	// strict makes the parser refuse elements with fields their types do
+	// not have.
+	strict bool
+	// cleanURLs makes the parser remove tracking parameters from the urls
+	// of posts with CleanURL.
+	cleanURLs bool
+	// permalinkBase is the address FullPermalink resolves the permalinks
+	// of the posts and comments the parser parses against.
+	permalinkBase string
+}
+
+type parserImpl struct {
+	codec         Codec
+	strict        bool
+	cleanURLs     bool
+	permalinkBase string
+}
 
 func newParser() parser {
-	return &parserImpl{}
+	return newParserFromConfig(parserConfig{})
+}
+
+// newStrictParser returns a parser which checks that the elements of the
+// listings it parses match their types exactly.
+func newStrictParser() parser {
+	return newParserFromConfig(parserConfig{strict: true})
+}
+
+func newParserFromConfig(c parserConfig) parser {
+	if c.codec == nil {
+		c.codec = jsonCodec{}
+	}
+
+	return &parserImpl{
+		codec:         c.codec,
+		strict:        c.strict,
+		cleanURLs:     c.cleanURLs,
+		permalinkBase: c.permalinkBase,
+	}
 }
 
 // parse parses any Reddit response and provides the elements in it.
-func (p *parserImpl) parse(
-	blob json.RawMessage,
-) ([]*Comment, []*Post, []*Message, []*More, error) {
-	comments, posts, msgs, mores, listingErr := parseRawListing(blob)
+func (p *parserImpl) parse(blob json.RawMessage) (Harvest, error) {
+	h, err := p.parseHarvest(blob)
+	if err == nil && p.cleanURLs {
+		for _, post := range h.Posts {
+			post.URL = CleanURL(post.URL)
+		}
+	}
+	if err == nil && p.permalinkBase != "" {
+		for _, post := range h.Posts {
+			post.permalinkBase = p.permalinkBase
+			setPermalinkBase(post.Replies, p.permalinkBase)
+		}
+		setPermalinkBase(h.Comments, p.permalinkBase)
+	}
+	return h, err
+}
+
+// setPermalinkBase sets the permalink base of the comments and their replies.
+func setPermalinkBase(comments []*Comment, base string) {
+	for _, comment := range comments {
+		comment.permalinkBase = base
+		setPermalinkBase(comment.Replies, base)
+	}
+}
+
+func (p *parserImpl) parseHarvest(blob json.RawMessage) (Harvest, error) {
+	if p.strict {
+		if err := checkStrict(p.codec, blob); err != nil {
+			return Harvest{}, err
+		}
+	}
+
+	h, listingErr := parseRawListing(p.codec, blob)
 	if listingErr == nil {
-		return comments, posts, msgs, mores, nil
+		return h, nil
 	}
 
-	post, threadErr := parseThread(blob)
+	post, threadErr := parseThread(p.codec, blob)
 	if threadErr == nil {
-		return nil, []*Post{post}, nil, nil, nil
+		return Harvest{Posts: []*Post{post}}, nil
 	}
 
-	comments, mores, moreErr := parseMoreChildren(blob)
+	comments, mores, moreErr := parseMoreChildren(p.codec, blob)
 	if moreErr == nil {
-		return comments, nil, nil, mores, nil
+		return Harvest{Comments: comments, Mores: mores}, nil
 	}
 
-	return nil, nil, nil, nil, fmt.Errorf(
+	return Harvest{}, fmt.Errorf(
 		"failed to parse as listing [%v], thread [%v], or more [%v]",
 		listingErr, threadErr, moreErr,
 	)
@@ -83,14 +170,14 @@ func (p *parserImpl) parse(
 // the status of some resource that was submitted
 func (p *parserImpl) parse_submitted(blob json.RawMessage) (Submission, error) {
 	var wrapped map[string]interface{}
-	err := json.Unmarshal(blob, &wrapped)
+	err := p.codec.Unmarshal(blob, &wrapped)
 	if err != nil {
 		return Submission{}, err
 	}
 
 	wrapped = wrapped["json"].(map[string]interface{})
-	if len(wrapped["errors"].([]interface{})) != 0 {
-		return Submission{}, fmt.Errorf("API errors were returned: %v", wrapped["errors"])
+	if errs := wrapped["errors"].([]interface{}); len(errs) != 0 {
+		return Submission{}, apiErrors(errs)
 	}
 
 	data := wrapped["data"].(map[string]interface{})
@@ -106,35 +193,83 @@ func (p *parserImpl) parse_submitted(blob json.RawMessage) (Submission, error) {
 	}
 
 	var submission Submission
-	err = mapstructure.Decode(data, &submission)
+	err = decode(data, &submission)
 	return submission, err
 }
 
 // parseRawListing parses a listing json blob and returns the elements in it.
-func parseRawListing(
-	blob json.RawMessage,
-) ([]*Comment, []*Post, []*Message, []*More, error) {
+func parseRawListing(c Codec, blob json.RawMessage) (Harvest, error) {
 	var activityListing thing
-	if err := json.Unmarshal(blob, &activityListing); err != nil {
-		return nil, nil, nil, nil, err
+	if err := c.Unmarshal(blob, &activityListing); err != nil {
+		return Harvest{}, err
+	}
+
+	comments, posts, msgs, mores, err := parseListing(&activityListing)
+	var subreddits []*SubredditDetail
+	var users []*User
+	if err == nil {
+		subreddits, users, err = parseSearchResults(&activityListing)
+	}
+
+	after, _ := activityListing.Data["after"].(string)
+	var dist int
+	decode(activityListing.Data["dist"], &dist)
+	return Harvest{
+		Comments:   comments,
+		Posts:      posts,
+		Messages:   msgs,
+		Mores:      mores,
+		Subreddits: subreddits,
+		Users:      users,
+		After:      after,
+		Dist:       dist,
+	}, err
+}
+
+// parseSearchResults returns the subreddits and users in a listing, which
+// only searches for them return.
+func parseSearchResults(t *thing) ([]*SubredditDetail, []*User, error) {
+	l := &listing{}
+	if err := decode(t.Data, l); err != nil {
+		return nil, nil, mapDecodeError(err, t.Data)
 	}
 
-	return parseListing(&activityListing)
+	var subreddits []*SubredditDetail
+	var users []*User
+	for _, c := range l.Children {
+		switch c.Kind {
+		case subredditKind:
+			subreddit := &SubredditDetail{}
+			if err := decode(c.Data, subreddit); err != nil {
+				return nil, nil, mapDecodeError(err, c.Data)
+			}
+			subreddits = append(subreddits, subreddit)
+		case userKind:
+			user := &User{}
+			if err := decode(c.Data, user); err != nil {
+				return nil, nil, mapDecodeError(err, c.Data)
+			}
+			users = append(users, user)
+		}
+	}
+
+	return subreddits, users, nil
 }
 
 // parseMoreChildren parses the json blob from /api/morechildren calls and returns the elements in it.
 func parseMoreChildren(
+	c Codec,
 	blob json.RawMessage,
 ) ([]*Comment, []*More, error) {
 	var wrapped map[string]interface{}
-	err := json.Unmarshal(blob, &wrapped)
+	err := c.Unmarshal(blob, &wrapped)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	wrapped = wrapped["json"].(map[string]interface{})
-	if len(wrapped["errors"].([]interface{})) != 0 {
-		return nil, nil, fmt.Errorf("API errors were returned: %v", wrapped["errors"])
+	if errs := wrapped["errors"].([]interface{}); len(errs) != 0 {
+		return nil, nil, apiErrors(errs)
 	}
 
 	data := wrapped["data"].(map[string]interface{})
@@ -149,7 +284,7 @@ func parseMoreChildren(
 	}
 
 	var m more
-	err = mapstructure.Decode(data, &m)
+	err = decode(data, &m)
 
 	if err != nil {
 		return nil, nil, err
@@ -165,9 +300,9 @@ func parseMoreChildren(
 //
 // Reddit structures this as two things in an array, the first thing being a
 // listing with only the post and the second thing being a listing of comments.
-func parseThread(blob json.RawMessage) (*Post, error) {
+func parseThread(c Codec, blob json.RawMessage) (*Post, error) {
 	var listings [2]thing
-	if err := json.Unmarshal(blob, &listings); err != nil {
+	if err := c.Unmarshal(blob, &listings); err != nil {
 		return nil, err
 	}
 
@@ -203,7 +338,7 @@ func parseListing(t *thing) ([]*Comment, []*Post, []*Message, []*More, error) {
 	}
 
 	l := &listing{}
-	if err := mapstructure.Decode(t.Data, l); err != nil {
+	if err := decode(t.Data, l); err != nil {
 		return nil, nil, nil, nil, mapDecodeError(err, t.Data)
 	}
 
@@ -253,25 +388,10 @@ func parseChildren(children []thing) ([]*Comment, []*Post, []*Message, []*More,
 
 // parseComment parses a comment into the user facing Comment struct.
 func parseComment(t *thing) (*Comment, error) {
-	// Reddit makes the replies field a string if it is empty, just to make
-	// it harder for programmers who like static type systems.
-	value, present := t.Data["replies"]
-	if present {
-		if str, ok := value.(string); ok && str == "" {
-			delete(t.Data, "replies")
-		}
-	}
-	// Similarly, edited is false if a post hasn't been edited and a timestamp
-	// otherwise.
-	value, present = t.Data["edited"]
-	if present {
-		if _, ok := value.(bool); ok {
-			delete(t.Data, "edited")
-		}
-	}
+	cleanComment(t.Data)
 
 	c := &comment{}
-	if err := mapstructure.Decode(t.Data, c); err != nil {
+	if err := decode(t.Data, c); err != nil {
 		return nil, mapDecodeError(err, t.Data)
 	}
 
@@ -293,10 +413,35 @@ func parseComment(t *thing) (*Comment, error) {
 
 }
 
+// cleanComment removes the fields of a comment which Reddit gives a different
+// type when they are empty.
+func cleanComment(data map[string]interface{}) {
+	// Reddit makes the replies field a string if it is empty, just to make
+	// it harder for programmers who like static type systems.
+	value, present := data["replies"]
+	if present {
+		if str, ok := value.(string); ok && str == "" {
+			delete(data, "replies")
+		}
+	}
+	cleanEdited(data)
+}
+
+// cleanEdited removes the edited field of a post or comment which has not been
+// edited, which Reddit sends as false; for those which have, it is a
+// timestamp, often fractional, which decodes to whole seconds.
+func cleanEdited(data map[string]interface{}) {
+	if _, ok := data["edited"].(bool); ok {
+		delete(data, "edited")
+	}
+}
+
 // parsePost parses a post into the user facing Post struct.
 func parsePost(t *thing) (*Post, error) {
+	cleanEdited(t.Data)
+
 	p := &Post{}
-	if err := mapstructure.Decode(t.Data, p); err != nil {
+	if err := decode(t.Data, p); err != nil {
 		return nil, mapDecodeError(err, t.Data)
 	}
 
@@ -307,19 +452,692 @@ func parsePost(t *thing) (*Post, error) {
 // parseMessage parses a message into the user facing Message struct.
 func parseMessage(t *thing) (*Message, error) {
 	m := &Message{}
-	return m, mapstructure.Decode(t.Data, m)
+	return m, decode(t.Data, m)
 }
 
 // parseMore parses a more comment list into the user facing More struct.
 func parseMore(t *thing) (*More, error) {
 	m := &More{}
-	if err := mapstructure.Decode(t.Data, m); err != nil {
+	if err := decode(t.Data, m); err != nil {
 		return nil, mapDecodeError(err, t.Data)
 	}
 
 	return m, nil
 }
 
+// parseTrophies parses the trophy list returned by a user's trophies endpoint.
+func parseTrophies(c Codec, blob json.RawMessage) ([]*Trophy, error) {
+	var list struct {
+		Kind string `json:"kind"`
+		Data struct {
+			Trophies []thing `json:"trophies"`
+		} `json:"data"`
+	}
+	if err := c.Unmarshal(blob, &list); err != nil {
+		return nil, err
+	}
+
+	if list.Kind != trophyKind {
+		return nil, fmt.Errorf("thing is not trophy list")
+	}
+
+	trophies := []*Trophy{}
+	for _, t := range list.Data.Trophies {
+		trophy := &Trophy{}
+		if err := decode(t.Data, trophy); err != nil {
+			return nil, mapDecodeError(err, t.Data)
+		}
+		trophies = append(trophies, trophy)
+	}
+
+	return trophies, nil
+}
+
+// parseUser parses a user's profile. Suspended users are reported with a
+// UserSuspendedError.
+func parseUser(c Codec, blob json.RawMessage) (*User, error) {
+	var t thing
+	if err := c.Unmarshal(blob, &t); err != nil {
+		return nil, err
+	}
+
+	if t.Kind != userKind {
+		return nil, fmt.Errorf("thing is not user")
+	}
+
+	user := &User{}
+	if err := decode(t.Data, user); err != nil {
+		return nil, mapDecodeError(err, t.Data)
+	}
+
+	if user.IsSuspended {
+		return nil, &UserSuspendedError{User: user.Name}
+	}
+
+	return user, nil
+}
+
+// parseScopes parses the descriptions of OAuth2 scopes, by scope id.
+func parseScopes(c Codec, blob json.RawMessage) (map[string]Scope, error) {
+	var raw map[string]map[string]interface{}
+	if err := c.Unmarshal(blob, &raw); err != nil {
+		return nil, err
+	}
+
+	scopes := map[string]Scope{}
+	for id, data := range raw {
+		scope := Scope{}
+		if err := decode(data, &scope); err != nil {
+			return nil, mapDecodeError(err, data)
+		}
+		scopes[id] = scope
+	}
+
+	return scopes, nil
+}
+
+// parseRules parses the rules of a subreddit.
+func parseRules(c Codec, blob json.RawMessage) ([]*Rule, error) {
+	var data map[string]interface{}
+	if err := c.Unmarshal(blob, &data); err != nil {
+		return nil, err
+	}
+
+	resp := struct {
+		Rules []*Rule `mapstructure:"rules"`
+	}{}
+	if err := decode(data, &resp); err != nil {
+		return nil, mapDecodeError(err, data)
+	}
+
+	if resp.Rules == nil {
+		resp.Rules = []*Rule{}
+	}
+	return resp.Rules, nil
+}
+
+// parseMe parses the account's own profile, which Reddit sends without the
+// thing wrapping other users' profiles.
+func parseMe(c Codec, blob json.RawMessage) (*User, error) {
+	var data map[string]interface{}
+	if err := c.Unmarshal(blob, &data); err != nil {
+		return nil, err
+	}
+
+	user := &User{}
+	if err := decode(data, user); err != nil {
+		return nil, mapDecodeError(err, data)
+	}
+
+	if user.Name == "" {
+		return nil, fmt.Errorf("response is not an account profile")
+	}
+
+	return user, nil
+}
+
+// parseCollection parses a collection and the listing of its posts.
+func parseCollection(c Codec, blob json.RawMessage) (*Collection, error) {
+	var raw struct {
+		SortedLinks thing `json:"sorted_links"`
+	}
+	if err := c.Unmarshal(blob, &raw); err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := c.Unmarshal(blob, &data); err != nil {
+		return nil, err
+	}
+
+	coll := &Collection{}
+	if err := decode(data, coll); err != nil {
+		return nil, mapDecodeError(err, data)
+	}
+
+	if coll.ID == "" {
+		return nil, fmt.Errorf("response is not a collection")
+	}
+
+	coll.Posts = []*Post{}
+	if raw.SortedLinks.Kind == listingKind {
+		_, posts, _, _, err := parseListing(&raw.SortedLinks)
+		if err != nil {
+			return nil, err
+		}
+		coll.Posts = posts
+	}
+
+	return coll, nil
+}
+
+// parseKarma parses the karma list returned by an account's karma endpoint.
+func parseKarma(c Codec, blob json.RawMessage) ([]*SubredditKarma, error) {
+	var list struct {
+		Kind string                   `json:"kind"`
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := c.Unmarshal(blob, &list); err != nil {
+		return nil, err
+	}
+
+	if list.Kind != karmaKind {
+		return nil, fmt.Errorf("thing is not karma list")
+	}
+
+	karma := []*SubredditKarma{}
+	for _, k := range list.Data {
+		sr := &SubredditKarma{}
+		if err := decode(k, sr); err != nil {
+			return nil, mapDecodeError(err, k)
+		}
+		karma = append(karma, sr)
+	}
+
+	return karma, nil
+}
+
+// parseMultis parses a list of multireddits.
+func parseMultis(c Codec, blob json.RawMessage) ([]*Multi, error) {
+	var things []thing
+	if err := c.Unmarshal(blob, &things); err != nil {
+		return nil, err
+	}
+
+	multis := []*Multi{}
+	for i := range things {
+		m, err := parseMultiThing(&things[i])
+		if err != nil {
+			return nil, err
+		}
+		multis = append(multis, m)
+	}
+
+	return multis, nil
+}
+
+// parseMulti parses a multireddit.
+func parseMulti(c Codec, blob json.RawMessage) (*Multi, error) {
+	var t thing
+	if err := c.Unmarshal(blob, &t); err != nil {
+		return nil, err
+	}
+
+	return parseMultiThing(&t)
+}
+
+func parseMultiThing(t *thing) (*Multi, error) {
+	if t.Kind != multiKind {
+		return nil, fmt.Errorf("thing is not multireddit")
+	}
+
+	m := struct {
+		Multi      `mapstructure:",squash"`
+		Subreddits []struct {
+			Name string `mapstructure:"name"`
+		} `mapstructure:"subreddits"`
+	}{}
+	if err := decode(t.Data, &m); err != nil {
+		return nil, mapDecodeError(err, t.Data)
+	}
+
+	m.Multi.Subreddits = []string{}
+	for _, sr := range m.Subreddits {
+		m.Multi.Subreddits = append(m.Multi.Subreddits, sr.Name)
+	}
+	return &m.Multi, nil
+}
+
+// parseSubredditAbout parses the about page of a subreddit.
+func parseSubredditAbout(c Codec, blob json.RawMessage) (*SubredditDetail, error) {
+	var t thing
+	if err := c.Unmarshal(blob, &t); err != nil {
+		return nil, err
+	}
+
+	if t.Kind != subredditKind {
+		return nil, fmt.Errorf("thing is not subreddit")
+	}
+
+	s := &SubredditDetail{}
+	if err := decode(t.Data, s); err != nil {
+		return nil, mapDecodeError(err, t.Data)
+	}
+	return s, nil
+}
+
+// parsePostRequirements parses the post requirements of a subreddit.
+func parsePostRequirements(c Codec, blob json.RawMessage) (*PostRequirements, error) {
+	var data map[string]interface{}
+	if err := c.Unmarshal(blob, &data); err != nil {
+		return nil, err
+	}
+
+	r := &PostRequirements{}
+	if err := decode(data, r); err != nil {
+		return nil, mapDecodeError(err, data)
+	}
+	return r, nil
+}
+
+// parseLiveThread parses the description of a live thread.
+func parseLiveThread(c Codec, blob json.RawMessage) (*LiveThread, error) {
+	var t thing
+	if err := c.Unmarshal(blob, &t); err != nil {
+		return nil, err
+	}
+
+	if t.Kind != liveThreadKind {
+		return nil, fmt.Errorf("thing is not live thread")
+	}
+
+	l := &LiveThread{}
+	if err := decode(t.Data, l); err != nil {
+		return nil, mapDecodeError(err, t.Data)
+	}
+	return l, nil
+}
+
+// parseLiveUpdates parses a listing of the updates to a live thread.
+func parseLiveUpdates(c Codec, blob json.RawMessage) ([]*LiveUpdate, error) {
+	var t thing
+	if err := c.Unmarshal(blob, &t); err != nil {
+		return nil, err
+	}
+
+	if t.Kind != listingKind {
+		return nil, fmt.Errorf("thing is not listing")
+	}
+
+	l := &listing{}
+	if err := decode(t.Data, l); err != nil {
+		return nil, mapDecodeError(err, t.Data)
+	}
+
+	updates := []*LiveUpdate{}
+	for _, c := range l.Children {
+		if c.Kind != liveUpdateKind {
+			continue
+		}
+
+		u := &LiveUpdate{}
+		if err := decode(c.Data, u); err != nil {
+			return nil, mapDecodeError(err, c.Data)
+		}
+		updates = append(updates, u)
+	}
+
+	return updates, nil
+}
+
+// parseTraffic parses the traffic of a subreddit. Reddit sends each period as
+// [timestamp, uniques, pageviews], with new subscriptions after them by day.
+func parseTraffic(c Codec, blob json.RawMessage) (*Traffic, error) {
+	var raw struct {
+		Hour  [][]int64 `json:"hour"`
+		Day   [][]int64 `json:"day"`
+		Month [][]int64 `json:"month"`
+	}
+	if err := c.Unmarshal(blob, &raw); err != nil {
+		return nil, err
+	}
+
+	hours, err := trafficPoints(raw.Hour)
+	if err != nil {
+		return nil, err
+	}
+	days, err := trafficPoints(raw.Day)
+	if err != nil {
+		return nil, err
+	}
+	months, err := trafficPoints(raw.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Traffic{Hours: hours, Days: days, Months: months}, nil
+}
+
+// trafficPoints returns the periods of a traffic series, oldest first. Reddit
+// sends them newest first.
+func trafficPoints(series [][]int64) ([]TrafficPoint, error) {
+	points := []TrafficPoint{}
+	for _, values := range series {
+		if len(values) < 3 {
+			return nil, fmt.Errorf(
+				"traffic period has %d values; wanted at least 3", len(values),
+			)
+		}
+
+		point := TrafficPoint{
+			Time:      time.Unix(values[0], 0).UTC(),
+			Uniques:   int(values[1]),
+			Pageviews: int(values[2]),
+		}
+		if len(values) > 3 {
+			point.Subscriptions = int(values[3])
+		}
+		points = append(points, point)
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points, nil
+}
+
+// parseFlair parses the current flair from a flair selector response. The
+// flair is nil if the user has none.
+func parseFlair(c Codec, blob json.RawMessage) (*Flair, error) {
+	var selector struct {
+		Current map[string]interface{} `json:"current"`
+	}
+	if err := c.Unmarshal(blob, &selector); err != nil {
+		return nil, err
+	}
+
+	flair := &Flair{}
+	if err := decode(selector.Current, flair); err != nil {
+		return nil, mapDecodeError(err, selector.Current)
+	}
+
+	if flair.TemplateID == "" && flair.Text == "" && flair.CSSClass == "" {
+		return nil, nil
+	}
+
+	return flair, nil
+}
+
+// parseFlairResults parses the per row results of a bulk flair update.
+func parseFlairResults(c Codec, blob json.RawMessage) ([]FlairResult, error) {
+	var raw []map[string]interface{}
+	if err := c.Unmarshal(blob, &raw); err != nil {
+		return nil, err
+	}
+
+	results := []FlairResult{}
+	for _, data := range raw {
+		result := FlairResult{}
+		if err := decode(data, &result); err != nil {
+			return nil, mapDecodeError(err, data)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// parseSettings parses a subreddit's settings, along with the raw fields they
+// were read from.
+func parseSettings(c Codec, blob json.RawMessage) (
+	*SubredditSettings,
+	map[string]interface{},
+	error,
+) {
+	var t thing
+	if err := c.Unmarshal(blob, &t); err != nil {
+		return nil, nil, err
+	}
+
+	if t.Kind != settingsKind {
+		return nil, nil, fmt.Errorf("thing is not subreddit settings")
+	}
+
+	settings := &SubredditSettings{}
+	if err := decode(t.Data, settings); err != nil {
+		return nil, nil, mapDecodeError(err, t.Data)
+	}
+
+	return settings, t.Data, nil
+}
+
+// parseErrors returns the errors from a json errors envelope, for endpoints
+// which respond with nothing else.
+func parseErrors(c Codec, blob json.RawMessage) error {
+	var wrapped struct {
+		JSON struct {
+			Errors []interface{} `json:"errors"`
+		} `json:"json"`
+	}
+	if err := c.Unmarshal(blob, &wrapped); err != nil {
+		return err
+	}
+
+	if len(wrapped.JSON.Errors) != 0 {
+		return apiErrors(wrapped.JSON.Errors)
+	}
+
+	return nil
+}
+
+// envelopeError returns the errors in a json errors envelope, if the blob is
+// one. Blobs which are not, such as listings, have no errors.
+func envelopeError(c Codec, blob json.RawMessage) error {
+	var wrapped struct {
+		JSON struct {
+			Errors []interface{} `json:"errors"`
+		} `json:"json"`
+	}
+	if c.Unmarshal(blob, &wrapped) != nil || len(wrapped.JSON.Errors) == 0 {
+		return nil
+	}
+
+	return apiErrors(wrapped.JSON.Errors)
+}
+
+// parseAvailability parses the response of the username availability
+// endpoint, which is a bare boolean, or an errors envelope if the name is not
+// valid.
+func parseAvailability(c Codec, blob json.RawMessage) (bool, error) {
+	var available bool
+	if err := c.Unmarshal(blob, &available); err == nil {
+		return available, nil
+	}
+
+	if err := parseErrors(c, blob); err != nil {
+		return false, err
+	}
+
+	return false, fmt.Errorf("unexpected availability response: %s", blob)
+}
+
+// parseNeedsCaptcha parses the response of the captcha check, which is a bare
+// boolean.
+func parseNeedsCaptcha(c Codec, blob json.RawMessage) (bool, error) {
+	var needs bool
+	if err := c.Unmarshal(blob, &needs); err != nil {
+		return false, fmt.Errorf("unexpected captcha response: %s", blob)
+	}
+	return needs, nil
+}
+
+// parseNames parses a list of subreddit names.
+func parseNames(c Codec, blob json.RawMessage) ([]string, error) {
+	var names struct {
+		Names []string `json:"names"`
+	}
+	if err := c.Unmarshal(blob, &names); err != nil {
+		return nil, err
+	}
+
+	if names.Names == nil {
+		return []string{}, nil
+	}
+
+	return names.Names, nil
+}
+
+// decode decodes the fields of a json map into a struct. Fields the struct
+// does not have are ignored and nulls leave fields at their zero value, since
+// Reddit often sends fields which were not there before and nulls in place of
+// values. Numbers are converted by numberHook.
+func decode(data interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook: numberHook,
+			Result:     out,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(data)
+}
+
+// numberHook converts the json.Numbers a Codec decodes, such as the default
+// one, to the kind of field they are decoded into without passing them through
+// a float64, so large integers and exact scores are not rounded. Fractional
+// numbers decoded into integer fields, such as timestamps, are truncated as
+// before. Numbers decoded into interface values, such as the data of things,
+// stay json.Numbers until they reach a field.
+func numberHook(_, to reflect.Type, data interface{}) (interface{}, error) {
+	n, ok := data.(json.Number)
+	if !ok {
+		return data, nil
+	}
+
+	switch to.Kind() {
+	case reflect.Interface:
+		return n, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+			return u, nil
+		}
+	case reflect.String:
+		return string(n), nil
+	}
+
+	return n.Float64()
+}
+
+// decodeStrict is decode without tolerance: fields the struct does not have
+// are errors.
+func decodeStrict(data interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook:  numberHook,
+			ErrorUnused: true,
+			Result:      out,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(data)
+}
+
+// checkStrict decodes the elements of the listings in a json blob strictly,
+// so that fields Reddit sends which their types lack are reported. Blobs
+// which are not listings or threads are not checked.
+func checkStrict(c Codec, blob json.RawMessage) error {
+	var listings []thing
+	var single thing
+	if err := c.Unmarshal(blob, &single); err == nil {
+		listings = []thing{single}
+	} else if err := c.Unmarshal(blob, &listings); err != nil {
+		return nil
+	}
+
+	for _, l := range listings {
+		if l.Kind != listingKind {
+			continue
+		}
+
+		children := &listing{}
+		if err := decode(l.Data, children); err != nil {
+			return mapDecodeError(err, l.Data)
+		}
+
+		for _, c := range children.Children {
+			var out interface{}
+			if c.Kind == messageKind || c.Data["was_comment"] != nil {
+				out = &Message{}
+			} else if c.Kind == commentKind {
+				cleanComment(c.Data)
+				out = &comment{}
+			} else if c.Kind == postKind {
+				cleanEdited(c.Data)
+				out = &Post{}
+			} else if c.Kind == moreKind {
+				out = &More{}
+			} else {
+				continue
+			}
+
+			if err := decodeStrict(c.Data, out); err != nil {
+				return fmt.Errorf(
+					"%s does not match its type: %v", c.Kind, err,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// archivedCode is the error code Reddit returns for writes to archived posts
+// and comments.
+const archivedCode = "TOO_OLD"
+
+// archivedError returns ArchivedErr if a response is a json errors envelope
+// holding Reddit's error for writes to archived things, for endpoints whose
+// responses are otherwise not read.
+func archivedError(c Codec, blob json.RawMessage) error {
+	if parseErrors(c, blob) == ArchivedErr {
+		return ArchivedErr
+	}
+	return nil
+}
+
+// apiErrors returns an error describing the contents of a json errors
+// envelope, which Reddit formats as a list of [code, message, field] lists.
+func apiErrors(errs []interface{}) error {
+	for _, e := range errs {
+		fields, ok := e.([]interface{})
+		if !ok || len(fields) < 2 {
+			continue
+		}
+
+		switch code, _ := fields[0].(string); code {
+		case rateLimitCode:
+			message, _ := fields[1].(string)
+			return newRateLimitError(message)
+		case archivedCode:
+			return ArchivedErr
+		}
+	}
+
+	return &apiError{errs: errs}
+}
+
+// apiError is the error for the contents of a json errors envelope which
+// have no more specific error.
+type apiError struct {
+	errs []interface{}
+}
+
+func (a *apiError) Error() string {
+	return fmt.Sprintf("API errors were returned: %v", a.errs)
+}
+
+// has returns whether Reddit returned the error code.
+func (a *apiError) has(code string) bool {
+	for _, e := range a.errs {
+		if fields, ok := e.([]interface{}); ok && len(fields) > 0 {
+			if c, _ := fields[0].(string); c == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func mapDecodeError(err error, val interface{}) error {
 	return fmt.Errorf(
 		"failed to decode json map into struct: %v; value: %v",
@@ -10,7 +10,9 @@ package reddit
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/kylelemons/godebug/pretty"
 	"github.com/turnage/graw/reddit/internal/testdata"
 )
 
@@ -23,14 +25,14 @@ func TestParse(t *testing.T) {
 		testdata.MustAsset("inbox.json"),
 		testdata.MustAsset("more.json"),
 	} {
-		if _, _, _, _, err := p.parse(input); err != nil {
+		if _, err := p.parse(input); err != nil {
 			t.Errorf("failed to parse input %d: %v", i, err)
 		}
 	}
 }
 
 func TestParseThread(t *testing.T) {
-	post, err := parseThread(testdata.MustAsset("thread.json"))
+	post, err := parseThread(jsonCodec{}, testdata.MustAsset("thread.json"))
 	if err != nil {
 		t.Fatalf("failed to parse: %v", err)
 	}
@@ -111,12 +113,11 @@ func TestParseThread(t *testing.T) {
 }
 
 func TestParseUserFeed(t *testing.T) {
-	comments, posts, _, _, err := parseRawListing(
-		testdata.MustAsset("user.json"),
-	)
+	h, err := parseRawListing(jsonCodec{}, testdata.MustAsset("user.json"))
 	if err != nil {
 		t.Fatalf("failed to parse user feed: %v", err)
 	}
+	comments, posts := h.Comments, h.Posts
 
 	if len(comments) < 1 {
 		t.Fatalf("found no comments in user feed")
@@ -142,10 +143,11 @@ func TestParseUserFeed(t *testing.T) {
 }
 
 func TestParseSubredditFeed(t *testing.T) {
-	_, posts, _, _, err := parseRawListing(testdata.MustAsset("subreddit.json"))
+	h, err := parseRawListing(jsonCodec{}, testdata.MustAsset("subreddit.json"))
 	if err != nil {
 		t.Fatalf("failed to parse subreddit feed: %v", err)
 	}
+	posts := h.Posts
 
 	if len(posts) != 27 {
 		t.Fatalf(
@@ -166,11 +168,26 @@ func TestParseSubredditFeed(t *testing.T) {
 	}
 }
 
+func TestParseListingDist(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing",
+		"data": {"after": "t3_2", "dist": 2, "children": []}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	if h.Dist != 2 {
+		t.Errorf("wrong dist: %d", h.Dist)
+	}
+}
+
 func TestParseInboxFeed(t *testing.T) {
-	_, _, msgs, _, err := parseRawListing(testdata.MustAsset("inbox.json"))
+	h, err := parseRawListing(jsonCodec{}, testdata.MustAsset("inbox.json"))
 	if err != nil {
 		t.Fatalf("failed to parse inbox feed: %v", err)
 	}
+	msgs := h.Messages
 
 	if len(msgs) != 5 {
 		t.Fatalf("found unexpected number of messages: %v", len(msgs))
@@ -182,7 +199,7 @@ func TestParseInboxFeed(t *testing.T) {
 }
 
 func TestParseMoreChildren(t *testing.T) {
-	comments, mores, err := parseMoreChildren(testdata.MustAsset("more.json"))
+	comments, mores, err := parseMoreChildren(jsonCodec{}, testdata.MustAsset("more.json"))
 	if err != nil {
 		t.Fatalf("failed to parse more children: %v", err)
 	}
@@ -195,3 +212,1137 @@ func TestParseMoreChildren(t *testing.T) {
 		t.Fatalf("found unexpected number of mores: %v", len(mores))
 	}
 }
+
+func TestParseSubmittedRateLimit(t *testing.T) {
+	p := newParser()
+	_, err := p.parse_submitted([]byte(`{"json": {"errors": [[
+		"RATELIMIT",
+		"you are doing that too much. try again in 9 minutes.",
+		"ratelimit"
+	]]}}`))
+
+	rateLimitErr, ok := err.(*RateLimitError)
+	if !ok {
+		t.Fatalf("wanted *RateLimitError; got %v", err)
+	}
+
+	if rateLimitErr.Wait != 9*time.Minute {
+		t.Errorf("wrong wait: %v", rateLimitErr.Wait)
+	}
+}
+
+func TestParseSubmittedErrors(t *testing.T) {
+	p := newParser()
+	_, err := p.parse_submitted([]byte(`{"json": {"errors": [[
+		"SUBREDDIT_NOEXIST",
+		"that subreddit doesn't exist",
+		"sr"
+	]]}}`))
+
+	if err == nil {
+		t.Fatalf("wanted error for errors envelope")
+	} else if _, ok := err.(*RateLimitError); ok {
+		t.Errorf("wanted generic error; got %v", err)
+	}
+}
+
+func TestParseTrophies(t *testing.T) {
+	trophies, err := parseTrophies(jsonCodec{}, []byte(`{
+		"kind": "TrophyList",
+		"data": {"trophies": [
+			{"kind": "t6", "data": {
+				"icon_70": "https://www.redditstatic.com/awards2/verified_email-70.png",
+				"granted_at": 1493200011,
+				"url": null,
+				"icon_40": "https://www.redditstatic.com/awards2/verified_email-40.png",
+				"name": "Verified Email",
+				"award_id": "o",
+				"id": "1ab2cd",
+				"description": null
+			}},
+			{"kind": "t6", "data": {
+				"name": "Five-Year Club",
+				"award_id": null,
+				"id": null,
+				"granted_at": null,
+				"description": null
+			}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if len(trophies) != 2 {
+		t.Fatalf("wanted 2 trophies; got %d", len(trophies))
+	}
+
+	if trophies[0].Name != "Verified Email" {
+		t.Errorf("trophy name incorrect: %s", trophies[0].Name)
+	}
+
+	if trophies[0].GrantedAt != 1493200011 {
+		t.Errorf("trophy grant time incorrect: %d", trophies[0].GrantedAt)
+	}
+
+	if trophies[1].Name != "Five-Year Club" {
+		t.Errorf("trophy name incorrect: %s", trophies[1].Name)
+	}
+
+	empty, err := parseTrophies(jsonCodec{}, []byte(`{"kind": "TrophyList", "data": {"trophies": []}}`))
+	if err != nil {
+		t.Errorf("failed to parse empty trophy list: %v", err)
+	} else if empty == nil || len(empty) != 0 {
+		t.Errorf("wanted empty trophy list; got %v", empty)
+	}
+}
+
+func TestParseFlair(t *testing.T) {
+	flair, err := parseFlair(jsonCodec{}, []byte(`{
+		"current": {
+			"flair_css_class": "gopher",
+			"flair_template_id": "1a2b3c",
+			"flair_text": "Gopher",
+			"flair_position": "right"
+		},
+		"choices": []
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if flair == nil || flair.Text != "Gopher" || flair.CSSClass != "gopher" {
+		t.Errorf("flair incorrect: %v", flair)
+	}
+
+	none, err := parseFlair(jsonCodec{}, []byte(`{
+		"current": {
+			"flair_css_class": null,
+			"flair_template_id": null,
+			"flair_text": null,
+			"flair_position": "right"
+		},
+		"choices": []
+	}`))
+	if err != nil {
+		t.Errorf("failed to parse missing flair: %v", err)
+	} else if none != nil {
+		t.Errorf("wanted no flair; got %v", none)
+	}
+}
+
+// driftedListing is a listing with a post which has a field the Post type does
+// not, and nulls where strings, numbers, bools and structs are expected.
+var driftedListing = []byte(`{
+	"kind": "Listing",
+	"data": {"children": [{"kind": "t3", "data": {
+		"name": "t3_abc",
+		"title": "title",
+		"brand_new_field": {"what": "is this"},
+		"score": null,
+		"author_flair_text": null,
+		"media": null,
+		"locked": null,
+		"num_comments": 12
+	}}]}
+}`)
+
+func TestParseTolerant(t *testing.T) {
+	h, err := newParser().parse(driftedListing)
+	if err != nil {
+		t.Fatalf("failed to parse drifted listing: %v", err)
+	}
+
+	if len(h.Posts) != 1 {
+		t.Fatalf("wanted 1 post; got %d", len(h.Posts))
+	}
+
+	post := h.Posts[0]
+	if post.Title != "title" || post.Score != 0 || post.Locked || post.NumComments != 12 {
+		t.Errorf("post parsed incorrectly: %+v", post)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	if _, err := newStrictParser().parse(driftedListing); err == nil {
+		t.Errorf("wanted error parsing drifted listing strictly")
+	}
+
+	if _, err := newStrictParser().parse([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [{"kind": "t3", "data": {
+			"name": "t3_abc",
+			"title": "title",
+			"score": 5
+		}}]}
+	}`)); err != nil {
+		t.Errorf("failed to parse exact listing strictly: %v", err)
+	}
+
+	if _, err := newStrictParser().parse(testdata.MustAsset("more.json")); err != nil {
+		t.Errorf("strict parser checked non-listing: %v", err)
+	}
+}
+
+func TestParseMixedListing(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing",
+		"data": {"after": "t1_def", "dist": 2, "children": [
+			{"kind": "t1", "data": {
+				"name": "t1_def",
+				"body": "saved comment",
+				"link_title": "a post",
+				"replies": "",
+				"edited": false
+			}},
+			{"kind": "t3", "data": {
+				"name": "t3_abc",
+				"title": "saved post",
+				"is_self": true
+			}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse mixed listing: %v", err)
+	}
+
+	if len(h.Comments) != 1 || h.Comments[0].Body != "saved comment" {
+		t.Errorf("comment parsed incorrectly: %v", h.Comments)
+	}
+
+	if len(h.Posts) != 1 || h.Posts[0].Title != "saved post" {
+		t.Errorf("post parsed incorrectly: %v", h.Posts)
+	}
+
+	if h.After != "t1_def" {
+		t.Errorf("wrong after: %q", h.After)
+	}
+}
+
+var listingWithMore = []byte(`{
+	"kind": "Listing",
+	"data": {"after": null, "children": [
+		{"kind": "t1", "data": {
+			"name": "t1_def",
+			"body": "a comment",
+			"replies": "",
+			"edited": false
+		}},
+		{"kind": "more", "data": {
+			"count": 3,
+			"name": "t1_ghi",
+			"id": "ghi",
+			"parent_id": "t3_abc",
+			"depth": 0,
+			"children": ["ghi", "jkl", "mno"]
+		}}
+	]}
+}`)
+
+func TestParseListingMore(t *testing.T) {
+	for _, p := range []parser{newParser(), newStrictParser()} {
+		h, err := p.parse(listingWithMore)
+		if err != nil {
+			t.Fatalf("failed to parse listing with more: %v", err)
+		}
+
+		if len(h.Comments) != 1 || h.Comments[0].Body != "a comment" {
+			t.Errorf("comment parsed incorrectly: %v", h.Comments)
+		}
+
+		if len(h.Mores) != 1 {
+			t.Fatalf("got %d mores; wanted 1", len(h.Mores))
+		}
+
+		more := h.Mores[0]
+		if more.Count != 3 || more.ParentID != "t3_abc" ||
+			strings.Join(more.Children, ",") != "ghi,jkl,mno" {
+			t.Errorf("more parsed incorrectly: %+v", more)
+		}
+
+		if more.ContinuesThread() {
+			t.Errorf("more with children continues thread")
+		}
+	}
+}
+
+func TestParseSubredditDetail(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {
+				"name": "t3_abc",
+				"title": "post",
+				"sr_detail": {
+					"name": "t5_2qh1i",
+					"display_name": "golang",
+					"title": "The Go Programming Language",
+					"subscribers": 200000,
+					"over_18": false,
+					"icon_img": "",
+					"key_color": "#222222",
+					"header_img": null
+				}
+			}},
+			{"kind": "t3", "data": {"name": "t3_def", "title": "plain post"}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	if len(h.Posts) != 2 {
+		t.Fatalf("wanted 2 posts; got %d", len(h.Posts))
+	}
+
+	detail := h.Posts[0].SubredditDetail
+	if detail == nil || detail.DisplayName != "golang" || detail.Subscribers != 200000 {
+		t.Errorf("subreddit detail parsed incorrectly: %+v", detail)
+	}
+
+	if h.Posts[1].SubredditDetail != nil {
+		t.Errorf("wanted no subreddit detail without sr_detail")
+	}
+}
+
+func TestParseInfoMetadata(t *testing.T) {
+	h, err := newParser().parse([]byte(`{
+		"kind": "Listing",
+		"data": {"after": null, "dist": 1, "children": [
+			{"kind": "t3", "data": {
+				"name": "t3_abc",
+				"title": "metadata",
+				"score": 1234,
+				"num_comments": 56,
+				"upvote_ratio": 0.97,
+				"created_utc": 1570000000.0,
+				"over_18": true,
+				"stickied": true,
+				"edited": false,
+				"all_awardings": [],
+				"preview": {"enabled": false}
+			}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse info listing: %v", err)
+	}
+
+	if len(h.Posts) != 1 {
+		t.Fatalf("wanted 1 post; got %d", len(h.Posts))
+	}
+
+	post := h.Posts[0]
+	if post.Score != 1234 ||
+		post.NumComments != 56 ||
+		post.UpvoteRatio != 0.97 ||
+		post.CreatedUTC != 1570000000 ||
+		!post.NSFW ||
+		!post.Stickied {
+		t.Errorf("post metadata parsed incorrectly: %+v", post)
+	}
+}
+
+func TestParseKarma(t *testing.T) {
+	karma, err := parseKarma(jsonCodec{}, []byte(`{
+		"kind": "KarmaList",
+		"data": [
+			{"sr": "golang", "comment_karma": 120, "link_karma": 34},
+			{"sr": "rust", "comment_karma": 5, "link_karma": 0}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if len(karma) != 2 {
+		t.Fatalf("wanted 2 subreddits; got %d", len(karma))
+	}
+
+	if karma[0].Subreddit != "golang" || karma[0].CommentKarma != 120 || karma[0].LinkKarma != 34 {
+		t.Errorf("karma parsed incorrectly: %+v", karma[0])
+	}
+
+	empty, err := parseKarma(jsonCodec{}, []byte(`{"kind": "KarmaList", "data": []}`))
+	if err != nil {
+		t.Errorf("failed to parse empty karma list: %v", err)
+	} else if empty == nil || len(empty) != 0 {
+		t.Errorf("wanted empty karma list; got %v", empty)
+	}
+}
+
+func TestParsePreview(t *testing.T) {
+	h, err := newParser().parse([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {
+				"name": "t3_dg3h1q",
+				"title": "preview",
+				"thumbnail": "https://b.thumbs.redditmedia.com/thumb.jpg",
+				"preview": {
+					"images": [{
+						"source": {
+							"url": "https://preview.redd.it/gopher.png?auto=webp&s=9a1",
+							"width": 1200,
+							"height": 800
+						},
+						"resolutions": [
+							{"url": "https://preview.redd.it/gopher.png?width=108&s=1b2", "width": 108, "height": 72},
+							{"url": "https://preview.redd.it/gopher.png?width=216&s=2c3", "width": 216, "height": 144},
+							{"url": "https://preview.redd.it/gopher.png?width=320&s=3d4", "width": 320, "height": 213},
+							{"url": "https://preview.redd.it/gopher.png?width=640&s=4e5", "width": 640, "height": 426},
+							{"url": "https://preview.redd.it/gopher.png?width=960&s=5f6", "width": 960, "height": 640}
+						],
+						"variants": {},
+						"id": "Xq3kSg2eQ8Zlc0C9Yc"
+					}],
+					"enabled": true
+				}
+			}},
+			{"kind": "t3", "data": {"name": "t3_dg3h1r", "title": "no preview"}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	preview := h.Posts[0].Preview
+	if preview == nil || len(preview.Images) != 1 {
+		t.Fatalf("preview parsed incorrectly: %+v", preview)
+	}
+
+	image := preview.Images[0]
+	if image.Source.Width != 1200 || len(image.Resolutions) != 5 {
+		t.Errorf("preview image parsed incorrectly: %+v", image)
+	}
+
+	for _, test := range []struct {
+		maxWidth int
+		url      string
+		ok       bool
+	}{
+		{2000, "https://preview.redd.it/gopher.png?auto=webp&s=9a1", true},
+		{1000, "https://preview.redd.it/gopher.png?width=960&s=5f6", true},
+		{320, "https://preview.redd.it/gopher.png?width=320&s=3d4", true},
+		{100, "", false},
+	} {
+		url, ok := h.Posts[0].BestThumbnail(test.maxWidth)
+		if url != test.url || ok != test.ok {
+			t.Errorf(
+				"got %q, %v under %d; wanted %q, %v",
+				url, ok, test.maxWidth, test.url, test.ok,
+			)
+		}
+	}
+
+	if h.Posts[1].Preview != nil {
+		t.Errorf("wanted no preview for post without one")
+	}
+	if _, ok := h.Posts[1].BestThumbnail(2000); ok {
+		t.Errorf("wanted no thumbnail for post without a preview")
+	}
+}
+
+func TestParseMedia(t *testing.T) {
+	h, err := newParser().parse([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {
+				"name": "t3_dh1v0a",
+				"is_self": false,
+				"domain": "youtube.com",
+				"post_hint": "rich:video",
+				"media": {
+					"type": "youtube.com",
+					"oembed": {
+						"type": "video",
+						"thumbnail_url": "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg",
+						"html": "&lt;iframe width=\"356\" height=\"200\" src=\"https://www.youtube.com/embed/dQw4w9WgXcQ?feature=oembed&amp;enablejsapi=1\" frameborder=\"0\"&gt;&lt;/iframe&gt;"
+					}
+				},
+				"secure_media": null
+			}},
+			{"kind": "t3", "data": {
+				"name": "t3_dh1v0b",
+				"domain": "v.redd.it",
+				"post_hint": "hosted:video",
+				"secure_media": {
+					"reddit_video": {
+						"fallback_url": "https://v.redd.it/k2u5wojt4ss31/DASH_720?source=fallback",
+						"height": 720,
+						"duration": 12
+					}
+				}
+			}},
+			{"kind": "t3", "data": {
+				"name": "t3_dh1v0c",
+				"is_self": true,
+				"domain": "self.golang",
+				"media": null,
+				"secure_media": null
+			}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	embed := h.Posts[0]
+	if embed.IsSelf || embed.Domain != "youtube.com" || embed.PostHint != "rich:video" {
+		t.Errorf("link parsed incorrectly: %+v", embed)
+	}
+	if embed.Media.Type != "youtube.com" ||
+		embed.Media.OEmbed.ThumbnailURL != "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg" {
+		t.Errorf("media parsed incorrectly: %+v", embed.Media)
+	}
+
+	for i, want := range []string{
+		"https://www.youtube.com/embed/dQw4w9WgXcQ?feature=oembed&enablejsapi=1",
+		"https://v.redd.it/k2u5wojt4ss31/DASH_720?source=fallback",
+	} {
+		if url, ok := h.Posts[i].EmbedURL(); url != want || !ok {
+			t.Errorf("got embed url %q, %v of post %d; wanted %q", url, ok, i, want)
+		}
+	}
+
+	self := h.Posts[2]
+	if !self.IsSelf || self.Domain != "self.golang" {
+		t.Errorf("self post parsed incorrectly: %+v", self)
+	}
+	if _, ok := self.EmbedURL(); ok {
+		t.Errorf("wanted no embed url for post without media")
+	}
+}
+
+func TestParseGallery(t *testing.T) {
+	h, err := newParser().parse([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {
+				"name": "t3_hrrh23",
+				"title": "gallery",
+				"is_gallery": true,
+				"url": "https://www.reddit.com/gallery/hrrh23",
+				"gallery_data": {"items": [
+					{"media_id": "nd7hx2w1p7a51", "id": 1234567, "caption": "second upload"},
+					{"media_id": "5bvp1yz0p7a51", "id": 1234568, "outbound_url": "https://golang.org"},
+					{"media_id": "k3ezcobqp7a51", "id": 1234569},
+					{"media_id": "missing00000", "id": 1234570}
+				]},
+				"media_metadata": {
+					"5bvp1yz0p7a51": {
+						"status": "valid",
+						"e": "Image",
+						"m": "image/jpg",
+						"p": [{"y": 81, "x": 108, "u": "https://preview.redd.it/5bvp1yz0p7a51.jpg?width=108"}],
+						"s": {"y": 900, "x": 1200, "u": "https://preview.redd.it/5bvp1yz0p7a51.jpg?width=1200"},
+						"id": "5bvp1yz0p7a51"
+					},
+					"nd7hx2w1p7a51": {
+						"status": "valid",
+						"e": "AnimatedImage",
+						"m": "image/gif",
+						"s": {"y": 300, "x": 400, "gif": "https://i.redd.it/nd7hx2w1p7a51.gif", "mp4": "https://preview.redd.it/nd7hx2w1p7a51.gif?format=mp4"},
+						"id": "nd7hx2w1p7a51"
+					},
+					"k3ezcobqp7a51": {"status": "unprocessed", "id": "k3ezcobqp7a51"}
+				}
+			}},
+			{"kind": "t3", "data": {"name": "t3_hrrh24", "title": "not a gallery", "media_metadata": null}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	images := h.Posts[0].GalleryImages()
+	if len(images) != 2 {
+		t.Fatalf("got %d gallery images; wanted 2: %+v", len(images), images)
+	}
+
+	if images[0].URL != "https://i.redd.it/nd7hx2w1p7a51.gif" ||
+		images[0].Caption != "second upload" ||
+		images[0].Width != 400 {
+		t.Errorf("first gallery image incorrect: %+v", images[0])
+	}
+
+	if images[1].URL != "https://preview.redd.it/5bvp1yz0p7a51.jpg?width=1200" ||
+		images[1].OutboundURL != "https://golang.org" ||
+		images[1].Height != 900 {
+		t.Errorf("second gallery image incorrect: %+v", images[1])
+	}
+
+	if images := h.Posts[1].GalleryImages(); images == nil || len(images) != 0 {
+		t.Errorf("wanted empty images for post which is not a gallery; got %v", images)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := parseScopes(jsonCodec{}, []byte(`{
+		"identity": {
+			"description": "Access my reddit username and signup date.",
+			"id": "identity",
+			"name": "My Identity"
+		},
+		"modposts": {
+			"description": "Approve, remove, mark nsfw, and distinguish content in subreddits I moderate.",
+			"id": "modposts",
+			"name": "Moderate Posts"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse scopes: %v", err)
+	}
+
+	if diff := pretty.Compare(
+		scopes,
+		map[string]Scope{
+			"identity": {
+				ID:          "identity",
+				Name:        "My Identity",
+				Description: "Access my reddit username and signup date.",
+			},
+			"modposts": {
+				ID:          "modposts",
+				Name:        "Moderate Posts",
+				Description: "Approve, remove, mark nsfw, and distinguish content in subreddits I moderate.",
+			},
+		},
+	); diff != "" {
+		t.Errorf("scopes parsed incorrectly; diff: %s", diff)
+	}
+}
+
+func TestParseMe(t *testing.T) {
+	user, err := parseMe(jsonCodec{}, []byte(`{
+		"is_employee": false,
+		"seen_layout_switch": true,
+		"has_visited_new_profile": false,
+		"pref_no_profanity": true,
+		"has_external_account": false,
+		"pref_geopopular": "",
+		"is_sponsor": false,
+		"gold_expiration": null,
+		"has_gold_subscription": false,
+		"num_friends": 0,
+		"features": {"mod_service_mute_writes": true, "chat_subreddit": true},
+		"has_android_subscription": false,
+		"verified": true,
+		"new_modmail_exists": null,
+		"pref_autoplay": true,
+		"coins": 0,
+		"has_paypal_subscription": false,
+		"has_subscribed_to_premium": false,
+		"id": "1w72",
+		"has_stripe_subscription": false,
+		"oauth_client_id": "abcdefghijklmn",
+		"can_create_subreddit": true,
+		"over_18": true,
+		"is_gold": false,
+		"is_mod": true,
+		"awarder_karma": 0,
+		"suspension_expiration_utc": null,
+		"has_verified_email": true,
+		"is_suspended": false,
+		"pref_video_autoplay": true,
+		"in_chat": true,
+		"has_ios_subscription": false,
+		"pref_show_twitter": false,
+		"password_set": true,
+		"link_karma": 1543,
+		"force_password_reset": false,
+		"total_karma": 10873,
+		"inbox_count": 2,
+		"pref_top_karma_subreddits": true,
+		"has_mail": true,
+		"pref_show_snoovatar": false,
+		"name": "gopher",
+		"pref_clickgadget": 5,
+		"created": 1144917741.0,
+		"gold_creddits": 0,
+		"created_utc": 1144888941.0,
+		"has_mod_mail": false,
+		"in_beta": false,
+		"snoovatar_img": "https://i.redd.it/snoovatar/avatars/gopher.png",
+		"snoovatar_size": [380, 600],
+		"icon_img": "https://styles.redditmedia.com/t5_6ql2p/styles/profileIcon_gopher.png?width=256&height=256&crop=256:256,smart&s=ab12",
+		"subreddit": {
+			"default_set": true,
+			"user_is_contributor": false,
+			"banner_img": "",
+			"restrict_posting": true,
+			"user_is_banned": false,
+			"free_form_reports": true,
+			"community_icon": null,
+			"show_media": true,
+			"icon_color": "",
+			"user_is_muted": false,
+			"display_name": "u_gopher",
+			"header_img": null,
+			"title": "Gopher",
+			"coins": 0,
+			"previous_names": [],
+			"over_18": false,
+			"icon_size": [256, 256],
+			"primary_color": "",
+			"icon_img": "https://styles.redditmedia.com/t5_6ql2p/styles/profileIcon_gopher.png?width=256&height=256&crop=256:256,smart&s=ab12",
+			"description": "",
+			"submit_link_label": "",
+			"header_size": null,
+			"restrict_commenting": false,
+			"subscribers": 12,
+			"submit_text_label": "",
+			"is_default_icon": false,
+			"link_flair_position": "",
+			"display_name_prefixed": "u/gopher",
+			"key_color": "",
+			"name": "t5_6ql2p",
+			"is_default_banner": true,
+			"url": "/user/gopher/",
+			"quarantine": false,
+			"banner_size": null,
+			"user_is_moderator": true,
+			"accept_followers": true,
+			"public_description": "Gophers gopher.",
+			"link_flair_enabled": false,
+			"disable_contributor_requests": false,
+			"subreddit_type": "user",
+			"user_is_subscriber": false
+		},
+		"pref_show_presence": true,
+		"modhash": null,
+		"comment_karma": 9330
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse profile: %v", err)
+	}
+
+	if user.Name != "gopher" ||
+		user.LinkKarma != 1543 ||
+		user.CommentKarma != 9330 ||
+		!user.Verified ||
+		!user.HasVerifiedEmail ||
+		!user.IsMod ||
+		user.SnoovatarImg != "https://i.redd.it/snoovatar/avatars/gopher.png" ||
+		!strings.Contains(user.IconImg, "&height=256") {
+		t.Errorf("profile parsed incorrectly: %+v", user)
+	}
+
+	if sr := user.Subreddit; sr == nil {
+		t.Errorf("wanted profile subreddit")
+	} else if sr.DisplayNamePrefixed != "u/gopher" ||
+		sr.Name != "t5_6ql2p" ||
+		sr.Subscribers != 12 ||
+		sr.PublicDescription != "Gophers gopher." {
+		t.Errorf("profile subreddit parsed incorrectly: %+v", sr)
+	}
+
+	user, err = parseMe(jsonCodec{}, []byte(`{"name": "gopher", "subreddit": null}`))
+	if err != nil {
+		t.Fatalf("failed to parse profile without subreddit: %v", err)
+	}
+	if user.Subreddit != nil || user.SnoovatarImg != "" {
+		t.Errorf("wanted no subreddit or snoovatar; got %+v", user)
+	}
+
+	if _, err := parseMe(jsonCodec{}, []byte(`{}`)); err == nil {
+		t.Errorf("wanted error for response which is not a profile")
+	}
+}
+
+func TestParsePoll(t *testing.T) {
+	h, err := newParser().parse([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {
+				"name": "t3_jx0x1c",
+				"title": "Which is your favourite release?",
+				"poll_data": {
+					"prediction_status": null,
+					"total_stake_amount": null,
+					"voting_end_timestamp": 1606001797887,
+					"options": [
+						{"text": "Go 1.14", "id": "5887791"},
+						{"text": "Go 1.15", "id": "5887792"}
+					],
+					"vote_updates_remained": null,
+					"is_prediction": false,
+					"resolved_option_id": null,
+					"user_won_amount": null,
+					"user_selection": null,
+					"total_vote_count": 1402,
+					"tournament_id": null
+				}
+			}},
+			{"kind": "t3", "data": {"name": "t3_jx0x1d", "title": "not a poll"}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	poll := h.Posts[0].PollData
+	if poll == nil {
+		t.Fatalf("wanted poll data")
+	}
+
+	if poll.TotalVoteCount != 1402 ||
+		len(poll.Options) != 2 ||
+		poll.Options[1].ID != "5887792" ||
+		poll.Options[1].Text != "Go 1.15" ||
+		poll.UserSelection != "" {
+		t.Errorf("poll parsed incorrectly: %+v", poll)
+	}
+
+	if end := poll.VotingEnd(); !end.Equal(time.Unix(1606001797, 887000000)) {
+		t.Errorf("got voting end %v", end)
+	}
+
+	if h.Posts[1].PollData != nil {
+		t.Errorf("wanted no poll data for post which is not a poll")
+	}
+}
+
+func TestParseScoreHidden(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_abc", "score": 1, "hide_score": true}},
+			{"kind": "t1", "data": {"name": "t1_def", "score": 1, "score_hidden": true}},
+			{"kind": "t1", "data": {"name": "t1_ghi", "score": 12, "score_hidden": false}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("error parsing listing: %v", err)
+	}
+
+	if len(h.Posts) != 1 || len(h.Comments) != 2 {
+		t.Fatalf("wanted 1 post and 2 comments; got %+v", h)
+	}
+
+	if _, hidden := h.Posts[0].DisplayedScore(); !hidden {
+		t.Errorf("wanted the post's score hidden")
+	}
+	if _, hidden := h.Comments[0].DisplayedScore(); !hidden {
+		t.Errorf("wanted the first comment's score hidden")
+	}
+	if score, hidden := h.Comments[1].DisplayedScore(); hidden || score != 12 {
+		t.Errorf("wanted a visible score of 12; got %d, %v", score, hidden)
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing", "dist": 3, "data": {"dist": 3, "children": [
+			{"kind": "t5", "data": {"name": "t5_a", "subscribers": 9007199254740993}},
+			{"kind": "t3", "data": {"name": "t3_b", "edited": 1570000000.75, "upvote_ratio": 0.97}},
+			{"kind": "t3", "data": {"name": "t3_c", "edited": false}},
+			{"kind": "t1", "data": {"name": "t1_d", "edited": 1570000100.5, "score": -4}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("error parsing listing: %v", err)
+	}
+
+	if h.Subreddits[0].Subscribers != 9007199254740993 {
+		t.Errorf("large count rounded to %d", h.Subreddits[0].Subscribers)
+	}
+
+	if h.Posts[0].Edited != 1570000000 || h.Posts[0].UpvoteRatio != 0.97 {
+		t.Errorf(
+			"wanted the post edited at 1570000000 with ratio 0.97; got %d, %v",
+			h.Posts[0].Edited, h.Posts[0].UpvoteRatio,
+		)
+	}
+
+	if h.Posts[1].Edited != 0 {
+		t.Errorf("wanted the unedited post's Edited zero; got %d", h.Posts[1].Edited)
+	}
+
+	if h.Comments[0].Edited != 1570000100 || h.Comments[0].Score != -4 {
+		t.Errorf("comment numbers incorrect: %+v", h.Comments[0])
+	}
+
+	if h.Dist != 3 {
+		t.Errorf("got dist %d; wanted 3", h.Dist)
+	}
+}
+
+func TestParseSearchResults(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing", "data": {"after": "t5_b", "children": [
+			{"kind": "t5", "data": {
+				"name": "t5_a",
+				"display_name": "golang",
+				"subscribers": 200000,
+				"over_18": false
+			}},
+			{"kind": "t2", "data": {"id": "c", "name": "gopher", "link_karma": 5}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("error parsing results: %v", err)
+	}
+
+	if diff := pretty.Compare(
+		h,
+		Harvest{
+			Comments: []*Comment{},
+			Posts:    []*Post{},
+			Messages: []*Message{},
+			Mores:    []*More{},
+			Subreddits: []*SubredditDetail{
+				&SubredditDetail{Name: "t5_a", DisplayName: "golang", Subscribers: 200000},
+			},
+			Users: []*User{&User{ID: "c", Name: "gopher", LinkKarma: 5}},
+			After: "t5_b",
+		},
+	); diff != "" {
+		t.Errorf("search results incorrect; diff: %s", diff)
+	}
+}
+
+func TestParseAwardings(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_abc", "all_awardings": [
+				{"id": "gid_1", "name": "Silver", "count": 3, "coin_price": 100},
+				{"id": "gid_2", "name": "Gold", "count": 1, "coin_price": 500}
+			]}},
+			{"kind": "t1", "data": {"name": "t1_def", "all_awardings": []}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("error parsing listing: %v", err)
+	}
+
+	if diff := pretty.Compare(
+		h.Posts[0].Awardings,
+		[]*Awarding{
+			&Awarding{ID: "gid_1", Name: "Silver", Count: 3, CoinPrice: 100},
+			&Awarding{ID: "gid_2", Name: "Gold", Count: 1, CoinPrice: 500},
+		},
+	); diff != "" {
+		t.Errorf("awardings incorrect; diff: %s", diff)
+	}
+
+	if coins := h.Posts[0].TotalAwardCoins(); coins != 800 {
+		t.Errorf("got %d coins; wanted 800", coins)
+	}
+
+	if len(h.Comments[0].Awardings) != 0 || h.Comments[0].TotalAwardCoins() != 0 {
+		t.Errorf("wanted no awards; got %v", h.Comments[0].Awardings)
+	}
+}
+
+var multiResponse = `{"kind": "LabeledMulti", "data": {
+	"name": "languages",
+	"display_name": "Languages",
+	"path": "/user/gopher/m/languages/",
+	"owner": "gopher",
+	"description_md": "Programming languages",
+	"visibility": "public",
+	"over_18": false,
+	"created_utc": 1570000000.0,
+	"icon_url": "https://www.redditstatic.com/custom_feeds/custom_feed_default_4.png",
+	"subreddits": [{"name": "golang"}, {"name": "rust"}]
+}}`
+
+func TestParseMultis(t *testing.T) {
+	multis, err := parseMultis(jsonCodec{}, []byte("["+multiResponse+"]"))
+	if err != nil {
+		t.Fatalf("failed to parse multireddits: %v", err)
+	}
+
+	expected := []*Multi{{
+		Name:        "languages",
+		DisplayName: "Languages",
+		Path:        "/user/gopher/m/languages/",
+		Owner:       "gopher",
+		Description: "Programming languages",
+		Visibility:  "public",
+		Created:     1570000000,
+		Subreddits:  []string{"golang", "rust"},
+	}}
+	if diff := pretty.Compare(multis, expected); diff != "" {
+		t.Errorf("multireddits incorrect; diff: %s", diff)
+	}
+
+	if multis, err := parseMultis(jsonCodec{}, []byte(`[]`)); err != nil || multis == nil || len(multis) != 0 {
+		t.Errorf("wanted no multireddits; got %v, %v", multis, err)
+	}
+
+	multi, err := parseMulti(jsonCodec{}, []byte(multiResponse))
+	if err != nil || multi.Name != "languages" || len(multi.Subreddits) != 2 {
+		t.Errorf("wanted the languages multireddit; got %+v, %v", multi, err)
+	}
+
+	if _, err := parseMulti(jsonCodec{}, []byte(`{"kind": "t5", "data": {}}`)); err == nil {
+		t.Errorf("wanted an error parsing a subreddit as a multireddit")
+	}
+}
+
+func TestFullPermalink(t *testing.T) {
+	for _, test := range []struct {
+		base      string
+		permalink string
+		full      string
+	}{
+		{"", "/r/golang/comments/abc/title/", "https://www.reddit.com/r/golang/comments/abc/title/"},
+		{"https://old.reddit.com/", "/r/golang/comments/abc/title/", "https://old.reddit.com/r/golang/comments/abc/title/"},
+		{"https://www.reddit.com", "r/golang/comments/abc/", "https://www.reddit.com/r/golang/comments/abc/"},
+		{"https://old.reddit.com", "https://www.reddit.com/r/golang/comments/abc/", "https://www.reddit.com/r/golang/comments/abc/"},
+		{"https://www.reddit.com", "//www.reddit.com/r/golang/", "https://www.reddit.com/r/golang/"},
+		{"https://www.reddit.com", "", ""},
+	} {
+		post := &Post{Permalink: test.permalink, permalinkBase: test.base}
+		if full := post.FullPermalink(); full != test.full {
+			t.Errorf("[%s, %s] got %q; wanted %q", test.base, test.permalink, full, test.full)
+		}
+		if full := fullPermalink(test.base, post.FullPermalink()); full != test.full {
+			t.Errorf("[%s, %s] not idempotent; got %q", test.base, test.permalink, full)
+		}
+	}
+
+	comment := &Comment{Permalink: "/r/golang/comments/abc/title/def/"}
+	if full := comment.FullPermalink(); full != "https://www.reddit.com/r/golang/comments/abc/title/def/" {
+		t.Errorf("got comment permalink %q", full)
+	}
+}
+
+func TestParsePermalinkBase(t *testing.T) {
+	listing := []byte(`{"kind": "Listing", "data": {"children": [
+		{"kind": "t1", "data": {
+			"name": "t1_def",
+			"permalink": "/r/golang/comments/abc/title/def/",
+			"replies": {"kind": "Listing", "data": {"children": [
+				{"kind": "t1", "data": {
+					"name": "t1_ghi",
+					"permalink": "/r/golang/comments/abc/title/ghi/"
+				}}
+			]}}
+		}}
+	]}}`)
+
+	h, err := newParserFromConfig(
+		parserConfig{permalinkBase: "https://old.reddit.com"},
+	).parse(listing)
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+	if full := h.Comments[0].FullPermalink(); full != "https://old.reddit.com/r/golang/comments/abc/title/def/" {
+		t.Errorf("got comment permalink %q", full)
+	}
+	if len(h.Comments[0].Replies) != 1 {
+		t.Fatalf("got %d replies; wanted 1", len(h.Comments[0].Replies))
+	}
+	if full := h.Comments[0].Replies[0].FullPermalink(); full != "https://old.reddit.com/r/golang/comments/abc/title/ghi/" {
+		t.Errorf("got reply permalink %q", full)
+	}
+}
+
+var trafficResponse = `{
+	"hour": [[1570003200, 40, 90], [1569999600, 35, 80]],
+	"day": [[1569974400, 300, 900, 4], [1569888000, 280, 850, 0]],
+	"month": [[1569888000, 2000, 7000]]
+}`
+
+func TestParseTraffic(t *testing.T) {
+	traffic, err := parseTraffic(jsonCodec{}, []byte(trafficResponse))
+	if err != nil {
+		t.Fatalf("failed to parse traffic: %v", err)
+	}
+
+	expected := &Traffic{
+		Hours: []TrafficPoint{
+			{Time: time.Unix(1569999600, 0).UTC(), Uniques: 35, Pageviews: 80},
+			{Time: time.Unix(1570003200, 0).UTC(), Uniques: 40, Pageviews: 90},
+		},
+		Days: []TrafficPoint{
+			{Time: time.Unix(1569888000, 0).UTC(), Uniques: 280, Pageviews: 850},
+			{Time: time.Unix(1569974400, 0).UTC(), Uniques: 300, Pageviews: 900, Subscriptions: 4},
+		},
+		Months: []TrafficPoint{
+			{Time: time.Unix(1569888000, 0).UTC(), Uniques: 2000, Pageviews: 7000},
+		},
+	}
+	if diff := pretty.Compare(traffic, expected); diff != "" {
+		t.Errorf("traffic incorrect; diff: %s", diff)
+	}
+
+	if _, err := parseTraffic(jsonCodec{}, []byte(`{"hour": [[1570003200, 40]]}`)); err == nil {
+		t.Errorf("wanted an error for a short traffic period")
+	}
+}
+
+func TestParseModeratedSubreddits(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing",
+		"data": {
+			"after": "t5_2rc7j",
+			"children": [
+				{"kind": "t5", "data": {
+					"name": "t5_2qh1i",
+					"display_name": "golang",
+					"title": "The Go Programming Language",
+					"subreddit_type": "public",
+					"subscribers": 200000,
+					"user_is_moderator": true,
+					"mod_permissions": ["all"]
+				}},
+				{"kind": "t5", "data": {
+					"name": "t5_2rc7j",
+					"display_name": "gopherbots",
+					"subreddit_type": "private",
+					"subscribers": 12,
+					"user_is_moderator": true,
+					"mod_permissions": ["posts", "flair"]
+				}}
+			]
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	if len(h.Subreddits) != 2 || h.After != "t5_2rc7j" {
+		t.Fatalf("got %d subreddits after %q; wanted 2 after t5_2rc7j", len(h.Subreddits), h.After)
+	}
+
+	golang, bots := h.Subreddits[0], h.Subreddits[1]
+	if !golang.HasModPermission("wiki") {
+		t.Errorf("all permissions did not grant wiki")
+	}
+	if !bots.HasModPermission("flair") || bots.HasModPermission("config") {
+		t.Errorf("got permissions %v; wanted posts and flair", bots.ModPermissions)
+	}
+	if (&SubredditDetail{}).HasModPermission("posts") {
+		t.Errorf("subreddit without permissions granted posts")
+	}
+}
+
+func TestParseArchived(t *testing.T) {
+	h, err := parseRawListing(jsonCodec{}, []byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_old", "archived": true}},
+			{"kind": "t3", "data": {"name": "t3_new", "archived": false}},
+			{"kind": "t1", "data": {"name": "t1_old", "archived": true}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse listing: %v", err)
+	}
+
+	if !h.Posts[0].Archived || h.Posts[1].Archived || !h.Comments[0].Archived {
+		t.Errorf("archived flags parsed incorrectly: %v, %v, %v",
+			h.Posts[0].Archived, h.Posts[1].Archived, h.Comments[0].Archived)
+	}
+
+	if _, err := newParser().parse_submitted([]byte(`{"json": {"errors": [[
+		"TOO_OLD",
+		"that's a piece of history now; it's too late to reply to it",
+		"parent"
+	]]}}`)); err != ArchivedErr {
+		t.Errorf("wanted ArchivedErr; got %v", err)
+	}
+}
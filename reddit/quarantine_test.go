@@ -0,0 +1,93 @@
+package reddit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestQuarantineOptIn(t *testing.T) {
+	mu := &sync.Mutex{}
+	optedIn := false
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if r.Method == "POST" && r.URL.Path == "/api/quarantine_optin" {
+					optedIn = r.URL.Query().Get("sr_name") == "quarantined"
+					w.Write([]byte("{}"))
+					return
+				}
+
+				if !optedIn {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{
+						"reason": "quarantined",
+						"quarantine_message": "This community is quarantined.",
+						"message": "Forbidden",
+						"error": 403
+					}`))
+					return
+				}
+
+				w.Write([]byte(`{"kind": "Listing", "data": {"children": [
+					{"kind": "t3", "data": {"name": "t3_post"}}
+				]}}`))
+			},
+		),
+	)
+	defer server.Close()
+
+	r := &reaperImpl{
+		cli:        &baseClient{cli: &http.Client{}},
+		parser:     newParser(),
+		hostname:   strings.TrimPrefix(server.URL, "http://"),
+		reapSuffix: ".json",
+		scheme:     "http",
+		mu:         &sync.Mutex{},
+	}
+	b := &bot{
+		Account: newAccount(r, accountConfig{}),
+		Scanner: newScanner(r),
+	}
+
+	_, err := b.Listing("/r/quarantined/new", "")
+	qErr, ok := err.(*QuarantinedError)
+	if !ok {
+		t.Fatalf("wanted *QuarantinedError; got %v", err)
+	}
+	if qErr.Message != "This community is quarantined." {
+		t.Errorf("wrong quarantine message: %s", qErr.Message)
+	}
+
+	if err := b.QuarantineOptIn("quarantined"); err != nil {
+		t.Fatalf("failed to opt in: %v", err)
+	}
+
+	h, err := b.Listing("/r/quarantined/new", "")
+	if err != nil {
+		t.Fatalf("read after opt in failed: %v", err)
+	}
+	if len(h.Posts) != 1 {
+		t.Errorf("wanted 1 post after opt in; got %d", len(h.Posts))
+	}
+}
+
+func TestForbiddenError(t *testing.T) {
+	for i, test := range []struct {
+		body string
+		err  error
+	}{
+		{"", PermissionDeniedErr},
+		{"<html></html>", PermissionDeniedErr},
+		{`{"reason": "private", "message": "Forbidden", "error": 403}`, PermissionDeniedErr},
+	} {
+		if err := forbiddenError(jsonCodec{}, strings.NewReader(test.body)); err != test.err {
+			t.Errorf("wrong error on %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitCode is the error code Reddit puts in the json errors envelope of
+// write endpoints when an account is doing something too often.
+const rateLimitCode = "RATELIMIT"
+
+// defaultRateLimitWait is the wait assumed when Reddit's rate limit message
+// cannot be understood.
+const defaultRateLimitWait = time.Minute
+
+// rateLimitWait matches the durations in Reddit's rate limit messages, e.g.
+// "you are doing that too much. try again in 9 minutes."
+var rateLimitWait = regexp.MustCompile(
+	`(\d+)\s*(millisecond|ms|second|sec|minute|min|hour)s?\b`,
+)
+
+var rateLimitUnits = map[string]time.Duration{
+	"millisecond": time.Millisecond,
+	"ms":          time.Millisecond,
+	"second":      time.Second,
+	"sec":         time.Second,
+	"minute":      time.Minute,
+	"min":         time.Minute,
+	"hour":        time.Hour,
+}
+
+// RateLimitScope is whose limit Reddit enforced when it rate limited a
+// request, for pools of accounts to route around.
+type RateLimitScope int
+
+const (
+	// UnknownRateLimit is a rate limit Reddit did not say the scope of.
+	UnknownRateLimit RateLimitScope = iota
+	// AppRateLimited is the limit on the requests an OAuth2 app makes in
+	// each period, which Reddit reports in its rate limit headers. Other
+	// accounts authorized through the same app may share it.
+	AppRateLimited
+	// UserRateLimited is the limit on how often an account writes, such as
+	// submitting or commenting. Other accounts are not held by it.
+	UserRateLimited
+)
+
+// userRateLimitMarks are phrases of the messages Reddit explains limits on
+// the account's writes with.
+var userRateLimitMarks = []string{
+	"you are doing that too much",
+	"doing that a lot",
+	"take a break",
+}
+
+// RateLimitScopeOf returns the scope of a rate limit error, and false if err
+// is not a rate limit. RateLimitErr is of an UnknownRateLimit.
+func RateLimitScopeOf(err error) (RateLimitScope, bool) {
+	if r, ok := err.(*RateLimitError); ok {
+		return r.Scope, true
+	}
+
+	switch err {
+	case AppRateLimitErr:
+		return AppRateLimited, true
+	case RateLimitErr:
+		return UnknownRateLimit, true
+	}
+
+	return UnknownRateLimit, false
+}
+
+// rateLimited returns whether err is a 429 from Reddit.
+func rateLimited(err error) bool {
+	return err == RateLimitErr || err == AppRateLimitErr
+}
+
+// tooManyRequestsError returns the error for a 429 response: AppRateLimitErr
+// if its rate limit headers say the app's requests are spent, and
+// RateLimitErr otherwise.
+func tooManyRequestsError(resp *http.Response) error {
+	remaining, err := strconv.ParseFloat(resp.Header.Get(remainingHeader), 64)
+	if err == nil && remaining < 1 {
+		return AppRateLimitErr
+	}
+
+	return RateLimitErr
+}
+
+// RateLimitError is returned when Reddit refuses a write because the account
+// has made too many of them recently. Unlike RateLimitErr, this does not come
+// from the HTTP status; Reddit reports it in the response body.
+type RateLimitError struct {
+	// Message is Reddit's explanation, which includes the wait.
+	Message string
+	// Wait is how long Reddit asked to wait before trying again. It is a
+	// best effort reading of Message and defaults to a minute if Message
+	// does not say.
+	Wait time.Duration
+	// Scope is UserRateLimited if Message is one Reddit explains limits on
+	// the account with, and UnknownRateLimit otherwise.
+	Scope RateLimitScope
+}
+
+func (r *RateLimitError) Error() string {
+	return fmt.Sprintf("Reddit is rate limiting writes (wait %v): %s", r.Wait, r.Message)
+}
+
+// newRateLimitError builds a RateLimitError from Reddit's message by summing
+// all of the durations in it ("1 minute and 30 seconds").
+func newRateLimitError(message string) *RateLimitError {
+	wait := time.Duration(0)
+	for _, match := range rateLimitWait.FindAllStringSubmatch(
+		strings.ToLower(message), -1,
+	) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		wait += time.Duration(n) * rateLimitUnits[match[2]]
+	}
+
+	if wait == 0 {
+		wait = defaultRateLimitWait
+	}
+
+	scope := UnknownRateLimit
+	for _, mark := range userRateLimitMarks {
+		if strings.Contains(strings.ToLower(message), mark) {
+			scope = UserRateLimited
+		}
+	}
+
+	return &RateLimitError{Message: message, Wait: wait, Scope: scope}
+}
+
+// RateLimits are the minimum times between requests to classes of endpoints
+// which Reddit limits more strictly than others, kept on top of the overall
+// rate. Each class is paced on its own, so a burst of searches waits on the
+// search limit without holding up other requests. A class whose limit is zero
+// or negative is paced only by the overall rate.
+type RateLimits struct {
+	// Search is the minimum time between searches.
+	Search time.Duration
+	// Submit is the minimum time between posts, comments and messages.
+	Submit time.Duration
+}
+
+// DefaultRateLimits returns limits of 2 seconds between searches and between
+// submissions, which keep bursts of them within what Reddit allows most
+// accounts.
+func DefaultRateLimits() RateLimits {
+	return RateLimits{Search: defaultSearchRate, Submit: defaultSubmitRate}
+}
+
+const (
+	// searchClass is the rate class of search endpoints.
+	searchClass = "search"
+	// submitClass is the rate class of endpoints which make posts,
+	// comments or messages.
+	submitClass = "submit"
+
+	defaultSearchRate = 2 * time.Second
+	defaultSubmitRate = 2 * time.Second
+)
+
+// submitPaths are the endpoints in the submit rate class.
+var submitPaths = map[string]bool{
+	"/api/submit":  true,
+	"/api/comment": true,
+	"/api/compose": true,
+}
+
+// rateClass returns the rate class of the endpoint at path, or "" if it is
+// only paced by the overall rate.
+func rateClass(path string) string {
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".json")
+	switch {
+	case submitPaths[path]:
+		return submitClass
+	case path == "/search",
+		strings.HasSuffix(path, "/search"),
+		strings.HasPrefix(path, "/api/search_"):
+		return searchClass
+	}
+
+	return ""
+}
+
+// rateBuckets returns the pacing of each rate class under the limits.
+func (l RateLimits) rateBuckets() map[string]*rateBucket {
+	buckets := map[string]*rateBucket{}
+	for class, rate := range map[string]time.Duration{
+		searchClass: l.Search,
+		submitClass: l.Submit,
+	} {
+		if rate > 0 {
+			buckets[class] = &rateBucket{rate: rate}
+		}
+	}
+	return buckets
+}
+
+// rateBucket paces the requests of one rate class with no bursting.
+type rateBucket struct {
+	rate time.Duration
+	last time.Time
+	mu   sync.Mutex
+}
+
+// block waits until the bucket's rate has passed on the clock since its last
+// request.
+func (b *rateBucket) block(clock Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now := clock.Now(); now.Sub(b.last) < b.rate {
+		<-clock.After(b.last.Add(b.rate).Sub(now))
+	}
+	b.last = clock.Now()
+}
+
+const (
+	// remainingHeader is how many requests Reddit will take before the
+	// current rate limit period resets.
+	remainingHeader = "X-Ratelimit-Remaining"
+	// resetHeader is how many seconds are left in the current period.
+	resetHeader = "X-Ratelimit-Reset"
+)
+
+// quota tracks the requests Reddit reports a client has left in the rate limit
+// headers of its responses, so requests can wait for the quota to reset once
+// it is spent instead of being refused.
+type quota struct {
+	mu sync.Mutex
+	// reset is when the quota resets, if the last response said it was
+	// spent.
+	reset time.Time
+}
+
+// observe records the quota reported by a response, as of now. Responses
+// without the headers leave it as it was.
+func (q *quota) observe(resp *http.Response, now time.Time) {
+	remaining, err := strconv.ParseFloat(resp.Header.Get(remainingHeader), 64)
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseFloat(resp.Header.Get(resetHeader), 64)
+	if err != nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset = time.Time{}
+	if remaining < 1 {
+		q.reset = now.Add(time.Duration(reset * float64(time.Second)))
+	}
+}
+
+// wait waits until the quota resets if it is spent, or until ctx is done.
+func (q *quota) wait(ctx context.Context, clock Clock) error {
+	q.mu.Lock()
+	reset := q.reset
+	q.mu.Unlock()
+
+	wait := reset.Sub(clock.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(wait):
+		return nil
+	}
+}
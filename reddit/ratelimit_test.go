@@ -0,0 +1,171 @@
+package reddit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimitError(t *testing.T) {
+	for i, test := range []struct {
+		message string
+		wait    time.Duration
+	}{
+		{"you are doing that too much. try again in 9 minutes.", 9 * time.Minute},
+		{"you are doing that too much. try again in 1 minute.", time.Minute},
+		{"you are doing that too much. try again in 40 seconds.", 40 * time.Second},
+		{"Looks like you've been doing that a lot. Take a break for 4 minutes before trying again.", 4 * time.Minute},
+		{"try again in 2 minutes and 30 seconds", 2*time.Minute + 30*time.Second},
+		{"try again in 1 hour.", time.Hour},
+		{"you are doing that too much.", defaultRateLimitWait},
+		{"", defaultRateLimitWait},
+	} {
+		err := newRateLimitError(test.message)
+		if err.Wait != test.wait {
+			t.Errorf("wrong wait on %d; got %v, wanted %v", i, err.Wait, test.wait)
+		}
+		if err.Message != test.message {
+			t.Errorf("wrong message on %d: %s", i, err.Message)
+		}
+	}
+}
+
+func TestRateLimitScope(t *testing.T) {
+	p := newParser()
+	for i, test := range []struct {
+		body  string
+		scope RateLimitScope
+	}{
+		{`{"json": {"errors": [["RATELIMIT", "you are doing that too much. try again in 9 minutes.", "ratelimit"]]}}`, UserRateLimited},
+		{`{"json": {"errors": [["RATELIMIT", "Looks like you've been doing that a lot. Take a break for 4 minutes before trying again.", "ratelimit"]]}}`, UserRateLimited},
+		{`{"json": {"errors": [["RATELIMIT", "try again in 2 minutes", "ratelimit"]]}}`, UnknownRateLimit},
+	} {
+		_, err := p.parse_submitted([]byte(test.body))
+		if scope, ok := RateLimitScopeOf(err); !ok || scope != test.scope {
+			t.Errorf("got scope %v, %v on %d; wanted %v", scope, ok, i, test.scope)
+		}
+	}
+
+	for i, test := range []struct {
+		headers map[string]string
+		err     error
+		scope   RateLimitScope
+	}{
+		{map[string]string{"X-Ratelimit-Remaining": "0.0", "X-Ratelimit-Reset": "30"}, AppRateLimitErr, AppRateLimited},
+		{map[string]string{"X-Ratelimit-Remaining": "412.0", "X-Ratelimit-Reset": "30"}, RateLimitErr, UnknownRateLimit},
+		{nil, RateLimitErr, UnknownRateLimit},
+	} {
+		serv := httptest.NewServer(
+			http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					for key, value := range test.headers {
+						w.Header().Set(key, value)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					w.Write([]byte(`{"message": "Too Many Requests", "error": 429}`))
+				},
+			),
+		)
+
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		_, err = (&baseClient{cli: &http.Client{}}).Do(req)
+		serv.Close()
+		if err != test.err {
+			t.Errorf("got %v on %d; wanted %v", err, i, test.err)
+		}
+		if scope, ok := RateLimitScopeOf(err); !ok || scope != test.scope {
+			t.Errorf("got scope %v, %v on %d; wanted %v", scope, ok, i, test.scope)
+		}
+	}
+
+	if _, ok := RateLimitScopeOf(BusyErr); ok {
+		t.Errorf("wanted BusyErr not to be a rate limit")
+	}
+}
+
+func TestWaitOnExhaustion(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests++
+				spent := requests == 1
+				mu.Unlock()
+
+				if spent {
+					w.Header().Set("X-Ratelimit-Remaining", "0.0")
+				} else {
+					w.Header().Set("X-Ratelimit-Remaining", "599.0")
+				}
+				w.Header().Set("X-Ratelimit-Reset", "30")
+				w.Write([]byte("{}"))
+			},
+		),
+	)
+	defer serv.Close()
+
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	c := &baseClient{cli: &http.Client{}, clock: clock, quota: newQuota(true)}
+	do := func(ctx context.Context) error {
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+		_, err = c.Do(req.WithContext(ctx))
+		return err
+	}
+
+	if err := do(context.Background()); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- do(context.Background()) }()
+	waitForWaiters(t, clock, 1)
+
+	mu.Lock()
+	if requests != 1 {
+		t.Errorf("sent %d requests while the quota was spent; wanted 1", requests)
+	}
+	mu.Unlock()
+
+	clock.Advance(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("request after the reset failed: %v", err)
+	}
+
+	if err := do(context.Background()); err != nil {
+		t.Errorf("request with quota left failed: %v", err)
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("waited with quota left")
+	}
+}
+
+func TestWaitOnExhaustionContext(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	q := newQuota(true)
+	q.observe(&http.Response{Header: http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {"30"},
+	}}, clock.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := q.wait(ctx, clock); err != context.Canceled {
+		t.Errorf("wanted context.Canceled; got %v", err)
+	}
+
+	if newQuota(false) != nil {
+		t.Errorf("made a quota for a client which does not wait")
+	}
+}
@@ -1,6 +1,10 @@
 package reddit
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
@@ -15,7 +19,20 @@ var (
 		false: "http",
 	}
 	formEncoding = map[string][]string{
-		"content-type": {"application/x-www-form-urlencoded"},
+		"Content-Type": {"application/x-www-form-urlencoded"},
+	}
+	jsonEncoding = map[string][]string{
+		"Content-Type": {"application/json"},
+	}
+	// readOnlyHosts are Reddit's hosts which serve pages and listings but do
+	// not take writes from OAuth apps.
+	readOnlyHosts = map[string]bool{
+		"reddit.com":     true,
+		"www.reddit.com": true,
+		"old.reddit.com": true,
+		"new.reddit.com": true,
+		"i.reddit.com":   true,
+		"m.reddit.com":   true,
 	}
 )
 
@@ -26,18 +43,47 @@ type reaperConfig struct {
 	reapSuffix string
 	tls        bool
 	rate       time.Duration
+	limits     RateLimits
+	clock      Clock
+	rewrite    func(*url.URL) *url.URL
+	// trustedHosts are the hosts besides hostname which Requests with a
+	// Host send the client's credentials to.
+	trustedHosts []string
+	// codec encodes the json bodies the reaper's callers send and decodes
+	// the responses they parse. encoding/json is used if it is nil.
+	codec Codec
 }
 
 // reaper is a high level api for Reddit HTTP requests.
+//
+// Paths given to a reaper are the full paths of endpoints on its host, such as
+// "/api/comment" or "/r/golang/new"; no prefix is added to them, so endpoints
+// inside and outside of /api are reached the same way.
 type reaper interface {
 	// reap executes a GET request to Reddit and returns the elements from
 	// the endpoint.
 	reap(path string, values map[string]string) (Harvest, error)
+	// raw_reap executes a GET request to Reddit and returns the response
+	// body, for endpoints which do not return listings.
+	raw_reap(path string, values map[string]string) ([]byte, error)
 	// sow executes a POST request to Reddit.
 	sow(path string, values map[string]string) error
 	// get_sow executes a POST request to Reddit
 	// and returns the response, usually the posted item
 	get_sow(path string, values map[string]string) (Submission, error)
+	// raw_sow executes a POST request to Reddit and returns the response
+	// body.
+	raw_sow(path string, values map[string]string) ([]byte, error)
+	// send executes a GET or POST request to Reddit, at the request's Host
+	// if it has one, and returns the response body.
+	send(req Request) ([]byte, error)
+	// decode executes a GET or POST request to Reddit, at the request's
+	// Host if it has one, and decodes the json response into dst as it is
+	// read.
+	decode(req Request, dst interface{}) error
+	// bodyCodec returns the Codec the json bodies of requests are encoded
+	// and Reddit's responses are decoded with.
+	bodyCodec() Codec
 }
 
 type reaperImpl struct {
@@ -49,68 +95,75 @@ type reaperImpl struct {
 	rate       time.Duration
 	last       time.Time
 	mu         *sync.Mutex
+	// buckets pace the rate classes of endpoints, on top of rate.
+	buckets map[string]*rateBucket
+	// clock paces requests. It is the system clock if nil.
+	clock Clock
+	// rewrite, if set, rewrites the url of each request before it is sent.
+	rewrite func(*url.URL) *url.URL
+	// trustedHosts are the hosts besides hostname which Requests with a
+	// Host send the client's credentials to, in lower case.
+	trustedHosts map[string]bool
+	// codec decodes responses. encoding/json is used if it is nil.
+	codec Codec
 }
 
 func newReaper(c reaperConfig) reaper {
 	return &reaperImpl{
-		cli:        c.client,
-		parser:     c.parser,
-		hostname:   c.hostname,
-		reapSuffix: c.reapSuffix,
-		scheme:     scheme[c.tls],
-		rate:       c.rate,
-		mu:         &sync.Mutex{},
+		cli:          c.client,
+		parser:       c.parser,
+		hostname:     c.hostname,
+		reapSuffix:   c.reapSuffix,
+		scheme:       scheme[c.tls],
+		rate:         c.rate,
+		mu:           &sync.Mutex{},
+		buckets:      c.limits.rateBuckets(),
+		clock:        c.clock,
+		rewrite:      c.rewrite,
+		trustedHosts: hostSet(c.trustedHosts),
+		codec:        c.codec,
+	}
+}
+
+func (r *reaperImpl) bodyCodec() Codec {
+	return codecOrJSON(r.codec)
+}
+
+// hostSet returns the set of the hosts, in lower case.
+func hostSet(hosts []string) map[string]bool {
+	set := map[string]bool{}
+	for _, host := range hosts {
+		set[strings.ToLower(host)] = true
 	}
+	return set
 }
 
 func (r *reaperImpl) reap(path string, values map[string]string) (Harvest, error) {
-	r.rateBlock()
-	resp, err := r.cli.Do(
-		&http.Request{
-			Method: "GET",
-			URL:    r.url(r.path(path, r.reapSuffix), values),
-			Host:   r.hostname,
-		},
-	)
+	resp, err := r.raw_reap(path, values)
 	if err != nil {
 		return Harvest{}, err
 	}
 
-	comments, posts, messages, mores, err := r.parser.parse(resp)
-	return Harvest{
-		Comments: comments,
-		Posts:    posts,
-		Messages: messages,
-		Mores:    mores,
-	}, err
+	return r.parser.parse(resp)
+}
+
+func (r *reaperImpl) raw_reap(path string, values map[string]string) ([]byte, error) {
+	r.rateBlock(path)
+	return r.cli.Do(r.rewritten(r.get(path, values)))
 }
 
 func (r *reaperImpl) sow(path string, values map[string]string) error {
-	r.rateBlock()
-	_, err := r.cli.Do(
-		&http.Request{
-			Method: "POST",
-			Header: formEncoding,
-			Host:   r.hostname,
-			URL:    r.url(path, values),
-		},
-	)
+	resp, err := r.raw_sow(path, values)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return archivedError(r.bodyCodec(), resp)
 }
 
 func (r *reaperImpl) get_sow(path string, values map[string]string) (Submission, error) {
-	r.rateBlock()
 	values["api_type"] = "json"
-	resp, err := r.cli.Do(
-		&http.Request{
-			Method: "POST",
-			Header: formEncoding,
-			Host:   r.hostname,
-			URL:    r.url(path, values),
-		},
-	)
-
+	resp, err := r.raw_sow(path, values)
 	if err != nil {
 		return Submission{}, err
 	}
@@ -118,14 +171,149 @@ func (r *reaperImpl) get_sow(path string, values map[string]string) (Submission,
 	return r.parser.parse_submitted(resp)
 }
 
-func (r *reaperImpl) rateBlock() {
+// raw_sow sends the form values in the query of the POST rather than in a
+// body, so a request which fails can be sent again as it is.
+func (r *reaperImpl) raw_sow(path string, values map[string]string) ([]byte, error) {
+	r.rateBlock(path)
+	return r.cli.Do(r.rewritten(r.post(path, values)))
+}
+
+func (r *reaperImpl) send(req Request) ([]byte, error) {
+	httpReq, err := r.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.rateBlock(req.Path)
+	return r.cli.Do(r.rewritten(httpReq))
+}
+
+func (r *reaperImpl) decode(req Request, dst interface{}) error {
+	httpReq, err := r.request(req)
+	if err != nil {
+		return err
+	}
+
+	r.rateBlock(req.Path)
+	return r.cli.DoStream(r.rewritten(httpReq), dst)
+}
+
+// request returns the http request for a Request, sent to its Host instead of
+// the reaper's if it has one. Requests to hosts the reaper does not trust are
+// sent without the client's credentials.
+func (r *reaperImpl) request(req Request) (*http.Request, error) {
+	var httpReq *http.Request
+	switch req.Method {
+	case "GET":
+		httpReq = r.get(req.Path, req.Values)
+	case "POST":
+		httpReq = r.post(req.Path, req.Values)
+	case "PATCH":
+		httpReq = r.post(req.Path, req.Values)
+		httpReq.Method = "PATCH"
+	default:
+		return nil, fmt.Errorf("unsupported request method %q", req.Method)
+	}
+
+	if req.JSON != nil {
+		if req.Method == "GET" {
+			return nil, fmt.Errorf("json bodies are not sent with GETs")
+		}
+		withJSON(httpReq, req.JSON)
+	}
+
+	if req.Context != nil {
+		httpReq = httpReq.WithContext(req.Context)
+	}
+
+	if req.TraceID != "" {
+		httpReq = httpReq.WithContext(WithTraceID(httpReq.Context(), req.TraceID))
+	}
+
+	if req.Host == "" || req.Host == r.hostname {
+		return httpReq, nil
+	}
+
+	if strings.ContainsAny(req.Host, "/?#@") {
+		return nil, fmt.Errorf("invalid request host %q", req.Host)
+	}
+
+	if req.Method != "GET" && readOnlyHosts[strings.ToLower(req.Host)] {
+		return nil, fmt.Errorf(
+			"%s does not take writes; send them to %s", req.Host, r.hostname,
+		)
+	}
+
+	if !r.trustedHosts[strings.ToLower(req.Host)] {
+		httpReq = httpReq.WithContext(withoutCredentials(httpReq.Context()))
+	}
+
+	httpReq.Host = req.Host
+	httpReq.URL.Host = req.Host
+	return httpReq, nil
+}
+
+// rewritten returns the request with its url rewritten by the reaper's
+// rewrite, if it has one. The request is sent to the host of the new url.
+func (r *reaperImpl) rewritten(req *http.Request) *http.Request {
+	if r.rewrite == nil {
+		return req
+	}
+
+	u := *req.URL
+	rewritten := r.rewrite(&u)
+	if rewritten == nil {
+		return req
+	}
+
+	req.URL = rewritten
+	req.Host = rewritten.Host
+	return req
+}
+
+// withJSON makes a request send the json body, which can be sent again if the
+// request is retried.
+func withJSON(req *http.Request, body []byte) {
+	req.Header = jsonEncoding
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+}
+
+func (r *reaperImpl) get(path string, values map[string]string) *http.Request {
+	return &http.Request{
+		Method: "GET",
+		URL:    r.url(r.path(path, r.reapSuffix), values),
+		Host:   r.hostname,
+	}
+}
+
+func (r *reaperImpl) post(path string, values map[string]string) *http.Request {
+	return &http.Request{
+		Method: "POST",
+		Header: formEncoding,
+		Host:   r.hostname,
+		URL:    r.url(path, values),
+	}
+}
+
+// rateBlock waits until a request to the endpoint at path may be made, first
+// on the endpoint's rate class and then on the overall rate.
+func (r *reaperImpl) rateBlock(path string) {
+	clock := clockOrReal(r.clock)
+	if bucket, ok := r.buckets[rateClass(path)]; ok {
+		bucket.block(clock)
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if time.Since(r.last) < r.rate {
-		<-time.After(r.last.Add(r.rate).Sub(time.Now()))
+	if now := clock.Now(); now.Sub(r.last) < r.rate {
+		<-clock.After(r.last.Add(r.rate).Sub(now))
 	}
-	r.last = time.Now()
+	r.last = clock.Now()
 }
 
 func (r *reaperImpl) url(path string, values map[string]string) *url.URL {
@@ -25,11 +25,21 @@ func TestNew(t *testing.T) {
 		hostname: "com",
 		scheme:   "https",
 		mu:       &sync.Mutex{},
+		buckets:  map[string]*rateBucket{},
 	}
 
 	if diff := pretty.Compare(newReaper(cfg), expected); diff != "" {
 		t.Errorf("reaper construction incorrect; diff: %s", diff)
 	}
+
+	cfg.limits = DefaultRateLimits()
+	expected.buckets = map[string]*rateBucket{
+		searchClass: &rateBucket{rate: defaultSearchRate},
+		submitClass: &rateBucket{rate: defaultSubmitRate},
+	}
+	if diff := pretty.Compare(newReaper(cfg), expected); diff != "" {
+		t.Errorf("reaper construction with default limits incorrect; diff: %s", diff)
+	}
 }
 
 func TestReap(t *testing.T) {
@@ -169,6 +179,137 @@ func TestSow(t *testing.T) {
 	}
 }
 
+func TestSendHost(t *testing.T) {
+	for i, test := range []struct {
+		req         Request
+		correct     http.Request
+		credentials bool
+	}{
+		{Request{Method: "GET", Path: "/r/golang", Host: "proxy.example:8080"}, http.Request{
+			Method: "GET",
+			Host:   "proxy.example:8080",
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "proxy.example:8080",
+				Path:   "/r/golang.json",
+			},
+		}, false},
+		{Request{Method: "POST", Path: "/api/hide", Host: "oauth.reddit.com"}, http.Request{
+			Method: "POST",
+			Header: formEncoding,
+			Host:   "oauth.reddit.com",
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "oauth.reddit.com",
+				Path:   "/api/hide",
+			},
+		}, true},
+		{Request{Method: "GET", Path: "/r/golang", Host: "Trusted.example"}, http.Request{
+			Method: "GET",
+			Host:   "Trusted.example",
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "Trusted.example",
+				Path:   "/r/golang.json",
+			},
+		}, true},
+	} {
+		c := &mockClient{}
+		r := &reaperImpl{
+			cli:          c,
+			parser:       &mockParser{},
+			hostname:     "oauth.reddit.com",
+			reapSuffix:   ".json",
+			scheme:       "https",
+			mu:           &sync.Mutex{},
+			trustedHosts: hostSet([]string{"trusted.example"}),
+		}
+
+		if _, err := r.send(test.req); err != nil {
+			t.Errorf("Error sending input %d: %v", i, err)
+		}
+
+		if got := sendsCredentials(c.request.Context()); got != test.credentials {
+			t.Errorf("request %d sends credentials: %t; wanted %t", i, got, test.credentials)
+		}
+
+		// The context which marks the request is checked above.
+		want := &test.correct
+		if !test.credentials {
+			want = want.WithContext(c.request.Context())
+		}
+		if diff := pretty.Compare(c.request, want); diff != "" {
+			t.Errorf("request %d incorrect; diff: %s", i, diff)
+		}
+	}
+}
+
+func TestSendHostRefused(t *testing.T) {
+	r := &reaperImpl{
+		cli:      &mockClient{},
+		parser:   &mockParser{},
+		hostname: "oauth.reddit.com",
+		scheme:   "https",
+		mu:       &sync.Mutex{},
+	}
+
+	for _, req := range []Request{
+		Request{Method: "POST", Path: "/api/hide", Host: "www.reddit.com"},
+		Request{Method: "POST", Path: "/api/hide", Host: "I.Reddit.com"},
+		Request{Method: "GET", Path: "/r/golang", Host: "evil.example/path"},
+	} {
+		if _, err := r.send(req); err == nil {
+			t.Errorf("wanted error sending %s to %s", req.Method, req.Host)
+		}
+	}
+
+	if _, err := r.send(
+		Request{Method: "GET", Path: "/r/golang", Host: "www.reddit.com"},
+	); err != nil {
+		t.Errorf("wanted reads from www.reddit.com; got %v", err)
+	}
+}
+
+func TestSendPatch(t *testing.T) {
+	c := &mockClient{}
+	r := &reaperImpl{
+		cli:      c,
+		parser:   &mockParser{},
+		hostname: "oauth.reddit.com",
+		scheme:   "https",
+		mu:       &sync.Mutex{},
+	}
+
+	if _, err := r.send(Request{
+		Method: "PATCH",
+		Path:   "/api/v1/me/prefs",
+		JSON:   []byte(`{"nightmode": true}`),
+	}); err != nil {
+		t.Fatalf("failed to send patch: %v", err)
+	}
+
+	if c.request.Method != "PATCH" || c.request.URL.Path != "/api/v1/me/prefs" ||
+		c.request.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("patch sent incorrectly: %+v", c.request)
+	}
+
+	if _, err := r.send(Request{
+		Method: "PATCH",
+		Path:   "/api/v1/me/prefs",
+		Host:   "www.reddit.com",
+	}); err == nil {
+		t.Errorf("wanted error sending a patch to a read only host")
+	}
+
+	if _, err := r.send(Request{
+		Method: "GET",
+		Path:   "/api/v1/me/prefs",
+		JSON:   []byte(`{}`),
+	}); err == nil {
+		t.Errorf("wanted error sending a json body with a GET")
+	}
+}
+
 func TestRateBlockReap(t *testing.T) {
 	testRateBlock(func(r reaper) { r.reap("", nil) }, t)
 }
@@ -196,3 +337,131 @@ func testRateBlock(f func(reaper), t *testing.T) {
 		t.Errorf("wanted updated timestamp; found same timestamp")
 	}
 }
+
+func TestRateClassesIndependent(t *testing.T) {
+	r := newReaper(
+		reaperConfig{
+			client: &mockClient{},
+			parser: &mockParser{},
+			limits: RateLimits{Search: 50 * time.Millisecond, Submit: -1},
+		},
+	).(*reaperImpl)
+
+	if _, ok := r.buckets[submitClass]; ok {
+		t.Errorf("wanted no submit limit for a negative Submit")
+	}
+	if _, ok := newReaper(reaperConfig{}).(*reaperImpl).buckets[searchClass]; ok {
+		t.Errorf("wanted no search limit unless one is set")
+	}
+
+	r.raw_reap("/r/golang/search", nil)
+
+	start := time.Now()
+	r.raw_reap("/r/golang/new", nil)
+	r.sow("/api/comment", nil)
+	if block := time.Since(start); block >= 25*time.Millisecond {
+		t.Errorf("reads waited %v on the search limit", block)
+	}
+
+	r.raw_reap("/search", nil)
+	if block := time.Since(start); block < 40*time.Millisecond {
+		t.Errorf("search waited %v; wanted the search limit", block)
+	}
+}
+
+func TestRateClass(t *testing.T) {
+	for path, class := range map[string]string{
+		"/search":                   searchClass,
+		"/r/golang/search.json":     searchClass,
+		"/subreddits/search":        searchClass,
+		"/api/search_reddit_names":  searchClass,
+		"/api/submit":               submitClass,
+		"/api/comment":              submitClass,
+		"/r/golang/new":             "",
+		"/api/info":                 "",
+		"/r/search/comments/abc/go": "",
+	} {
+		if got := rateClass(path); got != class {
+			t.Errorf("%s has class %q; wanted %q", path, got, class)
+		}
+	}
+}
+
+func TestURLRewriter(t *testing.T) {
+	c := &mockClient{response: []byte("{}")}
+	r := &reaperImpl{
+		cli:        c,
+		parser:     &mockParser{},
+		hostname:   "oauth.reddit.com",
+		reapSuffix: ".json",
+		scheme:     "https",
+		mu:         &sync.Mutex{},
+		rewrite: func(u *url.URL) *url.URL {
+			u.Host = "cache.example"
+			u.Path = "/reddit" + u.Path
+			return u
+		},
+	}
+
+	for _, test := range []struct {
+		send func() error
+		url  string
+	}{
+		{func() error {
+			_, err := r.raw_reap("/r/golang", map[string]string{"limit": "5"})
+			return err
+		}, "https://cache.example/reddit/r/golang.json?limit=5"},
+		{func() error {
+			return r.sow("/api/hide", map[string]string{"id": "t3_a"})
+		}, "https://cache.example/reddit/api/hide?id=t3_a"},
+		{func() error {
+			_, err := r.send(Request{Method: "GET", Path: "/api/v1/me", Host: "www.reddit.com"})
+			return err
+		}, "https://cache.example/reddit/api/v1/me.json"},
+		{func() error {
+			return r.decode(Request{Method: "POST", Path: "/api/vote"}, &struct{}{})
+		}, "https://cache.example/reddit/api/vote"},
+	} {
+		if err := test.send(); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if got := c.request.URL.String(); got != test.url {
+			t.Errorf("sent to %s; wanted %s", got, test.url)
+		}
+		if c.request.Host != "cache.example" {
+			t.Errorf("sent with host %s; wanted cache.example", c.request.Host)
+		}
+	}
+
+	r.rewrite = func(*url.URL) *url.URL { return nil }
+	if _, err := r.raw_reap("/r/golang", nil); err != nil ||
+		c.request.URL.String() != "https://oauth.reddit.com/r/golang.json" {
+		t.Errorf("nil rewrite changed the url to %s", c.request.URL)
+	}
+}
+
+func TestSowArchived(t *testing.T) {
+	for _, test := range []struct {
+		resp string
+		err  error
+	}{
+		{`{"json": {"errors": [["TOO_OLD", "that's a piece of history now", "parent"]]}}`, ArchivedErr},
+		{`{"json": {"errors": []}}`, nil},
+		{`{}`, nil},
+		{``, nil},
+		{`{"json": {"errors": [["SUBREDDIT_NOEXIST", "no such subreddit", "sr"]]}}`, nil},
+	} {
+		r := &reaperImpl{
+			cli:      &mockClient{response: []byte(test.resp)},
+			parser:   &mockParser{},
+			hostname: "oauth.reddit.com",
+			scheme:   "https",
+			mu:       &sync.Mutex{},
+		}
+
+		err := r.sow("/api/comment", map[string]string{"thing_id": "t3_old", "text": "hi"})
+		if err != test.err {
+			t.Errorf("[%s] wanted %v; got %v", test.resp, test.err, err)
+		}
+	}
+}
@@ -28,7 +28,8 @@
 // Requests made by this API are rate limited with no bursting. All interfaces
 // exported by this package have goroutine safe implementations, but when shared
 // by many goroutines some calls may block for multiples of the rate limit
-// interval.
+// interval. Searches and submissions can also be paced by their own, stricter
+// limits, which RateLimits configures.
 //
 // This API for accessing feeds from Reddit is low level, built specifically for
 // graw. If you are interested in a simple high level event feed, see graw.
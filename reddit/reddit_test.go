@@ -12,10 +12,11 @@ import (
 // testCase is an expectation for a resulting request from a single method call
 // on a Bot interface.
 type testCase struct {
-	name    string
-	err     error
-	f       func(Bot) error
-	correct http.Request
+	name     string
+	err      error
+	f        func(Bot) error
+	correct  http.Request
+	response []byte
 }
 
 func TestAccount(t *testing.T) {
@@ -144,6 +145,301 @@ func TestAccount(t *testing.T) {
 					Header: formEncoding,
 				},
 			},
+			testCase{
+				name: "GetPostLinkToProfile",
+				f: func(b Bot) error {
+					_, err := b.GetPostLink("u/gopher", "title", "url")
+					return err
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/submit",
+						RawQuery: "api_type=json&kind=link&sr=u_gopher&title=title&url=url",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "PostSelfWithOptions",
+				f: func(b Bot) error {
+					_, err := b.PostSelfWithOptions(
+						"self", "title", "text",
+						SubmitOptions{
+							FlairID:             "id",
+							FlairText:           "flair",
+							NSFW:                true,
+							Spoiler:             true,
+							OC:                  true,
+							DisableInboxReplies: true,
+						},
+					)
+					return err
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/submit",
+						RawQuery: "api_type=json&flair_id=id&flair_text=flair&kind=self&nsfw=true&oc=true&sendreplies=false&spoiler=true&sr=self&text=text&title=title",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "PostSelfRichText",
+				f: func(b Bot) error {
+					_, err := b.PostSelfWithOptions(
+						"self", "title", "text",
+						SubmitOptions{
+							RichText: &RichText{
+								Document: []RichTextNode{
+									RichParagraph(RichSpan("hi")),
+								},
+							},
+						},
+					)
+					return err
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/submit",
+						RawQuery: "api_type=json&kind=self&richtext_json=%7B%22document%22%3A%5B%7B%22e%22%3A%22par%22%2C%22c%22%3A%5B%7B%22e%22%3A%22text%22%2C%22t%22%3A%22hi%22%7D%5D%7D%5D%7D&sr=self&title=title",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "ReplyRichText",
+				f: func(b Bot) error {
+					_, err := b.ReplyRichText(
+						"name",
+						&RichText{
+							Document: []RichTextNode{
+								RichParagraph(RichSpan("hi")),
+							},
+						},
+					)
+					return err
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/comment",
+						RawQuery: "api_type=json&richtext_json=%7B%22document%22%3A%5B%7B%22e%22%3A%22par%22%2C%22c%22%3A%5B%7B%22e%22%3A%22text%22%2C%22t%22%3A%22hi%22%7D%5D%7D%5D%7D&thing_id=name",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "PostLinkWithOptions",
+				f: func(b Bot) error {
+					_, err := b.PostLinkWithOptions(
+						"link", "title", "url",
+						SubmitOptions{NSFW: true},
+					)
+					return err
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/submit",
+						RawQuery: "api_type=json&kind=link&nsfw=true&sr=link&title=title&url=url",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "Me",
+				f: func(b Bot) error {
+					_, err := b.Me()
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/v1/me.json",
+						RawQuery: "raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`{"name": "gopher"}`),
+			},
+			testCase{
+				name: "KarmaBreakdown",
+				f: func(b Bot) error {
+					_, err := b.KarmaBreakdown()
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme: "https",
+						Host:   "reddit.com",
+						Path:   "/api/v1/me/karma.json",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`{"kind": "KarmaList", "data": []}`),
+			},
+			testCase{
+				name: "Multireddits",
+				f: func(b Bot) error {
+					_, err := b.Multireddits()
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme: "https",
+						Host:   "reddit.com",
+						Path:   "/api/multi/mine.json",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`[]`),
+			},
+			testCase{
+				name: "NeedsCaptcha",
+				f: func(b Bot) error {
+					_, err := b.NeedsCaptcha()
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme: "https",
+						Host:   "reddit.com",
+						Path:   "/api/needs_captcha.json",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`false`),
+			},
+			testCase{
+				name: "GiveAward",
+				f: func(b Bot) error {
+					return b.GiveAward("t3_abc", "gid_1")
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/v2/gold/gild",
+						RawQuery: "gild_type=gid_1&thing_id=t3_abc",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "SetSendReplies",
+				f: func(b Bot) error {
+					return b.SetSendReplies("t1_abc", false)
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/sendreplies",
+						RawQuery: "id=t1_abc&state=false",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
+			testCase{
+				name: "SetSticky",
+				f: func(b Bot) error {
+					return b.SetSticky("t3_abc", 2, true)
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/set_subreddit_sticky",
+						RawQuery: "api_type=json&id=t3_abc&num=2&state=true",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+				response: []byte(`{"json": {"errors": []}}`),
+			},
+			testCase{
+				name: "SetUserFlair",
+				f: func(b Bot) error {
+					return b.SetUserFlair("sub", "user", "text", "class")
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/r/sub/api/flair",
+						RawQuery: "api_type=json&css_class=class&name=user&text=text",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+				response: []byte(`{"json": {"errors": []}}`),
+			},
+			testCase{
+				name: "DeleteUserFlair",
+				f: func(b Bot) error {
+					return b.DeleteUserFlair("sub", "user")
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/r/sub/api/deleteflair",
+						RawQuery: "api_type=json&name=user",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+				response: []byte(`{"json": {"errors": []}}`),
+			},
+			testCase{
+				name: "UserFlair",
+				f: func(b Bot) error {
+					_, err := b.UserFlair("sub", "user")
+					return err
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/r/sub/api/flairselector",
+						RawQuery: "name=user",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+				response: []byte(`{"current": {}}`),
+			},
 		}, t,
 	)
 }
@@ -193,6 +489,205 @@ func TestLurker(t *testing.T) {
 					Host: "reddit.com",
 				},
 			},
+			testCase{
+				name: "ContinueThread",
+				err:  ThreadDoesNotExistErr,
+				f: func(b Bot) error {
+					_, err := b.ContinueThread("t3_abc", "t1_ghi")
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/comments/abc/_/ghi.json",
+						RawQuery: "raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+			},
+			testCase{
+				name: "UserTrophies",
+				f: func(b Bot) error {
+					_, err := b.UserTrophies("user")
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme: "https",
+						Host:   "reddit.com",
+						Path:   "/api/v1/user/user/trophies.json",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`{"kind": "TrophyList", "data": {"trophies": []}}`),
+			},
+			testCase{
+				name: "SearchSubredditNames",
+				f: func(b Bot) error {
+					_, err := b.SearchSubredditNames("go", true)
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/search_reddit_names.json",
+						RawQuery: "include_over_18=true&query=go",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`{"names": []}`),
+			},
+			testCase{
+				name: "FrontPage",
+				f: func(b Bot) error {
+					_, _, err := b.FrontPage(nil)
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/.json",
+						RawQuery: "limit=100&raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+			},
+			testCase{
+				name: "Best",
+				f: func(b Bot) error {
+					_, _, err := b.Best(nil)
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/best.json",
+						RawQuery: "limit=100&raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+			},
+			testCase{
+				name: "Multireddit",
+				f: func(b Bot) error {
+					_, err := b.Multireddit("/user/gopher/m/languages")
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/multi/user/gopher/m/languages.json",
+						RawQuery: "",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(multiResponse),
+			},
+			testCase{
+				name: "Sticky",
+				f: func(b Bot) error {
+					_, err := b.Sticky("golang", 1)
+					if _, ok := err.(*NoStickyError); ok {
+						return nil
+					}
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/r/golang/about/sticky.json",
+						RawQuery: "num=1&raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+			},
+			testCase{
+				name: "CommentContext",
+				f: func(b Bot) error {
+					_, _, err := b.CommentContext("t3_abc", "t1_def", 3, "top")
+					return err
+				},
+				err: ThreadDoesNotExistErr,
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/comments/abc/_/def.json",
+						RawQuery: "context=3&raw_json=1&sort=top",
+					},
+					Host: "reddit.com",
+				},
+			},
+			testCase{
+				name: "SubredditAutocomplete",
+				f: func(b Bot) error {
+					_, err := b.SubredditAutocomplete("go", false, true)
+					return err
+				},
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/subreddit_autocomplete_v2.json",
+						RawQuery: "include_over_18=false&include_profiles=true&query=go&raw_json=1&typeahead_active=true",
+					},
+					Host: "reddit.com",
+				},
+				response: []byte(`{"kind": "Listing", "data": {"children": [
+					{"kind": "t5", "data": {"name": "t5_2rc7j", "display_name": "golang", "subscribers": 200000, "over_18": false}}
+				]}}`),
+			},
+			testCase{
+				name: "Post",
+				f: func(b Bot) error {
+					_, err := b.Post("t3_abc")
+					return err
+				},
+				err: ThreadDoesNotExistErr,
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/info.json",
+						RawQuery: "id=t3_abc&raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+			},
+			testCase{
+				name: "Comment",
+				f: func(b Bot) error {
+					_, err := b.Comment("t1_abc")
+					return err
+				},
+				err: CommentDoesNotExistErr,
+				correct: http.Request{
+					Method: "GET",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/info.json",
+						RawQuery: "id=t1_abc&raw_json=1",
+					},
+					Host: "reddit.com",
+				},
+			},
 		}, t,
 	)
 }
@@ -208,11 +703,12 @@ func testRequests(cases []testCase, t *testing.T) {
 		mu:         &sync.Mutex{},
 	}
 	b := &bot{
-		Account: newAccount(r),
+		Account: newAccount(r, accountConfig{}),
 		Lurker:  newLurker(r),
 		Scanner: newScanner(r),
 	}
 	for _, test := range cases {
+		c.response = test.response
 		if err := test.f(b); err != test.err {
 			t.Errorf("[%s] unexpected error: %v", test.name, err)
 		}
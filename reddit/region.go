@@ -0,0 +1,49 @@
+package reddit
+
+import (
+	"fmt"
+)
+
+// geoParams are the listing parameters which localize a listing to a region.
+// Reddit names it "g" on front page listings such as /hot and "geo_filter"
+// elsewhere.
+var geoParams = []string{"g", "geo_filter"}
+
+// regions are the region codes Reddit localizes listings to: GLOBAL, countries,
+// and US states.
+var regions = map[string]bool{}
+
+func init() {
+	for _, code := range append(countryRegions, stateRegions...) {
+		regions[code] = true
+	}
+}
+
+var countryRegions = []string{
+	"GLOBAL", "US", "AR", "AU", "BG", "CA", "CL", "CO", "HR", "CZ", "FI",
+	"FR", "DE", "GR", "HU", "IS", "IN", "IE", "IT", "JP", "MY", "MX",
+	"NZ", "PH", "PL", "PT", "PR", "RO", "RS", "SG", "ES", "SE", "TW",
+	"TH", "TR", "GB",
+}
+
+var stateRegions = []string{
+	"US_WA", "US_DE", "US_DC", "US_WI", "US_WV", "US_HI", "US_FL",
+	"US_WY", "US_NH", "US_NJ", "US_NM", "US_TX", "US_LA", "US_NC",
+	"US_ND", "US_NE", "US_TN", "US_NY", "US_PA", "US_CA", "US_NV",
+	"US_VA", "US_CO", "US_AK", "US_AL", "US_AR", "US_VT", "US_IL",
+	"US_GA", "US_IN", "US_IA", "US_OK", "US_AZ", "US_ID", "US_CT",
+	"US_ME", "US_MD", "US_MA", "US_OH", "US_UT", "US_MO", "US_MN",
+	"US_MI", "US_RI", "US_KS", "US_MT", "US_MS", "US_SC", "US_KY",
+	"US_OR", "US_SD",
+}
+
+// checkRegions returns an error if a listing's parameters localize it to a
+// region Reddit does not know.
+func checkRegions(params map[string]string) error {
+	for _, param := range geoParams {
+		if code, ok := params[param]; ok && !regions[code] {
+			return fmt.Errorf("unknown region %q for %s", code, param)
+		}
+	}
+	return nil
+}
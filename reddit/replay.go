@@ -0,0 +1,268 @@
+package reddit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// redacted replaces secrets in recordings.
+const redacted = "REDACTED"
+
+// secretForms are the form values which are redacted from recorded requests.
+var secretForms = []string{"password", "client_secret", "token"}
+
+// secretFields are the json fields which are redacted from recorded responses.
+var secretFields = []string{"access_token", "refresh_token"}
+
+// interaction is a request and the response Reddit gave it, as recorded to
+// disk.
+type interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Form   string      `json:"form,omitempty"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// key is what replayed requests are matched on.
+func (i *interaction) key() string {
+	return i.Method + " " + i.URL + " " + i.Form
+}
+
+// RecordingTransport is a RoundTripper which makes requests and records them
+// with their responses, for replay by NewReplayTransport.
+type RecordingTransport struct {
+	base http.RoundTripper
+	path string
+
+	mu           sync.Mutex
+	interactions []interaction
+}
+
+// NewRecordingTransport returns a RoundTripper which makes requests with base,
+// or http.DefaultTransport if it is nil, and records each one and its
+// response. The recording is written to the file at path when the transport
+// is closed. Use it as the Transport of the Client in a BotConfig or
+// ScriptConfig.
+//
+// Passwords, client secrets and OAuth2 tokens are redacted from the
+// recording. Other content, such as the bot's messages, is recorded as it
+// is.
+func NewRecordingTransport(base http.RoundTripper, path string) *RecordingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &RecordingTransport{base: base, path: path}
+}
+
+func (t *RecordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := requestBody(r)
+	if err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+
+	send := r
+	if r.Body != nil && r.GetBody == nil {
+		// The request's body was read to record it, so a copy of the
+		// request is sent with what was read.
+		send = r.Clone(r.Context())
+		send.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := t.base.RoundTrip(send)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.interactions = append(t.interactions, interaction{
+		Method: r.Method,
+		URL:    requestURL(r.URL),
+		Form:   bodyForm(body),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   redactBody(respBody),
+	})
+
+	return resp, nil
+}
+
+// Close writes the requests recorded so far to the transport's file. Requests
+// made after Close are still recorded, and written by the next Close.
+func (t *RecordingTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	blob, err := json.MarshalIndent(t.interactions, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.path, blob, 0600)
+}
+
+// replayTransport answers requests with recorded responses.
+type replayTransport struct {
+	mu sync.Mutex
+	// responses are the recorded responses to each request, by key, in
+	// the order they were recorded.
+	responses map[string][]interaction
+}
+
+// NewReplayTransport returns a RoundTripper which answers requests with the
+// responses NewRecordingTransport recorded to the file at path, without
+// network access. Requests are matched on their method, url and form. A
+// request recorded several times is answered with each of its responses in
+// order, and then with the last one again. Requests which were not recorded
+// fail with an UnrecordedRequestError.
+func NewReplayTransport(path string) (http.RoundTripper, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var interactions []interaction
+	if err := json.Unmarshal(blob, &interactions); err != nil {
+		return nil, err
+	}
+
+	t := &replayTransport{responses: map[string][]interaction{}}
+	for _, i := range interactions {
+		t.responses[i.key()] = append(t.responses[i.key()], i)
+	}
+
+	return t, nil
+}
+
+func (t *replayTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := requestBody(r)
+	if r.Body != nil && r.GetBody != nil {
+		// requestBody read a copy, but the request's body must still be
+		// closed.
+		r.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req := interaction{
+		Method: r.Method,
+		URL:    requestURL(r.URL),
+		Form:   bodyForm(body),
+	}
+
+	t.mu.Lock()
+	recorded := t.responses[req.key()]
+	if len(recorded) > 1 {
+		t.responses[req.key()] = recorded[1:]
+	}
+	t.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return nil, &UnrecordedRequestError{Method: req.Method, URL: req.URL}
+	}
+
+	return &http.Response{
+		Status:     http.StatusText(recorded[0].Status),
+		StatusCode: recorded[0].Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     recorded[0].Header,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(recorded[0].Body)),
+		Request:    r,
+	}, nil
+}
+
+// requestURL returns the url of a request, secrets redacted, with its query
+// in a stable order.
+func requestURL(u *url.URL) string {
+	c := *u
+	c.RawQuery = redactForm(u.Query())
+	return c.String()
+}
+
+// requestBody returns the body of a request without changing the request. The
+// body is read from a copy made by GetBody if the request has it; otherwise
+// Body is read and closed.
+func requestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	body := r.Body
+	if r.GetBody != nil {
+		var err error
+		if body, err = r.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	defer body.Close()
+
+	return ioutil.ReadAll(body)
+}
+
+// bodyForm returns the form of a request body, secrets redacted.
+func bodyForm(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return string(body)
+	}
+	return redactForm(form)
+}
+
+func redactForm(form url.Values) string {
+	for _, key := range secretForms {
+		if _, ok := form[key]; ok {
+			form.Set(key, redacted)
+		}
+	}
+	return form.Encode()
+}
+
+// redactBody redacts OAuth2 tokens from a json response body.
+func redactBody(body []byte) string {
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) != nil {
+		return string(body)
+	}
+
+	found := false
+	for _, key := range secretFields {
+		if _, ok := fields[key]; ok {
+			fields[key] = redacted
+			found = true
+		}
+	}
+	if !found {
+		return string(body)
+	}
+
+	blob, err := json.Marshal(fields)
+	if err != nil {
+		return string(body)
+	}
+	return string(blob)
+}
@@ -0,0 +1,165 @@
+package reddit
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc is a RoundTripper which calls itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graw-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.json")
+
+	sent := 0
+	reddit := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent++
+		body := `{"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_abc", "title": "hello"}}
+		]}}`
+		if r.URL.Path == "/api/v1/access_token" {
+			body = `{"access_token": "secret-token", "token_type": "bearer"}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		}, nil
+	})
+
+	recording := NewRecordingTransport(reddit, path)
+	recorder := &http.Client{Transport: recording}
+	recorded, err := NewScriptFromConfig(ScriptConfig{Agent: "agent", Client: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorded.Listing("/r/golang", ""); err != nil {
+		t.Fatalf("error recording: %v", err)
+	}
+
+	tokenResp, err := recorder.PostForm(
+		"https://www.reddit.com/api/v1/access_token",
+		map[string][]string{"grant_type": {"password"}, "password": {"hunter2"}},
+	)
+	if err != nil {
+		t.Fatalf("error recording token request: %v", err)
+	}
+	tokenResp.Body.Close()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("recording was written before the transport was closed")
+	}
+	if err := recording.Close(); err != nil {
+		t.Fatalf("error writing recording: %v", err)
+	}
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(blob), "hunter2") ||
+		strings.Contains(string(blob), "secret-token") {
+		t.Errorf("recording has secrets: %s", blob)
+	}
+
+	replay, err := NewReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := NewScriptFromConfig(
+		ScriptConfig{Agent: "agent", Client: &http.Client{Transport: replay}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		h, err := replayed.Listing("/r/golang", "")
+		if err != nil {
+			t.Fatalf("error replaying: %v", err)
+		}
+		if len(h.Posts) != 1 || h.Posts[0].Title != "hello" {
+			t.Errorf("replayed harvest incorrect: %+v", h)
+		}
+	}
+
+	if sent != 2 {
+		t.Errorf("made %d requests; wanted only the 2 recorded", sent)
+	}
+
+	_, err = replayed.Listing("/r/rust", "")
+	var unrecorded *UnrecordedRequestError
+	if !errors.As(err, &unrecorded) || unrecorded.URL != "https://reddit.com/r/rust.json?before=&limit=100&raw_json=1" {
+		t.Errorf("wanted UnrecordedRequestError; got %v", err)
+	}
+}
+
+func TestRecordingLeavesRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graw-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var sent string
+	reddit := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		sent = string(body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("{}")),
+		}, nil
+	})
+
+	recording := NewRecordingTransport(reddit, filepath.Join(dir, "recording.json"))
+	for _, withGetBody := range []bool{true, false} {
+		req, err := http.NewRequest("POST", "https://oauth.reddit.com/api/hide", strings.NewReader("id=t3_abc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !withGetBody {
+			req.GetBody = nil
+		}
+		body := req.Body
+
+		resp, err := recording.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("error recording: %v", err)
+		}
+		resp.Body.Close()
+
+		if req.Body != body {
+			t.Errorf("recording replaced the request's body")
+		}
+		if sent != "id=t3_abc" {
+			t.Errorf("sent body %q; wanted the request's", sent)
+		}
+	}
+
+	if len(recording.interactions) != 2 || recording.interactions[1].Form != "id=t3_abc" {
+		t.Errorf("recorded %+v", recording.interactions)
+	}
+
+	recording.path = filepath.Join(dir, "missing", "recording.json")
+	if err := recording.Close(); err == nil {
+		t.Errorf("wanted error writing recording to a missing directory")
+	}
+}
@@ -0,0 +1,220 @@
+package reddit
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateSubmission checks a post against a subreddit's post requirements
+// before it is submitted, returning a PostRequirementError for the first
+// requirement it breaks. A post with an empty url is a self post, and body is
+// its text; otherwise body is ignored. Only opts' flair is checked.
+//
+// Reddit's patterns are Python regular expressions. Those which Go cannot
+// compile are not checked, and neither is LinkRepostAge, so a post which
+// passes may still be refused.
+func ValidateSubmission(
+	req PostRequirements,
+	title, body, link string,
+	opts SubmitOptions,
+) error {
+	if err := checkTitle(req, title); err != nil {
+		return err
+	}
+
+	if link == "" {
+		if err := checkBody(req, body); err != nil {
+			return err
+		}
+	} else if err := checkLink(req, link); err != nil {
+		return err
+	}
+
+	if req.FlairRequired && opts.FlairID == "" {
+		return &PostRequirementError{Field: "flair", Reason: "is required"}
+	}
+
+	return nil
+}
+
+// acceptsCrosspost returns whether a subreddit with the requirements takes a
+// crosspost of the post without flair. Crossposts carry only their title, so
+// the requirements of bodies and links do not apply.
+func acceptsCrosspost(req PostRequirements, post *Post) bool {
+	if req.FlairRequired {
+		return false
+	}
+
+	return checkTitle(req, post.Title) == nil
+}
+
+// checkTitle checks the title of a post against the requirements.
+func checkTitle(req PostRequirements, title string) error {
+	return checkText(
+		"title",
+		title,
+		req.TitleMinLength,
+		req.TitleMaxLength,
+		req.TitleRegexes,
+		req.TitleRequiredStrings,
+		req.TitleBlacklistedStrings,
+	)
+}
+
+// checkBody checks the text of a self post against the requirements.
+func checkBody(req PostRequirements, body string) error {
+	switch req.BodyRestrictionPolicy {
+	case "required":
+		if strings.TrimSpace(body) == "" {
+			return &PostRequirementError{Field: "body", Reason: "is required"}
+		}
+	case "notAllowed":
+		if body != "" {
+			return &PostRequirementError{Field: "body", Reason: "is not allowed"}
+		}
+		return nil
+	}
+
+	if body == "" {
+		return nil
+	}
+
+	return checkText(
+		"body",
+		body,
+		req.BodyMinLength,
+		req.BodyMaxLength,
+		req.BodyRegexes,
+		req.BodyRequiredStrings,
+		req.BodyBlacklistedStrings,
+	)
+}
+
+// checkText checks text against the length, pattern and string requirements
+// of a field.
+func checkText(
+	field, text string,
+	min, max int,
+	patterns, required, blacklisted []string,
+) error {
+	length := utf8.RuneCountInString(text)
+	if min > 0 && length < min {
+		return &PostRequirementError{
+			Field:  field,
+			Reason: fmt.Sprintf("must be at least %d characters", min),
+		}
+	}
+	if max > 0 && length > max {
+		return &PostRequirementError{
+			Field:  field,
+			Reason: fmt.Sprintf("must be at most %d characters", max),
+		}
+	}
+
+	if !matchesAny(text, patterns) {
+		return &PostRequirementError{
+			Field: field,
+			Reason: fmt.Sprintf(
+				"must match one of %s", strings.Join(patterns, ", "),
+			),
+		}
+	}
+
+	lower := strings.ToLower(text)
+	if len(required) != 0 && !containsAny(lower, required) {
+		return &PostRequirementError{
+			Field: field,
+			Reason: fmt.Sprintf(
+				"must contain one of %s", strings.Join(required, ", "),
+			),
+		}
+	}
+
+	for _, s := range blacklisted {
+		if s != "" && strings.Contains(lower, strings.ToLower(s)) {
+			return &PostRequirementError{
+				Field:  field,
+				Reason: fmt.Sprintf("must not contain %q", s),
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesAny returns whether text matches any of the patterns which compile.
+// Text matches if there are no such patterns.
+func matchesAny(text string, patterns []string) bool {
+	checked := false
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			continue
+		}
+
+		checked = true
+		if re.MatchString(text) {
+			return true
+		}
+	}
+
+	return !checked
+}
+
+// containsAny returns whether the lowercased text contains any of the
+// strings, ignoring case.
+func containsAny(lower string, strs []string) bool {
+	for _, s := range strs {
+		if strings.Contains(lower, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLink checks the url of a link post against the requirements.
+func checkLink(req PostRequirements, link string) error {
+	u, err := url.Parse(link)
+	if err != nil || u.Hostname() == "" {
+		return &PostRequirementError{Field: "url", Reason: "is not a valid url"}
+	}
+	host := strings.ToLower(u.Hostname())
+
+	switch req.LinkRestrictionPolicy {
+	case "whitelist":
+		if !onDomain(host, req.DomainWhitelist) {
+			return &PostRequirementError{
+				Field: "url",
+				Reason: fmt.Sprintf(
+					"must link to one of %s",
+					strings.Join(req.DomainWhitelist, ", "),
+				),
+			}
+		}
+	case "blacklist":
+		if onDomain(host, req.DomainBlacklist) {
+			return &PostRequirementError{
+				Field:  "url",
+				Reason: "links to a domain the subreddit does not allow",
+			}
+		}
+	}
+
+	return nil
+}
+
+// onDomain returns whether the host is one of the domains or a subdomain of
+// one.
+func onDomain(host string, domains []string) bool {
+	host = strings.TrimPrefix(host, "www.")
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(d), "www.")
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
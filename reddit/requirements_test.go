@@ -0,0 +1,226 @@
+package reddit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostRequirements(t *testing.T) {
+	r := &mockReaper{raw: []byte(`{
+		"title_regexes": ["^\\[(Help|News)\\]"],
+		"title_required_strings": [],
+		"title_blacklisted_strings": ["clickbait"],
+		"title_text_min_length": 10,
+		"title_text_max_length": null,
+		"body_restriction_policy": "required",
+		"body_regexes": [],
+		"body_text_min_length": null,
+		"link_restriction_policy": "whitelist",
+		"domain_whitelist": ["golang.org"],
+		"domain_blacklist": [],
+		"link_repost_age": 30,
+		"is_flair_required": true,
+		"guidelines_text": "Be kind."
+	}`)}
+
+	req, err := newLurker(r).PostRequirements("r/golang")
+	if err != nil {
+		t.Fatalf("failed to fetch post requirements: %v", err)
+	}
+
+	if r.path != "/api/v1/golang/post_requirements" {
+		t.Errorf("fetched post requirements from wrong path: %s", r.path)
+	}
+
+	if len(req.TitleRegexes) != 1 || req.TitleMinLength != 10 ||
+		req.BodyRestrictionPolicy != "required" || !req.FlairRequired ||
+		req.LinkRepostAge != 30 || req.Guidelines != "Be kind." {
+		t.Errorf("post requirements parsed incorrectly: %+v", req)
+	}
+
+	r.err = PermissionDeniedErr
+	if _, err := newLurker(r).PostRequirements("golang"); err != PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr; got %v", err)
+	}
+}
+
+func TestValidateSubmission(t *testing.T) {
+	flaired := SubmitOptions{FlairID: "flair"}
+	for _, test := range []struct {
+		name        string
+		req         PostRequirements
+		title, body string
+		link        string
+		opts        SubmitOptions
+		field       string
+	}{
+		{
+			name:  "no requirements",
+			title: "anything",
+		},
+		{
+			name:  "title matches pattern",
+			req:   PostRequirements{TitleRegexes: []string{`^\[(Help|News)\]`}},
+			title: "[Help] my code does not compile",
+		},
+		{
+			name:  "title matches no pattern",
+			req:   PostRequirements{TitleRegexes: []string{`^\[(Help|News)\]`}},
+			title: "my code does not compile",
+			field: "title",
+		},
+		{
+			name:  "uncompilable patterns are not checked",
+			req:   PostRequirements{TitleRegexes: []string{`(?<=x)y`}},
+			title: "anything",
+		},
+		{
+			name:  "title too short",
+			req:   PostRequirements{TitleMinLength: 10},
+			title: "short",
+			field: "title",
+		},
+		{
+			name:  "title missing required string",
+			req:   PostRequirements{TitleRequiredStrings: []string{"go", "golang"}},
+			title: "Rust is neat",
+			field: "title",
+		},
+		{
+			name:  "title has blacklisted string",
+			req:   PostRequirements{TitleBlacklistedStrings: []string{"clickbait"}},
+			title: "Not ClickBait at all",
+			field: "title",
+		},
+		{
+			name:  "flair required and missing",
+			req:   PostRequirements{FlairRequired: true},
+			title: "anything",
+			field: "flair",
+		},
+		{
+			name:  "flair required and set",
+			req:   PostRequirements{FlairRequired: true},
+			title: "anything",
+			opts:  flaired,
+		},
+		{
+			name:  "body required",
+			req:   PostRequirements{BodyRestrictionPolicy: "required"},
+			title: "anything",
+			body:  " ",
+			field: "body",
+		},
+		{
+			name:  "body not allowed",
+			req:   PostRequirements{BodyRestrictionPolicy: "notAllowed"},
+			title: "anything",
+			body:  "text",
+			field: "body",
+		},
+		{
+			name:  "body ignored for links",
+			req:   PostRequirements{BodyRestrictionPolicy: "required"},
+			title: "anything",
+			link:  "https://golang.org",
+		},
+		{
+			name: "link on whitelisted subdomain",
+			req: PostRequirements{
+				LinkRestrictionPolicy: "whitelist",
+				DomainWhitelist:       []string{"golang.org"},
+			},
+			title: "anything",
+			link:  "https://blog.golang.org/go1.13",
+		},
+		{
+			name: "link off whitelist",
+			req: PostRequirements{
+				LinkRestrictionPolicy: "whitelist",
+				DomainWhitelist:       []string{"golang.org"},
+			},
+			title: "anything",
+			link:  "https://notgolang.org",
+			field: "url",
+		},
+		{
+			name: "link on blacklist",
+			req: PostRequirements{
+				LinkRestrictionPolicy: "blacklist",
+				DomainBlacklist:       []string{"example.com"},
+			},
+			title: "anything",
+			link:  "https://www.example.com/spam",
+			field: "url",
+		},
+	} {
+		err := ValidateSubmission(test.req, test.title, test.body, test.link, test.opts)
+		if test.field == "" {
+			if err != nil {
+				t.Errorf("%s: wanted no error; got %v", test.name, err)
+			}
+			continue
+		}
+
+		reqErr, ok := err.(*PostRequirementError)
+		if !ok || reqErr.Field != test.field {
+			t.Errorf("%s: wanted error for %s; got %v", test.name, test.field, err)
+		}
+	}
+}
+
+// requirementsReaper serves a post from info and the post requirements of
+// subreddits by path, and errors for subreddits it has none for.
+type requirementsReaper struct {
+	mockReaper
+	requirements map[string]string
+	errs         map[string]error
+}
+
+func (r *requirementsReaper) raw_reap(path string, _ map[string]string) ([]byte, error) {
+	if err, ok := r.errs[path]; ok {
+		return nil, err
+	}
+	return []byte(r.requirements[path]), nil
+}
+
+func TestCrosspostTargets(t *testing.T) {
+	post := &Post{
+		Name:            "t3_abc",
+		Title:           "[News] Go 1.14 is released",
+		IsCrosspostable: true,
+	}
+	r := &requirementsReaper{
+		mockReaper: mockReaper{h: Harvest{Posts: []*Post{post}}},
+		requirements: map[string]string{
+			"/api/v1/golang/post_requirements":  `{}`,
+			"/api/v1/news/post_requirements":    `{"title_regexes": ["^\\[News\\]"]}`,
+			"/api/v1/help/post_requirements":    `{"title_regexes": ["^\\[Help\\]"]}`,
+			"/api/v1/flaired/post_requirements": `{"is_flair_required": true}`,
+		},
+		errs: map[string]error{
+			"/api/v1/private/post_requirements": PermissionDeniedErr,
+		},
+	}
+
+	targets, err := newLurker(r).CrosspostTargets(
+		"t3_abc", []string{"golang", "news", "help", "flaired", "private"},
+	)
+	if err != nil {
+		t.Fatalf("failed to find crosspost targets: %v", err)
+	}
+
+	if strings.Join(targets, ",") != "golang,news" {
+		t.Errorf("got targets %v; wanted golang and news", targets)
+	}
+
+	r.errs["/api/v1/golang/post_requirements"] = BusyErr
+	if _, err := newLurker(r).CrosspostTargets("t3_abc", []string{"golang"}); err != BusyErr {
+		t.Errorf("wanted BusyErr; got %v", err)
+	}
+
+	post.IsCrosspostable = false
+	if targets, err := newLurker(r).CrosspostTargets("t3_abc", []string{"news"}); err != nil || len(targets) != 0 {
+		t.Errorf("wanted no targets for a post which is not crosspostable; got %v, %v", targets, err)
+	}
+}
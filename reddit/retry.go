@@ -0,0 +1,168 @@
+package reddit
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Retryer decides whether requests which fail are tried again.
+type Retryer interface {
+	// ShouldRetry is called after each failed attempt at a request, with
+	// the number of attempts made so far, the response if Reddit answered
+	// (its body already closed), and the error the attempt failed with. It
+	// returns whether to try again, and how long to wait first.
+	ShouldRetry(
+		attempt int,
+		req *http.Request,
+		resp *http.Response,
+		err error,
+	) (retry bool, delay time.Duration)
+}
+
+// BackoffRetryer is a Retryer which retries requests that fail for reasons
+// which pass, such as Reddit being busy or a timeout, waiting twice as long
+// before each retry as before the last, less a random part of that wait so
+// clients which failed together do not retry together. Only requests which
+// cannot change anything at Reddit, such as GETs, are retried, so a POST
+// which timed out after Reddit took it is not made twice.
+type BackoffRetryer struct {
+	// MaxAttempts is the most times a request is tried. It is 3 if zero.
+	MaxAttempts int
+	// Base is the wait before the first retry. It is 1 second if zero.
+	Base time.Duration
+	// Max is the longest wait before a retry. It is 30 seconds if zero.
+	Max time.Duration
+	// RetryEmptyBodies retries GETs which Reddit answered successfully but
+	// with an empty body, instead of failing them with EmptyBodyErr.
+	RetryEmptyBodies bool
+	// Budget, if set, caps the retries made across every request which
+	// uses it. Requests which would be retried once it is spent fail with
+	// RetryBudgetExhaustedErr instead.
+	Budget *RetryBudget
+}
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBase     = time.Second
+	defaultRetryMax      = 30 * time.Second
+)
+
+func (b BackoffRetryer) ShouldRetry(
+	attempt int,
+	req *http.Request,
+	_ *http.Response,
+	err error,
+) (bool, time.Duration) {
+	attempts, base, max := b.MaxAttempts, b.Base, b.Max
+	if attempts == 0 {
+		attempts = defaultRetryAttempts
+	}
+	if base == 0 {
+		base = defaultRetryBase
+	}
+	if max == 0 {
+		max = defaultRetryMax
+	}
+
+	passes := transient(err) || (b.RetryEmptyBodies && err == EmptyBodyErr)
+	if attempt >= attempts || !idempotent(req) || !passes {
+		return false, 0
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	return true, delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (b BackoffRetryer) spendRetry() bool {
+	return b.Budget.spend()
+}
+
+// budgetedRetryer is a Retryer whose retries draw on a budget, which the
+// client spends from before each retry the retryer decides on.
+type budgetedRetryer interface {
+	// spendRetry takes a retry from the budget, or returns false if it is
+	// spent.
+	spendRetry() bool
+}
+
+// RetryBudget is a token bucket of retries, shared by the requests of every
+// retryer it is set on, so a long outage at Reddit cannot multiply a bot's
+// load with retries. It holds at most a minute's retries and refills
+// steadily as the minute passes.
+type RetryBudget struct {
+	// Clock, if set, is the clock the budget refills by, in place of the
+	// system clock.
+	Clock Clock
+
+	perMinute float64
+	mu        sync.Mutex
+	tokens    float64
+	last      time.Time
+}
+
+// NewRetryBudget returns a full budget of perMinute retries a minute.
+func NewRetryBudget(perMinute int) *RetryBudget {
+	return &RetryBudget{
+		perMinute: float64(perMinute),
+		tokens:    float64(perMinute),
+	}
+}
+
+// spend takes a retry from the budget, or returns false if it is spent. A nil
+// budget is never spent.
+func (r *RetryBudget) spend() bool {
+	if r == nil {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := clockOrReal(r.Clock).Now()
+	if r.last.IsZero() {
+		r.last = now
+	}
+	r.tokens += now.Sub(r.last).Minutes() * r.perMinute
+	if r.tokens > r.perMinute {
+		r.tokens = r.perMinute
+	}
+	r.last = now
+
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// idempotent returns whether making a request again cannot change anything
+// at Reddit.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// transient returns whether a request which failed with err may succeed if
+// it is made again later.
+func transient(err error) bool {
+	switch err {
+	case BusyErr, RateLimitErr, AppRateLimitErr, GatewayErr, GatewayTimeoutErr:
+		return true
+	}
+
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
@@ -0,0 +1,259 @@
+package reddit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingRetryer retries every failed request up to a number of attempts,
+// counting the attempts it is asked about.
+type countingRetryer struct {
+	attempts int
+	max      int
+}
+
+func (c *countingRetryer) ShouldRetry(
+	attempt int,
+	_ *http.Request,
+	_ *http.Response,
+	_ error,
+) (bool, time.Duration) {
+	c.attempts = attempt
+	return attempt < c.max, time.Millisecond
+}
+
+// flakyServerWhich fails the given number of requests with the code before
+// answering with the body.
+func flakyServerWhich(failures, code int, body string) (*httptest.Server, *int) {
+	requests := 0
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= failures {
+					w.WriteHeader(code)
+					return
+				}
+				w.Write([]byte(body))
+			},
+		),
+	), &requests
+}
+
+func TestRetryer(t *testing.T) {
+	serv, requests := flakyServerWhich(2, http.StatusServiceUnavailable, "ok")
+	defer serv.Close()
+
+	retryer := &countingRetryer{max: 5}
+	c := &baseClient{cli: &http.Client{}, retryer: retryer}
+
+	req, err := http.NewRequest("GET", serv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	body, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if string(body) != "ok" || *requests != 3 || retryer.attempts != 2 {
+		t.Errorf(
+			"got %q after %d requests and %d retry decisions; wanted ok after 3 and 2",
+			body, *requests, retryer.attempts,
+		)
+	}
+}
+
+func TestRetryerGivesUp(t *testing.T) {
+	serv, requests := flakyServerWhich(10, http.StatusBadGateway, "ok")
+	defer serv.Close()
+
+	c := &baseClient{cli: &http.Client{}, retryer: &countingRetryer{max: 3}}
+
+	req, err := http.NewRequest("POST", serv.URL, strings.NewReader("form"))
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	if _, err := c.Do(req); err != GatewayErr {
+		t.Errorf("wanted GatewayErr; got %v", err)
+	}
+
+	if *requests != 3 {
+		t.Errorf("made %d requests; wanted 3", *requests)
+	}
+}
+
+func TestBackoffRetryer(t *testing.T) {
+	get, _ := http.NewRequest("GET", "https://oauth.reddit.com/r/golang", nil)
+	post, _ := http.NewRequest("POST", "https://oauth.reddit.com/api/submit", nil)
+	b := BackoffRetryer{MaxAttempts: 4, Base: time.Second, Max: 3 * time.Second}
+
+	for _, test := range []struct {
+		attempt int
+		req     *http.Request
+		err     error
+		retry   bool
+		max     time.Duration
+	}{
+		{1, get, BusyErr, true, time.Second},
+		{2, get, GatewayTimeoutErr, true, 2 * time.Second},
+		{3, get, RateLimitErr, true, 3 * time.Second},
+		{4, get, BusyErr, false, 0},
+		{1, get, PermissionDeniedErr, false, 0},
+		{1, get, notFoundErr, false, 0},
+		{1, post, BusyErr, false, 0},
+		{1, get, EmptyBodyErr, false, 0},
+	} {
+		retry, delay := b.ShouldRetry(test.attempt, test.req, nil, test.err)
+		if retry != test.retry {
+			t.Errorf(
+				"got retry %v for %s attempt %d with %v; wanted %v",
+				retry, test.req.Method, test.attempt, test.err, test.retry,
+			)
+		} else if delay < test.max/2 || delay > test.max {
+			t.Errorf(
+				"got delay %v for attempt %d; wanted between %v and %v",
+				delay, test.attempt, test.max/2, test.max,
+			)
+		}
+	}
+}
+
+// emptyServerWhich answers the given number of requests with an empty body
+// before answering with the body.
+func emptyServerWhich(empties int, body string) (*httptest.Server, *int) {
+	requests := 0
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= empties {
+					w.Write([]byte(" \n"))
+					return
+				}
+				w.Write([]byte(body))
+			},
+		),
+	), &requests
+}
+
+func TestEmptyBody(t *testing.T) {
+	serv, _ := emptyServerWhich(1, `{"ok": true}`)
+	defer serv.Close()
+
+	c := &baseClient{cli: &http.Client{}}
+	req, err := http.NewRequest("GET", serv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	if _, err := c.Do(req); err != EmptyBodyErr {
+		t.Errorf("wanted EmptyBodyErr; got %v", err)
+	}
+
+	var ok struct{ OK bool }
+	if err := c.DoStream(req, &ok); err != nil || !ok.OK {
+		t.Errorf("wanted the second response to decode; got %v, %v", ok, err)
+	}
+}
+
+func TestRetryEmptyBodies(t *testing.T) {
+	serv, requests := emptyServerWhich(1, `{"ok": true}`)
+	defer serv.Close()
+
+	c := &baseClient{
+		cli: &http.Client{},
+		retryer: BackoffRetryer{
+			Base:             time.Millisecond,
+			RetryEmptyBodies: true,
+		},
+	}
+	req, err := http.NewRequest("GET", serv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare request for test: %v", err)
+	}
+
+	body, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if string(body) != `{"ok": true}` {
+		t.Errorf("got body %q; wanted the second response", body)
+	}
+
+	if *requests != 2 {
+		t.Errorf("made %d requests; wanted 2", *requests)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	serv, requests := flakyServerWhich(1000, http.StatusServiceUnavailable, "ok")
+	defer serv.Close()
+
+	budget := NewRetryBudget(5)
+	c := &baseClient{
+		cli: &http.Client{},
+		retryer: BackoffRetryer{
+			MaxAttempts: 3,
+			Base:        time.Millisecond,
+			Budget:      budget,
+		},
+	}
+
+	errs := map[error]int{}
+	for i := 0; i < 10; i++ {
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		_, err = c.Do(req)
+		errs[err]++
+	}
+
+	// The first two requests are retried twice each before they give up,
+	// the third spends the last retry, and the rest fail on their first
+	// attempt.
+	if *requests != 15 {
+		t.Errorf("made %d requests; wanted 15", *requests)
+	}
+	if errs[BusyErr] != 2 || errs[RetryBudgetExhaustedErr] != 8 {
+		t.Errorf("got errors %v; wanted 2 BusyErr and 8 RetryBudgetExhaustedErr", errs)
+	}
+
+	if budget.spend() {
+		t.Errorf("budget was not spent")
+	}
+}
+
+func TestRetryBudgetRefills(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1570000000, 0))
+	budget := NewRetryBudget(60)
+	budget.Clock = clock
+	for budget.spend() {
+	}
+
+	clock.Advance(2 * time.Second)
+	if !budget.spend() || !budget.spend() {
+		t.Errorf("wanted two seconds to refill two retries")
+	}
+	if budget.spend() {
+		t.Errorf("budget refilled more than it should have")
+	}
+
+	clock.Advance(time.Hour)
+	if budget.spend(); budget.tokens > 59 {
+		t.Errorf("budget refilled past its minute; has %v", budget.tokens)
+	}
+
+	var unlimited *RetryBudget
+	if !unlimited.spend() {
+		t.Errorf("nil budget refused a retry")
+	}
+}
@@ -0,0 +1,173 @@
+package reddit
+
+import (
+	"fmt"
+)
+
+// RichText is a document in Reddit's rich text json format, which can
+// express formatting markdown cannot. Build its nodes with RichParagraph,
+// RichHeading, RichSpan and RichLink, or by hand.
+//
+// https://www.reddit.com/dev/api#POST_api_submit
+type RichText struct {
+	Document []RichTextNode `json:"document"`
+}
+
+// RichTextNode is an element of a rich text document. Which fields are used
+// depends on its Element.
+type RichTextNode struct {
+	// Element is the kind of the node. Block nodes are "par", "h",
+	// "blockquote", "code", "list", "li" and "hr"; inline nodes are "text",
+	// "raw", "link", "spoilertext", "r/" and "u/".
+	Element string `json:"e"`
+	// Text is the text of "text", "raw" and "link" nodes, or the name of
+	// the subreddit or user of "r/" and "u/" nodes.
+	Text string `json:"t,omitempty"`
+	// URL is the target of "link" nodes.
+	URL string `json:"u,omitempty"`
+	// Formats are the formatting ranges of "text" nodes, as Reddit's
+	// [format, start, length] triples.
+	Formats [][3]int `json:"f,omitempty"`
+	// Level is the level, 1 to 6, of "h" nodes.
+	Level int `json:"l,omitempty"`
+	// Ordered numbers the items of "list" nodes.
+	Ordered bool `json:"o,omitempty"`
+	// Children are the nodes inside the node.
+	Children []RichTextNode `json:"c,omitempty"`
+}
+
+// RichParagraph returns a paragraph of the given inline nodes.
+func RichParagraph(nodes ...RichTextNode) RichTextNode {
+	return RichTextNode{Element: "par", Children: nodes}
+}
+
+// RichHeading returns a heading of the given level, 1 to 6, of the given
+// inline nodes.
+func RichHeading(level int, nodes ...RichTextNode) RichTextNode {
+	return RichTextNode{Element: "h", Level: level, Children: nodes}
+}
+
+// RichSpan returns an unformatted run of text.
+func RichSpan(text string) RichTextNode {
+	return RichTextNode{Element: "text", Text: text}
+}
+
+// RichLink returns a link with the given text.
+func RichLink(text, url string) RichTextNode {
+	return RichTextNode{Element: "link", Text: text, URL: url}
+}
+
+var (
+	// richBlocks are the nodes which may be in a document, and which
+	// element their children must be of. Children of "" may be any
+	// inline node.
+	richBlocks = map[string]string{
+		"par":        "",
+		"h":          "",
+		"blockquote": "block",
+		"code":       "raw",
+		"list":       "li",
+		"li":         "block",
+		"hr":         "none",
+	}
+	// richInlines are the nodes which may be in a paragraph, and whether
+	// they require Text.
+	richInlines = map[string]bool{
+		"text":        true,
+		"raw":         true,
+		"link":        true,
+		"r/":          true,
+		"u/":          true,
+		"spoilertext": false,
+	}
+)
+
+// errEmptyRichText is returned for rich text documents with no nodes.
+var errEmptyRichText = fmt.Errorf("rich text document is empty")
+
+// encode validates the document and returns its json, encoded with c.
+func (r *RichText) encode(c Codec) (string, error) {
+	if r == nil || len(r.Document) == 0 {
+		return "", errEmptyRichText
+	}
+
+	for _, node := range r.Document {
+		if err := validateRichBlock(node); err != nil {
+			return "", err
+		}
+	}
+
+	blob, err := c.Marshal(r)
+	return string(blob), err
+}
+
+func validateRichBlock(node RichTextNode) error {
+	children, ok := richBlocks[node.Element]
+	if !ok || node.Element == "li" {
+		return fmt.Errorf("rich text node %q is not a block", node.Element)
+	}
+
+	return validateRichChildren(node, children)
+}
+
+func validateRichChildren(node RichTextNode, children string) error {
+	if node.Element == "h" && (node.Level < 1 || node.Level > 6) {
+		return fmt.Errorf("rich text heading level %d is not 1 to 6", node.Level)
+	}
+
+	for _, child := range node.Children {
+		var err error
+		switch children {
+		case "none":
+			err = fmt.Errorf("rich text node %q has children", node.Element)
+		case "block":
+			err = validateRichBlock(child)
+		case "li":
+			if child.Element != "li" {
+				err = fmt.Errorf("rich text list has a %q node", child.Element)
+			} else {
+				err = validateRichChildren(child, richBlocks["li"])
+			}
+		case "raw":
+			if child.Element != "raw" {
+				err = fmt.Errorf("rich text code has a %q node", child.Element)
+			} else {
+				err = validateRichInline(child)
+			}
+		default:
+			err = validateRichInline(child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateRichInline(node RichTextNode) error {
+	needsText, ok := richInlines[node.Element]
+	if !ok {
+		return fmt.Errorf("rich text node %q is not inline", node.Element)
+	}
+
+	if needsText && node.Text == "" {
+		return fmt.Errorf("rich text node %q has no text", node.Element)
+	}
+
+	if node.Element == "link" && node.URL == "" {
+		return fmt.Errorf("rich text link %q has no url", node.Text)
+	}
+
+	if node.Element == "spoilertext" {
+		for _, child := range node.Children {
+			if err := validateRichInline(child); err != nil {
+				return err
+			}
+		}
+	} else if len(node.Children) != 0 {
+		return fmt.Errorf("rich text node %q has children", node.Element)
+	}
+
+	return nil
+}
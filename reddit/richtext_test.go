@@ -0,0 +1,72 @@
+package reddit
+
+import (
+	"testing"
+)
+
+func TestRichTextEncode(t *testing.T) {
+	doc := &RichText{
+		Document: []RichTextNode{
+			RichHeading(1, RichSpan("Title")),
+			RichParagraph(
+				RichTextNode{Element: "text", Text: "bold", Formats: [][3]int{{1, 0, 4}}},
+				RichLink("go", "https://golang.org"),
+			),
+			RichTextNode{
+				Element: "list",
+				Children: []RichTextNode{
+					RichTextNode{
+						Element:  "li",
+						Children: []RichTextNode{RichParagraph(RichSpan("item"))},
+					},
+				},
+			},
+		},
+	}
+
+	blob, err := doc.encode(jsonCodec{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if blob != `{"document":[`+
+		`{"e":"h","l":1,"c":[{"e":"text","t":"Title"}]},`+
+		`{"e":"par","c":[{"e":"text","t":"bold","f":[[1,0,4]]},{"e":"link","t":"go","u":"https://golang.org"}]},`+
+		`{"e":"list","c":[{"e":"li","c":[{"e":"par","c":[{"e":"text","t":"item"}]}]}]}]}` {
+		t.Errorf("rich text encoded incorrectly: %s", blob)
+	}
+}
+
+func TestRichTextInvalid(t *testing.T) {
+	for i, doc := range []*RichText{
+		nil,
+		&RichText{},
+		&RichText{Document: []RichTextNode{RichSpan("not a block")}},
+		&RichText{Document: []RichTextNode{RichHeading(7, RichSpan("deep"))}},
+		&RichText{Document: []RichTextNode{RichParagraph(RichLink("go", ""))}},
+		&RichText{Document: []RichTextNode{RichParagraph(RichParagraph())}},
+		&RichText{Document: []RichTextNode{
+			RichTextNode{Element: "list", Children: []RichTextNode{RichSpan("x")}},
+		}},
+	} {
+		if _, err := doc.encode(jsonCodec{}); err == nil {
+			t.Errorf("wanted error for invalid document %d", i)
+		}
+	}
+}
+
+func TestRichTextLinkPost(t *testing.T) {
+	r := &mockReaper{}
+	a := newAccount(r, accountConfig{})
+
+	if _, err := a.PostLinkWithOptions(
+		"link", "title", "url",
+		SubmitOptions{
+			RichText: &RichText{
+				Document: []RichTextNode{RichParagraph(RichSpan("hi"))},
+			},
+		},
+	); err != errRichTextLink {
+		t.Errorf("wanted errRichTextLink; got %v", err)
+	}
+}
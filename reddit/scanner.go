@@ -1,5 +1,15 @@
 package reddit
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLimit is the most elements Reddit returns from one listing request.
+const maxLimit = 100
+
 // deletedAuthor is the author field of deleted posts on Reddit.
 const deletedAuthor = "[deleted]"
 
@@ -26,8 +36,57 @@ type Scanner interface {
 	//
 	// If you want a stream where all of this is handled for you, see graw
 	// or graw/streams.
+	//
+	// Listings of subreddits which do not exist return a
+	// SubredditNotFoundError, so they can be told apart from listings
+	// which are empty.
 	Listing(path, after string) (Harvest, error)
+
+	// ListingWithParams is Listing with custom parameters for the request.
+	//
+	// Reddit returns at most 100 elements per request, so a "limit" under
+	// 1 is raised to 1 and a limit over 100 is met by requesting as many
+	// pages as needed, each "after" the last, and merging them. A negative
+	// or non-numeric limit is an error. The default limit is 100.
+	//
+	// Set "sr_detail" to "true" to have Reddit include a summary of each
+	// post's subreddit, in the posts' SubredditDetail.
+	//
+	// Listings which can be localized take the region code in "g" (the
+	// front page's hot listing) or "geo_filter", e.g. "GB" or "US_CA". An
+	// unknown region is an error.
+	//
+	// Set "show" to "all" to include the elements Reddit leaves out of
+	// listings by default, such as posts the account has hidden or which
+	// its preferences filter. Some of what is left out is only shown to
+	// accounts with permission to see it, such as a subreddit's
+	// moderators.
 	ListingWithParams(path string, params map[string]string) (Harvest, error)
+
+	// WindowedCollect returns the posts made to a subreddit between from
+	// and to, newest first. Listings end after 1000 elements, so it
+	// searches the subreddit for posts by their creation time, splitting
+	// the window into smaller ones until each has fewer posts than that,
+	// and merges their results. If more posts were made in one second than
+	// a listing serves, it returns an error, since they cannot all be
+	// collected.
+	//
+	// It depends on Reddit's search index, which does not have the newest
+	// posts until some time after they are made and may not have every
+	// post. It takes a request for each 100 posts, and more where windows
+	// are split.
+	WindowedCollect(subreddit string, from, to time.Time) ([]*Post, error)
+
+	// Search returns the results of a search, which are paged like
+	// ListingWithParams.
+	Search(opts SearchOptions) (Harvest, error)
+
+	// FrontPage returns the posts on the front page of the bot's account,
+	// from the subreddits it subscribes to, and the "after" of the next
+	// page. It takes the parameters of ListingWithParams.
+	FrontPage(params map[string]string) ([]*Post, string, error)
+	// Best is FrontPage in Reddit's personalized "best" order.
+	Best(params map[string]string) ([]*Post, string, error)
 }
 
 type scanner struct {
@@ -39,13 +98,14 @@ func newScanner(r reaper) Scanner {
 }
 
 func (s *scanner) Listing(path, after string) (Harvest, error) {
-	return s.r.reap(
+	h, err := s.r.reap(
 		path, map[string]string{
 			"raw_json": "1",
 			"limit":    "100",
 			"before":   after,
 		},
 	)
+	return h, listingError(path, err)
 }
 
 func (s *scanner) ListingWithParams(path string, params map[string]string) (
@@ -59,5 +119,113 @@ func (s *scanner) ListingWithParams(path string, params map[string]string) (
 	for key, value := range params {
 		reaperParams[key] = value
 	}
-	return s.r.reap(path, reaperParams)
-}
\ No newline at end of file
+
+	limit, err := listingLimit(reaperParams["limit"])
+	if err != nil {
+		return Harvest{}, err
+	}
+
+	if err := checkRegions(reaperParams); err != nil {
+		return Harvest{}, err
+	}
+
+	var h Harvest
+	if limit <= maxLimit {
+		reaperParams["limit"] = strconv.Itoa(limit)
+		h, err = s.r.reap(path, reaperParams)
+	} else {
+		h, err = s.page(path, reaperParams, limit)
+	}
+	return h, listingError(path, err)
+}
+
+// listingError returns the error for a listing at path which failed with err,
+// which is a SubredditNotFoundError if the listing is of a subreddit Reddit
+// does not have.
+func listingError(path string, err error) error {
+	if err != notFoundErr && err != searchRedirectErr {
+		return err
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "r" || parts[1] == "" {
+		return err
+	}
+
+	return &SubredditNotFoundError{Subreddit: parts[1]}
+}
+
+func (s *scanner) FrontPage(params map[string]string) ([]*Post, string, error) {
+	return s.posts("/", params)
+}
+
+func (s *scanner) Best(params map[string]string) ([]*Post, string, error) {
+	return s.posts("/best", params)
+}
+
+// posts returns the posts in a listing and the "after" of its next page.
+func (s *scanner) posts(path string, params map[string]string) (
+	[]*Post,
+	string,
+	error,
+) {
+	h, err := s.ListingWithParams(path, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return h.Posts, h.After, nil
+}
+
+// page gathers up to limit elements from a listing by following its "after"
+// references across as many requests as it takes.
+func (s *scanner) page(
+	path string,
+	params map[string]string,
+	limit int,
+) (Harvest, error) {
+	h := Harvest{}
+	for count := 0; count < limit; {
+		if remaining := limit - count; remaining < maxLimit {
+			params["limit"] = strconv.Itoa(remaining)
+		} else {
+			params["limit"] = strconv.Itoa(maxLimit)
+		}
+
+		p, err := s.r.reap(path, params)
+		if err != nil {
+			return h, err
+		}
+
+		h = merge(h, p)
+
+		size := len(p.Comments) + len(p.Posts) + len(p.Messages) +
+			len(p.Subreddits) + len(p.Users)
+		if size == 0 || p.After == "" {
+			break
+		}
+
+		count += size
+		params["after"] = p.After
+	}
+
+	return h, nil
+}
+
+// listingLimit parses the limit of a listing request, raised to at least 1.
+func listingLimit(value string) (int, error) {
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid listing limit %q", value)
+	}
+
+	if limit < 0 {
+		return 0, fmt.Errorf("negative listing limit %d", limit)
+	}
+
+	if limit < 1 {
+		return 1, nil
+	}
+
+	return limit, nil
+}
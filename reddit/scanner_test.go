@@ -0,0 +1,236 @@
+package reddit
+
+import (
+	"fmt"
+	"testing"
+)
+
+// pagingReaper serves a listing one page per request, recording the
+// parameters of each request.
+type pagingReaper struct {
+	mockReaper
+	pages  []Harvest
+	params []map[string]string
+}
+
+func (p *pagingReaper) reap(path string, values map[string]string) (Harvest, error) {
+	params := map[string]string{}
+	for key, value := range values {
+		params[key] = value
+	}
+	p.params = append(p.params, params)
+
+	if len(p.params) > len(p.pages) {
+		return Harvest{}, nil
+	}
+	return p.pages[len(p.params)-1], nil
+}
+
+func postPage(start, size int, after string) Harvest {
+	h := Harvest{After: after}
+	for i := start; i < start+size; i++ {
+		h.Posts = append(h.Posts, &Post{Name: fmt.Sprintf("t3_%d", i)})
+	}
+	return h
+}
+
+func TestListingWithParamsLimit(t *testing.T) {
+	for i, test := range []struct {
+		limit string
+		sent  string
+		err   bool
+	}{
+		{"", "100", false},
+		{"50", "50", false},
+		{"100", "100", false},
+		{"0", "1", false},
+		{"-1", "", true},
+		{"many", "", true},
+	} {
+		r := &pagingReaper{pages: []Harvest{postPage(0, 1, "")}}
+		params := map[string]string{}
+		if test.limit != "" {
+			params["limit"] = test.limit
+		}
+
+		_, err := newScanner(r).ListingWithParams("/r/all", params)
+		if test.err {
+			if err == nil {
+				t.Errorf("wanted error for limit %q on %d", test.limit, i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error on %d: %v", i, err)
+		} else if sent := r.params[0]["limit"]; sent != test.sent {
+			t.Errorf("sent limit %s on %d; wanted %s", sent, i, test.sent)
+		}
+	}
+}
+
+func TestListingWithParamsPages(t *testing.T) {
+	r := &pagingReaper{
+		pages: []Harvest{
+			postPage(0, 100, "t3_99"),
+			postPage(100, 100, "t3_199"),
+			postPage(200, 50, "t3_249"),
+		},
+	}
+
+	h, err := newScanner(r).ListingWithParams(
+		"/r/all",
+		map[string]string{"limit": "250"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(h.Posts) != 250 {
+		t.Errorf("got %d posts; wanted 250", len(h.Posts))
+	}
+
+	if len(r.params) != 3 {
+		t.Fatalf("made %d requests; wanted 3", len(r.params))
+	}
+
+	for i, want := range []struct{ limit, after string }{
+		{"100", ""},
+		{"100", "t3_99"},
+		{"50", "t3_199"},
+	} {
+		if r.params[i]["limit"] != want.limit || r.params[i]["after"] != want.after {
+			t.Errorf("request %d had params %v; wanted %v", i, r.params[i], want)
+		}
+	}
+}
+
+func TestListingWithParamsPagesEnd(t *testing.T) {
+	r := &pagingReaper{pages: []Harvest{postPage(0, 100, "t3_99"), postPage(100, 20, "")}}
+
+	h, err := newScanner(r).ListingWithParams(
+		"/r/all",
+		map[string]string{"limit": "500"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(h.Posts) != 120 || len(r.params) != 2 {
+		t.Errorf("got %d posts in %d requests; wanted 120 in 2", len(h.Posts), len(r.params))
+	}
+}
+
+func TestListingWithParamsRegion(t *testing.T) {
+	for i, test := range []struct {
+		param string
+		code  string
+		err   bool
+	}{
+		{"g", "GB", false},
+		{"geo_filter", "US_CA", false},
+		{"g", "GLOBAL", false},
+		{"g", "gb", true},
+		{"geo_filter", "XX", true},
+	} {
+		r := &pagingReaper{pages: []Harvest{postPage(0, 1, "")}}
+		_, err := newScanner(r).ListingWithParams(
+			"/hot", map[string]string{test.param: test.code},
+		)
+		if test.err {
+			if err == nil {
+				t.Errorf("wanted error for region %q on %d", test.code, i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error on %d: %v", i, err)
+		} else if sent := r.params[0][test.param]; sent != test.code {
+			t.Errorf("sent %s=%q on %d; wanted %q", test.param, sent, i, test.code)
+		}
+	}
+}
+
+func TestListingWithParamsShowAll(t *testing.T) {
+	r := &pagingReaper{pages: []Harvest{postPage(0, 100, "t3_99"), postPage(100, 1, "")}}
+
+	if _, err := newScanner(r).ListingWithParams(
+		"/r/golang/new",
+		map[string]string{"show": "all", "limit": "150"},
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, params := range r.params {
+		if params["show"] != "all" {
+			t.Errorf("request %d had params %v; wanted show=all", i, params)
+		}
+	}
+}
+
+func TestFrontPage(t *testing.T) {
+	c := &mockClient{response: []byte(`{"kind": "Listing", "data": {
+		"after": "t3_def",
+		"children": [
+			{"kind": "t3", "data": {"name": "t3_abc", "title": "Go 1.14 is released", "subreddit": "golang", "score": 1200}},
+			{"kind": "t3", "data": {"name": "t3_def", "title": "Rust 1.40", "subreddit": "rust", "score": 900}}
+		]
+	}}`)}
+	s := newScanner(
+		newReaper(reaperConfig{client: c, parser: newParser(), hostname: "reddit.com"}),
+	)
+
+	for _, test := range []struct {
+		path string
+		f    func() ([]*Post, string, error)
+	}{
+		{"/", func() ([]*Post, string, error) {
+			return s.FrontPage(nil)
+		}},
+		{"/best", func() ([]*Post, string, error) {
+			return s.Best(map[string]string{"limit": "2"})
+		}},
+	} {
+		posts, after, err := test.f()
+		if err != nil {
+			t.Fatalf("unexpected error reading %s: %v", test.path, err)
+		}
+
+		if c.request.URL.Path != test.path {
+			t.Errorf("read %s; wanted %s", c.request.URL.Path, test.path)
+		}
+
+		if len(posts) != 2 || posts[0].Subreddit != "golang" || after != "t3_def" {
+			t.Errorf("got posts %v after %q; wanted the front page", posts, after)
+		}
+	}
+}
+
+func TestListingSubredditNotFound(t *testing.T) {
+	for _, err := range []error{notFoundErr, searchRedirectErr} {
+		s := newScanner(reaperWhich(Harvest{}, err))
+
+		_, err := s.Listing("/r/nope/new", "")
+		if notFound, ok := err.(*SubredditNotFoundError); !ok || notFound.Subreddit != "nope" {
+			t.Errorf("wanted SubredditNotFoundError for nope; got %v", err)
+		}
+
+		_, err = s.ListingWithParams("/r/nope/new", map[string]string{"limit": "500"})
+		if _, ok := err.(*SubredditNotFoundError); !ok {
+			t.Errorf("wanted SubredditNotFoundError from pages; got %v", err)
+		}
+	}
+
+	s := newScanner(reaperWhich(Harvest{}, notFoundErr))
+	if _, err := s.Listing("/message/inbox", ""); err != notFoundErr {
+		t.Errorf("wanted other listings' 404s unchanged; got %v", err)
+	}
+}
+
+func TestListingEmptySubreddit(t *testing.T) {
+	h, err := newScanner(reaperWhich(Harvest{}, nil)).Listing("/r/quiet/new", "")
+	if err != nil || len(h.Posts) != 0 {
+		t.Errorf("wanted an empty listing; got %v, %v", h, err)
+	}
+}
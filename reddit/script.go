@@ -2,6 +2,7 @@ package reddit
 
 import (
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -9,11 +10,13 @@ import (
 type Script interface {
 	Lurker
 	Scanner
+	Decoder
 }
 
 type script struct {
 	Lurker
 	Scanner
+	Decoder
 }
 
 type ScriptConfig struct {
@@ -22,8 +25,48 @@ type ScriptConfig struct {
 	Agent string
 	// Rate is the minimum amount of time between requests.
 	Rate time.Duration
+	// RateLimits pace classes of endpoints Reddit limits more strictly,
+	// such as search, on top of Rate. Only Rate paces them if it is zero;
+	// DefaultRateLimits suit most bots.
+	RateLimits RateLimits
 	// Custom HTTP client
 	Client *http.Client
+	// Headers are set on every request made through this package. A header
+	// the request already sets, such as the form Content-Type on POSTs or
+	// the User-Agent (which is always Agent), takes precedence over the
+	// same header here.
+	Headers map[string]string
+	// TLS secures the connections made to Reddit.
+	TLS TLSOptions
+	// Connections tunes the pool of connections kept to Reddit.
+	Connections ConnectionOptions
+	// Retryer, if set, decides which failed requests are made again.
+	// BackoffRetryer is a reasonable policy for most scripts.
+	Retryer Retryer
+	// Codec encodes the json bodies the script sends and decodes Reddit's
+	// responses. encoding/json is used if it is nil.
+	Codec Codec
+	// SplitLimits are the most items sent per request to endpoints which
+	// take lists of them, such as Info.
+	SplitLimits SplitLimits
+	// Clock, if set, is the clock the script paces its requests and waits
+	// between retries by, in place of the system clock.
+	Clock Clock
+	// TraceHeader, if set, is the header the script sends the trace id of
+	// each request tagged with one in.
+	TraceHeader string
+	// OnRequest, if set, is called with each request the script makes once
+	// it succeeds or fails for good.
+	OnRequest func(RequestEvent)
+	// URLRewriter, if set, rewrites the url of each request the script
+	// makes just before it is sent.
+	URLRewriter func(*url.URL) *url.URL
+	// WaitOnExhaustion makes the script hold each request while Reddit's
+	// rate limit headers say its requests for the period are spent.
+	WaitOnExhaustion bool
+	// MaxConcurrentRequests is the most requests the script has in flight
+	// at once. There is no cap if it is zero.
+	MaxConcurrentRequests int
 }
 
 // NewScript returns a Script handle to Reddit's API which always sends the
@@ -41,19 +84,39 @@ func NewScript(agent string, rate time.Duration) (Script, error) {
 
 // NewScriptFromConfig returns a Script handle to Reddit's API from ScriptConfig
 func NewScriptFromConfig(config ScriptConfig) (Script, error) {
-	c, err := newClient(clientConfig{agent: config.Agent, client: config.Client})
+	c, err := newClient(
+		clientConfig{
+			agent:                 config.Agent,
+			client:                config.Client,
+			headers:               config.Headers,
+			tls:                   config.TLS,
+			connections:           config.Connections,
+			retryer:               config.Retryer,
+			clock:                 config.Clock,
+			traceHeader:           config.TraceHeader,
+			onRequest:             config.OnRequest,
+			waitOnExhaustion:      config.WaitOnExhaustion,
+			maxConcurrentRequests: config.MaxConcurrentRequests,
+			codec:                 config.Codec,
+		},
+	)
 	r := newReaper(
 		reaperConfig{
 			client:     c,
-			parser:     newParser(),
+			parser:     newParserFromConfig(parserConfig{codec: config.Codec}),
 			hostname:   "reddit.com",
 			reapSuffix: ".json",
 			tls:        true,
 			rate:       maxOf(config.Rate, 2*time.Second),
+			limits:     config.RateLimits,
+			clock:      config.Clock,
+			rewrite:    config.URLRewriter,
+			codec:      config.Codec,
 		},
 	)
 	return &script{
-		Lurker:  newLurker(r),
+		Lurker:  newLurkerFromConfig(r, lurkerConfig{limits: config.SplitLimits, clock: config.Clock}),
 		Scanner: newScanner(r),
+		Decoder: newDecoder(r),
 	}, err
 }
@@ -0,0 +1,129 @@
+package reddit
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SearchOptions are the parameters of a search. Query is required; the zero
+// value of every other field uses Reddit's default.
+type SearchOptions struct {
+	// Query is what to search for, in Reddit's search syntax.
+	Query string
+	// Subreddit, if set, restricts a search for posts to the subreddit.
+	Subreddit string
+	// Type is what to search for: "link" for posts (the default), "sr"
+	// for subreddits or "user" for users. Their results are in the
+	// harvest's Posts, Subreddits and Users.
+	Type string
+	// Sort orders the results: "relevance" (the default), "hot", "top",
+	// "new" or "comments".
+	Sort string
+	// Time restricts the results to those made within the last "hour",
+	// "day", "week", "month" or "year", or "all" of them. It applies to
+	// the relevance, top and comments sorts.
+	Time string
+	// Category restricts the results to a category of posts.
+	Category string
+	// IncludeOver18 includes NSFW results.
+	IncludeOver18 bool
+	// After is the name of the result to continue the search after.
+	After string
+	// Limit is the most results to return; see ListingWithParams. It is
+	// 100 if zero.
+	Limit int
+}
+
+var (
+	searchTypes = map[string]bool{"link": true, "sr": true, "user": true}
+	searchSorts = map[string]bool{
+		"relevance": true,
+		"hot":       true,
+		"top":       true,
+		"new":       true,
+		"comments":  true,
+	}
+	searchTimes = map[string]bool{
+		"hour":  true,
+		"day":   true,
+		"week":  true,
+		"month": true,
+		"year":  true,
+		"all":   true,
+	}
+	// timedSorts are the sorts Time applies to.
+	timedSorts = map[string]bool{"": true, "relevance": true, "top": true, "comments": true}
+)
+
+// errEmptyQuery is returned for searches with no query.
+var errEmptyQuery = fmt.Errorf("search query is empty")
+
+// params returns the path and parameters of the search, or an error if the
+// options are invalid.
+func (o SearchOptions) params() (string, map[string]string, error) {
+	if o.Query == "" {
+		return "", nil, errEmptyQuery
+	}
+
+	if o.Type != "" && !searchTypes[o.Type] {
+		return "", nil, fmt.Errorf("unknown search type %q", o.Type)
+	}
+	if o.Sort != "" && !searchSorts[o.Sort] {
+		return "", nil, fmt.Errorf("unknown search sort %q", o.Sort)
+	}
+	if o.Time != "" && !searchTimes[o.Time] {
+		return "", nil, fmt.Errorf("unknown search time %q", o.Time)
+	}
+	if o.Time != "" && !timedSorts[o.Sort] {
+		return "", nil, fmt.Errorf("search time does not apply to the %s sort", o.Sort)
+	}
+	if o.Limit < 0 {
+		return "", nil, fmt.Errorf("invalid search limit %d", o.Limit)
+	}
+
+	path := "/search"
+	params := map[string]string{"q": o.Query}
+	if o.Subreddit != "" {
+		if o.Type != "" && o.Type != "link" {
+			return "", nil, fmt.Errorf(
+				"only searches for posts can be restricted to a subreddit",
+			)
+		}
+
+		subreddit, err := NormalizeSubreddit(o.Subreddit)
+		if err != nil {
+			return "", nil, err
+		}
+		path = "/r/" + subreddit + "/search"
+		params["restrict_sr"] = "on"
+	}
+
+	for key, value := range map[string]string{
+		"type":     o.Type,
+		"sort":     o.Sort,
+		"t":        o.Time,
+		"category": o.Category,
+		"after":    o.After,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	if o.IncludeOver18 {
+		params["include_over_18"] = "on"
+	}
+	if o.Limit != 0 {
+		params["limit"] = strconv.Itoa(o.Limit)
+	}
+
+	return path, params, nil
+}
+
+func (s *scanner) Search(opts SearchOptions) (Harvest, error) {
+	path, params, err := opts.params()
+	if err != nil {
+		return Harvest{}, err
+	}
+
+	return s.ListingWithParams(path, params)
+}
@@ -0,0 +1,104 @@
+package reddit
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestSearchOptionsParams(t *testing.T) {
+	for i, test := range []struct {
+		opts   SearchOptions
+		path   string
+		params map[string]string
+	}{
+		{
+			SearchOptions{Query: "gopher"},
+			"/search",
+			map[string]string{"q": "gopher"},
+		},
+		{
+			SearchOptions{
+				Query:         "gopher",
+				Subreddit:     "r/golang",
+				Sort:          "top",
+				Time:          "week",
+				Category:      "news",
+				IncludeOver18: true,
+				After:         "t3_abc",
+				Limit:         250,
+			},
+			"/r/golang/search",
+			map[string]string{
+				"q":               "gopher",
+				"restrict_sr":     "on",
+				"sort":            "top",
+				"t":               "week",
+				"category":        "news",
+				"include_over_18": "on",
+				"after":           "t3_abc",
+				"limit":           "250",
+			},
+		},
+		{
+			SearchOptions{Query: "go", Type: "sr", Sort: "new"},
+			"/search",
+			map[string]string{"q": "go", "type": "sr", "sort": "new"},
+		},
+	} {
+		path, params, err := test.opts.params()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if path != test.path {
+			t.Errorf("%d: searched %s; wanted %s", i, path, test.path)
+		}
+
+		if diff := pretty.Compare(params, test.params); diff != "" {
+			t.Errorf("%d: params incorrect; diff: %s", i, diff)
+		}
+	}
+}
+
+func TestSearchOptionsInvalid(t *testing.T) {
+	for i, opts := range []SearchOptions{
+		SearchOptions{},
+		SearchOptions{Query: "go", Sort: "best"},
+		SearchOptions{Query: "go", Time: "decade"},
+		SearchOptions{Query: "go", Type: "comment"},
+		SearchOptions{Query: "go", Sort: "new", Time: "day"},
+		SearchOptions{Query: "go", Type: "user", Subreddit: "golang"},
+		SearchOptions{Query: "go", Subreddit: "not a subreddit"},
+		SearchOptions{Query: "go", Limit: -1},
+	} {
+		if _, _, err := opts.params(); err == nil {
+			t.Errorf("%d: wanted error for %+v", i, opts)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	r := &pagingReaper{pages: []Harvest{postPage(0, 100, "t3_99"), postPage(100, 20, "")}}
+	s := newScanner(r)
+
+	h, err := s.Search(SearchOptions{Query: "gopher", Limit: 120})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(h.Posts) != 120 {
+		t.Errorf("got %d results; wanted 120", len(h.Posts))
+	}
+
+	if len(r.params) != 2 ||
+		r.params[0]["q"] != "gopher" ||
+		r.params[1]["after"] != "t3_99" {
+		t.Errorf("search requests incorrect: %v", r.params)
+	}
+
+	if _, err := s.Search(SearchOptions{Sort: "new"}); err != errEmptyQuery {
+		t.Errorf("wanted errEmptyQuery; got %v", err)
+	}
+}
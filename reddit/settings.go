@@ -0,0 +1,75 @@
+package reddit
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// settingsFormNames maps the fields of a subreddit's settings which
+// /api/site_admin names differently than /about/edit does.
+var settingsFormNames = map[string]string{
+	"subreddit_id":    "sr",
+	"subreddit_type":  "type",
+	"content_options": "link_type",
+}
+
+// settingsForm converts the raw fields of a subreddit's settings into the
+// values /api/site_admin expects. Reddit resets any setting missing from the
+// form, so every current setting is sent back.
+func settingsForm(current map[string]interface{}) map[string]string {
+	form := map[string]string{}
+	for key, value := range current {
+		if name, ok := settingsFormNames[key]; ok {
+			key = name
+		}
+
+		switch v := value.(type) {
+		case string:
+			form[key] = v
+		case bool:
+			form[key] = strconv.FormatBool(v)
+		case float64:
+			form[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case json.Number:
+			form[key] = v.String()
+		}
+	}
+	return form
+}
+
+// values sets the form values of the settings which are set.
+func (s SubredditSettings) values(form map[string]string) {
+	for key, value := range map[string]*string{
+		"title":              s.Title,
+		"public_description": s.PublicDescription,
+		"description":        s.Description,
+		"submit_text":        s.SubmitText,
+		"type":               s.Type,
+		"link_type":          s.LinkType,
+	} {
+		if value != nil {
+			form[key] = *value
+		}
+	}
+
+	for key, value := range map[string]*bool{
+		"over_18":                 s.NSFW,
+		"show_media":              s.ShowMedia,
+		"allow_images":            s.AllowImages,
+		"spoilers_enabled":        s.SpoilersEnabled,
+		"exclude_banned_modqueue": s.ExcludeBannedModqueue,
+	} {
+		if value != nil {
+			form[key] = strconv.FormatBool(*value)
+		}
+	}
+
+	for key, value := range map[string]*int{
+		"comment_score_hide_mins": s.CommentScoreHideMins,
+		"wiki_edit_karma":         s.WikiEditKarma,
+	} {
+		if value != nil {
+			form[key] = strconv.Itoa(*value)
+		}
+	}
+}
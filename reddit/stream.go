@@ -0,0 +1,207 @@
+package reddit
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// streamJSON decodes the json value read from r into dst as encoding/json
+// would, but walks the objects and arrays dst's structs and slices are made of
+// token by token, so the elements of an array, like the children of a
+// listing, are read and decoded one at a time instead of buffering the whole
+// value first. Values of other types are decoded with encoding/json. As with
+// the default Codec, numbers are decoded into interface values as
+// json.Number, and json with more after its value is an error.
+func streamJSON(r io.Reader, dst interface{}) error {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return d.Decode(dst)
+	}
+	if err := streamValue(d, v.Elem()); err != nil {
+		return err
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// streamValue decodes the next json value into v.
+func streamValue(d *json.Decoder, v reflect.Value) error {
+	if !streams(v.Type()) {
+		return d.Decode(v.Addr().Interface())
+	}
+
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok == nil {
+		// encoding/json leaves structs alone on null, but clears
+		// pointers and slices.
+		if v.Kind() != reflect.Struct {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	delim, _ := tok.(json.Delim)
+	switch {
+	case v.Kind() == reflect.Struct && delim == '{':
+		return streamObject(d, v)
+	case v.Kind() == reflect.Slice && delim == '[':
+		return streamArray(d, v)
+	}
+	return &json.UnmarshalTypeError{Value: fmt.Sprint(tok), Type: v.Type()}
+}
+
+// streamObject decodes the members of the object whose opening brace was just
+// read into the struct v, skipping those it has no field for.
+func streamObject(d *json.Decoder, v reflect.Value) error {
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		field := fieldFor(v, tok.(string))
+		if !field.IsValid() {
+			var skipped json.RawMessage
+			if err := d.Decode(&skipped); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := streamValue(d, field); err != nil {
+			return err
+		}
+	}
+
+	_, err := d.Token()
+	return err
+}
+
+// streamArray decodes the elements of the array whose opening bracket was just
+// read into the slice v, one at a time.
+func streamArray(d *json.Decoder, v reflect.Value) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	} else {
+		v.SetLen(0)
+	}
+
+	for d.More() {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := streamValue(d, elem); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, elem))
+	}
+
+	_, err := d.Token()
+	return err
+}
+
+// streams returns whether values of type t are decoded token by token, rather
+// than handed to encoding/json. Only structs and slices whose decoding
+// encoding/json does not customize are.
+func streams(t reflect.Type) bool {
+	for ; t.Kind() == reflect.Ptr; t = t.Elem() {
+		if unmarshals(t) {
+			return false
+		}
+	}
+	if unmarshals(t) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			_, opts := jsonTag(f)
+			if f.Anonymous || strings.Contains(opts, "string") {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		// encoding/json reads []byte from base64 strings.
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// unmarshals returns whether t decodes itself from json or text.
+func unmarshals(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(jsonUnmarshalerType) ||
+		pt.Implements(jsonUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) ||
+		pt.Implements(textUnmarshalerType)
+}
+
+// fieldFor returns the field of the struct v which the object member key
+// decodes into, matching names as encoding/json does, or the zero Value if
+// there is none.
+func fieldFor(v reflect.Value, key string) reflect.Value {
+	fold := -1
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		name, _ := jsonTag(f)
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+
+		if name == key {
+			return v.Field(i)
+		}
+		if fold < 0 && strings.EqualFold(name, key) {
+			fold = i
+		}
+	}
+
+	if fold < 0 {
+		return reflect.Value{}
+	}
+	return v.Field(fold)
+}
+
+// jsonTag returns the name a struct field has in json and its tag's options.
+func jsonTag(f reflect.StructField) (string, string) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "-", ""
+	}
+
+	name, opts := tag, ""
+	if i := strings.Index(tag, ","); i >= 0 {
+		name, opts = tag[:i], tag[i+1:]
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, opts
+}
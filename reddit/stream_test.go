@@ -0,0 +1,75 @@
+package reddit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// streamed is a listing shaped destination with fields of the kinds streamJSON
+// walks and the kinds it leaves to encoding/json.
+type streamed struct {
+	Kind string `json:"kind"`
+	Data struct {
+		After    *string `json:"after"`
+		Children []struct {
+			Kind string `json:"kind"`
+			Data struct {
+				Name    string            `json:"name"`
+				Score   int32             `json:"score,string"`
+				Edited  *time.Time        `json:"edited"`
+				Replies []json.RawMessage `json:"replies"`
+				Awards  map[string]int    `json:"awards"`
+				Body    []byte            `json:"body"`
+			} `json:"data"`
+		} `json:"children"`
+		Pages [][]int `json:"pages"`
+		Other interface{}
+		skip  string
+	} `json:"data"`
+}
+
+func TestStreamJSON(t *testing.T) {
+	for i, blob := range []string{
+		`{"kind": "Listing", "data": {"after": "t1_b", "children": [
+			{"kind": "t1", "data": {"name": "t1_a", "score": "3",
+			"edited": "2020-01-02T03:04:05Z", "replies": [{"kind": "t1"}, ""],
+			"awards": {"gold": 1}, "body": "aGk=", "extra": [1, {"a": 2}]}},
+			{"kind": "t1", "data": {"NAME": "t1_b", "edited": null}}
+		], "pages": [[1, 2], [], null], "other": {"x": [1]}, "skip": "no"}}`,
+		`{"kind": "Listing", "data": {"after": null, "children": []}}`,
+		`{"kind": "Listing", "data": {"children": null}}`,
+		`{"kind": "Listing", "data": null}`,
+		`null`,
+	} {
+		var want, got streamed
+		if err := (jsonCodec{}).Unmarshal([]byte(blob), &want); err != nil {
+			t.Fatalf("%d: failed to prepare test: %v", i, err)
+		}
+
+		if err := streamJSON(strings.NewReader(blob), &got); err != nil {
+			t.Errorf("%d: failed to stream: %v", i, err)
+			continue
+		}
+
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("%d: streamed differently from the default codec: %s", i, diff)
+		}
+	}
+}
+
+func TestStreamJSONMismatch(t *testing.T) {
+	var dst streamed
+	if err := streamJSON(
+		strings.NewReader(`{"data": {"children": {"kind": "t1"}}}`), &dst,
+	); err == nil {
+		t.Errorf("wanted an error streaming an object into a slice")
+	}
+
+	if err := streamJSON(strings.NewReader(`{}`), dst); err == nil {
+		t.Errorf("wanted an error streaming into a non-pointer")
+	}
+}
@@ -0,0 +1,104 @@
+package reddit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxMultiredditSubreddits is the most subreddits this package
+	// combines into one "+" joined listing path.
+	maxMultiredditSubreddits = 100
+	// maxMultiredditPath is the longest "+" joined listing path this
+	// package builds, to keep request URLs well within Reddit's limits.
+	maxMultiredditPath = 2000
+)
+
+// subredditName matches valid subreddit names. Reddit requires 3-21
+// characters for new subreddits, but some old subreddits have only two.
+var subredditName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_]{1,20}$`)
+
+// profilePrefix begins the names of user profiles, which take submissions
+// like subreddits under the name "u_" and the username.
+const profilePrefix = "u_"
+
+// profileName matches the names of user profiles.
+var profileName = regexp.MustCompile(`^u_[A-Za-z0-9_-]{3,20}$`)
+
+// NormalizeSubreddit returns the bare name of a subreddit given as "golang",
+// "r/golang" or "/r/golang/", or an error if it is not a valid subreddit
+// name. User profiles given as "u/gopher" or "/user/gopher" are named
+// "u_gopher", as Reddit names them for submissions. The subreddit-taking
+// methods of this package normalize their names with it, so they may be given
+// in any of these forms.
+func NormalizeSubreddit(name string) (string, error) {
+	sr := strings.TrimSpace(name)
+	sr = strings.TrimPrefix(sr, "/")
+	sr = strings.TrimSuffix(sr, "/")
+	for _, prefix := range []string{"user/", "u/"} {
+		if len(sr) > len(prefix) && strings.EqualFold(sr[:len(prefix)], prefix) {
+			sr = profilePrefix + sr[len(prefix):]
+			if !profileName.MatchString(sr) {
+				return "", fmt.Errorf("invalid profile name %q", name)
+			}
+			return sr, nil
+		}
+	}
+
+	if len(sr) > 2 && strings.EqualFold(sr[:2], "r/") {
+		sr = sr[2:]
+	}
+
+	if !subredditName.MatchString(sr) && !profileName.MatchString(sr) {
+		return "", fmt.Errorf("invalid subreddit name %q", name)
+	}
+
+	return sr, nil
+}
+
+// Multireddit returns the path of the listing that combines the subreddits
+// using Reddit's "+" feature, e.g. /r/golang+rust. It does not check that
+// Reddit will accept that many subreddits at once; see SplitMultireddit.
+func Multireddit(subreddits []string) string {
+	return "/r/" + strings.Join(subreddits, "+")
+}
+
+// SplitMultireddit returns the paths of as few combined listings as it takes
+// to cover all of the subreddits while keeping each listing small enough for
+// Reddit to accept. It returns an error if a subreddit name is invalid.
+func SplitMultireddit(subreddits []string) ([]string, error) {
+	if len(subreddits) == 0 {
+		return nil, fmt.Errorf("no subreddits to combine")
+	}
+
+	names := make([]string, len(subreddits))
+	for i, name := range subreddits {
+		sr, err := NormalizeSubreddit(name)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = sr
+	}
+
+	return SplitMultiredditNames(names), nil
+}
+
+// SplitMultiredditNames is SplitMultireddit without checking or normalizing
+// the names, which are combined as they are given. It suits names Reddit
+// takes in combined listings but NormalizeSubreddit does not, such as
+// "all-pics", or names already joined, such as "golang+rust".
+func SplitMultiredditNames(names []string) []string {
+	paths := []string{}
+	group := []string{}
+	for _, sr := range names {
+		if len(group) > 0 && (len(group) == maxMultiredditSubreddits ||
+			len(Multireddit(append(group, sr))) > maxMultiredditPath) {
+			paths = append(paths, Multireddit(group))
+			group = nil
+		}
+		group = append(group, sr)
+	}
+
+	return append(paths, Multireddit(group))
+}
@@ -0,0 +1,145 @@
+package reddit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMultireddit(t *testing.T) {
+	if path := Multireddit([]string{"golang", "rust"}); path != "/r/golang+rust" {
+		t.Errorf("got %s; wanted /r/golang+rust", path)
+	}
+}
+
+func TestSplitMultireddit(t *testing.T) {
+	paths, err := SplitMultireddit([]string{"golang", "rust"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(paths) != 1 || paths[0] != "/r/golang+rust" {
+		t.Errorf("got %v; wanted [/r/golang+rust]", paths)
+	}
+
+	many := []string{}
+	for i := 0; i < 250; i++ {
+		many = append(many, fmt.Sprintf("sub%d", i))
+	}
+
+	paths, err = SplitMultireddit(many)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != 3 {
+		t.Fatalf("got %d paths; wanted 3", len(paths))
+	}
+
+	count := 0
+	for _, path := range paths {
+		if len(path) > maxMultiredditPath {
+			t.Errorf("path is too long: %d", len(path))
+		}
+		count += len(strings.Split(strings.TrimPrefix(path, "/r/"), "+"))
+	}
+
+	if count != len(many) {
+		t.Errorf("paths cover %d subreddits; wanted %d", count, len(many))
+	}
+
+	long := []string{}
+	for i := 0; i < 100; i++ {
+		long = append(long, fmt.Sprintf("%021d", i))
+	}
+
+	if paths, err := SplitMultireddit(long); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(paths) != 2 {
+		t.Errorf("got %d paths for long names; wanted 2", len(paths))
+	}
+}
+
+func TestSplitMultiredditInvalid(t *testing.T) {
+	for _, subreddits := range [][]string{
+		nil,
+		{"golang", ""},
+		{"a"},
+		{"waytoolongforasubredditname"},
+		{"has space"},
+	} {
+		if _, err := SplitMultireddit(subreddits); err == nil {
+			t.Errorf("wanted error for %v", subreddits)
+		}
+	}
+}
+
+func TestSplitMultiredditNames(t *testing.T) {
+	paths := SplitMultiredditNames([]string{"all-pics", "golang+rust", "a"})
+	if len(paths) != 1 || paths[0] != "/r/all-pics+golang+rust+a" {
+		t.Errorf("got paths %v; wanted the names as given", paths)
+	}
+
+	many := make([]string, 150)
+	for i := range many {
+		many[i] = fmt.Sprintf("Sub-%d", i)
+	}
+	if paths := SplitMultiredditNames(many); len(paths) != 2 {
+		t.Errorf("got %d paths; wanted 2", len(paths))
+	}
+}
+
+func TestSplitMultiredditNormalizes(t *testing.T) {
+	paths, err := SplitMultireddit([]string{"golang", "r/rust", "/r/haskell/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != 1 || paths[0] != "/r/golang+rust+haskell" {
+		t.Errorf("got paths %v; wanted [/r/golang+rust+haskell]", paths)
+	}
+}
+
+func TestNormalizeSubreddit(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		sr   string
+		err  bool
+	}{
+		{"golang", "golang", false},
+		{"r/golang", "golang", false},
+		{"/r/golang", "golang", false},
+		{"/r/golang/", "golang", false},
+		{"R/golang", "golang", false},
+		{" golang ", "golang", false},
+		{"ja", "ja", false},
+		{"Go_Lang_2", "Go_Lang_2", false},
+		{"u_some-user", "u_some-user", false},
+		{"u/gopher", "u_gopher", false},
+		{"/u/gopher/", "u_gopher", false},
+		{"/user/gopher", "u_gopher", false},
+		{"U/gopher", "u_gopher", false},
+		{"", "", true},
+		{"/r/", "", true},
+		{"a", "", true},
+		{"_golang", "", true},
+		{"waytoolongforasubredditname", "", true},
+		{"go-lang", "", true},
+		{"has space", "", true},
+		{"r/golang+rust", "", true},
+		{"u/ab", "", true},
+		{"/user/gopher/submitted", "", true},
+	} {
+		sr, err := NormalizeSubreddit(test.name)
+		if test.err {
+			if err == nil {
+				t.Errorf("wanted error for %q; got %q", test.name, sr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.name, err)
+		} else if sr != test.sr {
+			t.Errorf("normalized %q to %q; wanted %q", test.name, sr, test.sr)
+		}
+	}
+}
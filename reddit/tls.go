@@ -0,0 +1,45 @@
+package reddit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+)
+
+// TLSOptions secure the connections a bot makes to Reddit. The zero value uses
+// Go's defaults.
+type TLSOptions struct {
+	// MinVersion is the lowest TLS version allowed, e.g. tls.VersionTLS12.
+	MinVersion uint16
+	// RootCAs are the certificate authorities trusted to sign Reddit's
+	// certificates, instead of the system's.
+	RootCAs *x509.CertPool
+	// VerifyPeerCertificate, if set, is called with the certificates
+	// Reddit presents after the usual checks, and fails the connection if
+	// it returns an error. Use it to pin Reddit's certificate or key.
+	VerifyPeerCertificate func(
+		rawCerts [][]byte,
+		verifiedChains [][]*x509.Certificate,
+	) error
+}
+
+func (t TLSOptions) unset() bool {
+	return t.MinVersion == 0 && t.RootCAs == nil && t.VerifyPeerCertificate == nil
+}
+
+// apply sets the options which are set on a transport's TLS config.
+func (t TLSOptions) apply(transport *http.Transport) {
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	if t.MinVersion != 0 {
+		transport.TLSClientConfig.MinVersion = t.MinVersion
+	}
+	if t.RootCAs != nil {
+		transport.TLSClientConfig.RootCAs = t.RootCAs
+	}
+	if t.VerifyPeerCertificate != nil {
+		transport.TLSClientConfig.VerifyPeerCertificate = t.VerifyPeerCertificate
+	}
+}
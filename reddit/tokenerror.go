@@ -0,0 +1,89 @@
+package reddit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenErrorTransport makes failures reported by Reddit's token endpoint
+// visible to the oauth2 package. Reddit reports some failures, such as a wrong
+// password, with a 200 status and an "error" field, which the oauth2 package
+// would only report as a missing access token.
+type tokenErrorTransport struct {
+	http.RoundTripper
+}
+
+func (t *tokenErrorTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(r)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var tokenErr struct {
+		AccessToken string      `json:"access_token"`
+		Error       interface{} `json:"error"`
+	}
+	if json.Unmarshal(body, &tokenErr) == nil &&
+		tokenErr.AccessToken == "" && tokenErr.Error != nil {
+		resp.StatusCode = http.StatusBadRequest
+		resp.Status = http.StatusText(http.StatusBadRequest)
+	}
+
+	return resp, nil
+}
+
+// authError classifies an error from Reddit's token endpoint. Errors which
+// did not come from the endpoint, such as network failures, are returned as
+// they are.
+func authError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		if _, ok := urlErr.Err.(*oauth2.RetrieveError); ok {
+			err = urlErr.Err
+		}
+	}
+
+	retrieveErr, ok := err.(*oauth2.RetrieveError)
+	if !ok {
+		return err
+	}
+
+	var body struct {
+		Error       interface{} `json:"error"`
+		Description string      `json:"error_description"`
+		Message     string      `json:"message"`
+	}
+	json.Unmarshal(retrieveErr.Body, &body)
+	code, _ := body.Error.(string)
+	text := strings.ToLower(body.Description + " " + body.Message)
+
+	switch {
+	case strings.Contains(text, "suspended"):
+		return AccountSuspendedErr
+	case strings.Contains(text, "two-factor"),
+		strings.Contains(text, "two factor"),
+		strings.Contains(text, "otp"):
+		return TwoFactorRequiredErr
+	case code == "invalid_client",
+		retrieveErr.Response.StatusCode == http.StatusUnauthorized:
+		return InvalidClientErr
+	case code == "invalid_grant",
+		retrieveErr.Response.StatusCode == http.StatusBadRequest,
+		retrieveErr.Response.StatusCode == http.StatusForbidden:
+		return InvalidCredentialsErr
+	}
+
+	return err
+}
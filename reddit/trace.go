@@ -0,0 +1,39 @@
+package reddit
+
+import (
+	"context"
+	"time"
+)
+
+// traceKey is the context key of a request's trace id.
+type traceKey struct{}
+
+// WithTraceID returns a context which tags the requests made with it with the
+// trace id, so they can be matched with the caller's own traces.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceKey{}, id)
+}
+
+// TraceID returns the trace id the context tags requests with, or "" if it
+// has none.
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+// RequestEvent describes a request the bot made, once it has succeeded or
+// failed for good.
+type RequestEvent struct {
+	Method string
+	URL    string
+	// TraceID is the trace id the request was tagged with, if any.
+	TraceID string
+	// BodySize is how many bytes of content the request sent: its json
+	// body, or for other writes the form values, which this package sends
+	// in the url's query. It is zero for reads.
+	BodySize int64
+	// Duration is how long the request took, with its retries.
+	Duration time.Duration
+	// Err is the error the request failed with, or nil.
+	Err error
+}
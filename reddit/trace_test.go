@@ -0,0 +1,102 @@
+package reddit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestTraceID(t *testing.T) {
+	headers := make(chan string, 2)
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				headers <- r.Header.Get("X-Trace-Id")
+				w.Write([]byte("{}"))
+			},
+		),
+	)
+	defer serv.Close()
+
+	host, err := url.Parse(serv.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server url: %v", err)
+	}
+
+	events := []RequestEvent{}
+	r := &reaperImpl{
+		cli: &baseClient{
+			cli:         &http.Client{},
+			traceHeader: "X-Trace-Id",
+			onRequest:   func(e RequestEvent) { events = append(events, e) },
+		},
+		parser:   newParser(),
+		hostname: host.Host,
+		scheme:   "http",
+		mu:       &sync.Mutex{},
+	}
+
+	ctx := WithTraceID(context.Background(), "from-context")
+	for _, test := range []struct {
+		req   Request
+		trace string
+	}{
+		{Request{Method: "GET", Path: "/api/v1/me", TraceID: "from-field"}, "from-field"},
+		{Request{Method: "POST", Path: "/api/hide", Context: ctx}, "from-context"},
+		{Request{Method: "GET", Path: "/api/v1/me", Context: ctx, TraceID: "override"}, "override"},
+		{Request{Method: "GET", Path: "/api/v1/me"}, ""},
+	} {
+		if _, err := r.send(test.req); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if header := <-headers; header != test.trace {
+			t.Errorf("sent trace header %q; wanted %q", header, test.trace)
+		}
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("got %d request events; wanted 4", len(events))
+	}
+	for i, trace := range []string{"from-field", "from-context", "override", ""} {
+		if events[i].TraceID != trace || events[i].Err != nil {
+			t.Errorf("event %d is %+v; wanted trace id %q", i, events[i], trace)
+		}
+	}
+	if events[1].Method != "POST" || events[1].URL != serv.URL+"/api/hide" {
+		t.Errorf("got event %+v; wanted the POST to /api/hide", events[1])
+	}
+	for i, e := range events {
+		if e.BodySize != 0 {
+			t.Errorf("event %d has body size %d; wanted 0", i, e.BodySize)
+		}
+	}
+
+	events = nil
+	if _, err := r.send(Request{
+		Method: "POST",
+		Path:   "/api/comment",
+		Values: map[string]string{"text": "hello"},
+	}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	<-headers
+	if _, err := r.send(Request{
+		Method: "PATCH",
+		Path:   "/api/v1/me/prefs",
+		JSON:   []byte(`{"over_18": true}`),
+	}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	<-headers
+	if len(events) != 2 || events[0].BodySize != int64(len("text=hello")) ||
+		events[1].BodySize != int64(len(`{"over_18": true}`)) {
+		t.Errorf("got events %+v; wanted the sizes of the form and json bodies", events)
+	}
+
+	if _, ok := formEncoding["X-Trace-Id"]; ok {
+		t.Errorf("trace header leaked into the shared form headers")
+	}
+}
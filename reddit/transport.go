@@ -0,0 +1,82 @@
+package reddit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ConnectionOptions tune the pool of connections a bot keeps to Reddit. The
+// zero value uses Go's defaults.
+//
+// Requests are made one at a time, no more often than the bot's Rate, so a bot
+// rarely has more than one connection open to each of Reddit's hosts, and
+// keep-alives let it reuse that connection instead of reconnecting for each
+// request. Larger pools pay off when several bots share one http.Client.
+type ConnectionOptions struct {
+	// MaxIdleConns is the most idle connections kept across all hosts.
+	// Since nearly all requests go to one host, it is also used as the
+	// per host limit if MaxIdleConnsPerHost is not set.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the most idle connections kept to each host.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives makes every request use a new connection.
+	DisableKeepAlives bool
+}
+
+func (c ConnectionOptions) unset() bool {
+	return c == ConnectionOptions{}
+}
+
+// apply sets the options which are set on a transport.
+func (c ConnectionOptions) apply(transport *http.Transport) {
+	if c.MaxIdleConns != 0 {
+		transport.MaxIdleConns = c.MaxIdleConns
+		transport.MaxIdleConnsPerHost = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.DisableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+}
+
+// withTransport returns a copy of the client whose transport applies the
+// options. The client's transport must be an *http.Transport (or nil, for the
+// default transport) to apply them to.
+func withTransport(
+	client *http.Client,
+	t TLSOptions,
+	c ConnectionOptions,
+) (*http.Client, error) {
+	if t.unset() && c.unset() {
+		return client, nil
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf(
+			"TLS and connection options require an *http.Transport; client has %T",
+			base,
+		)
+	}
+
+	transport = transport.Clone()
+	t.apply(transport)
+	c.apply(transport)
+
+	configured := *client
+	configured.Transport = transport
+	return &configured, nil
+}
@@ -0,0 +1,128 @@
+package reddit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// tlsServerWhich returns a TLS server which speaks TLS versions up to max.
+func tlsServerWhich(max uint16) *httptest.Server {
+	serv := httptest.NewUnstartedServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("{}"))
+			},
+		),
+	)
+	serv.TLS = &tls.Config{MaxVersion: max}
+	// The failed handshakes are expected; don't log them.
+	serv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	serv.StartTLS()
+	return serv
+}
+
+func TestTLSOptions(t *testing.T) {
+	serv := tlsServerWhich(tls.VersionTLS12)
+	defer serv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serv.Certificate())
+
+	pinErr := fmt.Errorf("certificate is not pinned")
+	for i, test := range []struct {
+		opts TLSOptions
+		ok   bool
+	}{
+		{TLSOptions{}, false},
+		{TLSOptions{RootCAs: roots}, true},
+		{TLSOptions{RootCAs: roots, MinVersion: tls.VersionTLS12}, true},
+		{TLSOptions{RootCAs: roots, MinVersion: tls.VersionTLS13}, false},
+		{
+			TLSOptions{
+				RootCAs: roots,
+				VerifyPeerCertificate: func(_ [][]byte, _ [][]*x509.Certificate) error {
+					return pinErr
+				},
+			},
+			false,
+		},
+	} {
+		c, err := newClient(clientConfig{agent: "agent", tls: test.opts})
+		if err != nil {
+			t.Fatalf("failed to make client on %d: %v", i, err)
+		}
+
+		req, err := http.NewRequest("GET", serv.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare request for test: %v", err)
+		}
+
+		if _, err := c.Do(req); (err == nil) != test.ok {
+			t.Errorf("wrong on %d; got error %v", i, err)
+		}
+	}
+}
+
+func TestTLSOptionsTransport(t *testing.T) {
+	custom := &http.Client{Transport: &agentForwarder{}}
+	if _, err := withTransport(custom, TLSOptions{MinVersion: tls.VersionTLS12}, ConnectionOptions{}); err == nil {
+		t.Errorf("wanted error applying TLS options to a custom round tripper")
+	}
+
+	if c, err := withTransport(custom, TLSOptions{}, ConnectionOptions{}); err != nil || c != custom {
+		t.Errorf("unset options changed the client: %v", err)
+	}
+}
+
+func TestConnectionOptions(t *testing.T) {
+	for i, test := range []struct {
+		opts      ConnectionOptions
+		idle      int
+		perHost   int
+		timeout   time.Duration
+		keepAlive bool
+	}{
+		{ConnectionOptions{MaxIdleConns: 20}, 20, 20, defaultIdleTimeout(), true},
+		{
+			ConnectionOptions{MaxIdleConns: 20, MaxIdleConnsPerHost: 5},
+			20, 5, defaultIdleTimeout(), true,
+		},
+		{
+			ConnectionOptions{IdleConnTimeout: time.Second, DisableKeepAlives: true},
+			defaultTransport().MaxIdleConns, defaultTransport().MaxIdleConnsPerHost,
+			time.Second, false,
+		},
+	} {
+		c, err := withTransport(&http.Client{}, TLSOptions{}, test.opts)
+		if err != nil {
+			t.Fatalf("failed to apply options on %d: %v", i, err)
+		}
+
+		transport := c.Transport.(*http.Transport)
+		if transport.MaxIdleConns != test.idle ||
+			transport.MaxIdleConnsPerHost != test.perHost ||
+			transport.IdleConnTimeout != test.timeout ||
+			transport.DisableKeepAlives == test.keepAlive {
+			t.Errorf("options applied incorrectly on %d: %+v", i, transport)
+		}
+	}
+
+	if defaultTransport().DisableKeepAlives {
+		t.Errorf("connection options changed the default transport")
+	}
+}
+
+func defaultTransport() *http.Transport {
+	return http.DefaultTransport.(*http.Transport)
+}
+
+func defaultIdleTimeout() time.Duration {
+	return defaultTransport().IdleConnTimeout
+}
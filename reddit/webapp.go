@@ -0,0 +1,90 @@
+package reddit
+
+import (
+	"golang.org/x/oauth2"
+)
+
+// AuthCodeOptions configure the authorization code flow of web apps, in which
+// a user grants the app access to their account on Reddit's authorization
+// page and the app exchanges the code Reddit redirects back with for a token.
+type AuthCodeOptions struct {
+	// RedirectURI is the app's registered redirect uri.
+	RedirectURI string
+	// Scopes are the OAuth2 scopes to ask for. The scopes bots use are
+	// asked for if it is empty.
+	Scopes []string
+	// Temporary asks for a grant which lasts an hour, with no refresh
+	// token. Grants are permanent by default, so the app can refresh the
+	// token for as long as the user leaves it authorized.
+	Temporary bool
+}
+
+// grantDuration returns the value of Reddit's duration parameter for the
+// options.
+func (o AuthCodeOptions) grantDuration() string {
+	if o.Temporary {
+		return "temporary"
+	}
+	return "permanent"
+}
+
+func (o AuthCodeOptions) config(app App) *oauth2.Config {
+	scopes := o.Scopes
+	if len(scopes) == 0 {
+		scopes = app.scopes()
+	}
+
+	app = app.withEndpoints()
+	return &oauth2.Config{
+		ClientID:     app.ID,
+		ClientSecret: app.Secret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authorizeURL,
+			TokenURL: app.TokenURL,
+		},
+		RedirectURL: o.RedirectURI,
+		Scopes:      scopes,
+	}
+}
+
+// AuthCodeURL returns the url of the page where a user grants the app access
+// to their account. Reddit redirects them to the options' RedirectURI with
+// the given state, which the app should check, and a code for ExchangeCode.
+func AuthCodeURL(app App, state string, opts AuthCodeOptions) string {
+	return opts.config(app).AuthCodeURL(
+		state,
+		oauth2.SetAuthURLParam("duration", opts.grantDuration()),
+	)
+}
+
+// ExchangeCode exchanges the code Reddit redirected a user back with for
+// their token, with the same options given to AuthCodeURL. If the options
+// are permanent but Reddit issued no refresh token, the token is returned
+// with MissingRefreshTokenErr: the token works, but expires within an hour
+// and cannot be refreshed.
+func ExchangeCode(
+	agent string,
+	app App,
+	code string,
+	opts AuthCodeOptions,
+) (*oauth2.Token, error) {
+	if err := app.validateAuth(); err != nil {
+		return nil, err
+	}
+
+	a, err := unauthorizedAppClient(clientConfig{agent: agent, app: app})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := opts.config(app).Exchange(a.tokenContext(), code)
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	if !opts.Temporary && token.RefreshToken == "" {
+		return token, MissingRefreshTokenErr
+	}
+
+	return token, nil
+}
@@ -0,0 +1,72 @@
+package reddit
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAuthCodeURL(t *testing.T) {
+	app := App{ID: "id", Secret: "secret"}
+	for _, test := range []struct {
+		opts     AuthCodeOptions
+		duration string
+	}{
+		{AuthCodeOptions{RedirectURI: "https://example.org/auth"}, "permanent"},
+		{AuthCodeOptions{RedirectURI: "https://example.org/auth", Temporary: true}, "temporary"},
+	} {
+		u, err := url.Parse(AuthCodeURL(app, "state", test.opts))
+		if err != nil {
+			t.Fatalf("invalid url: %v", err)
+		}
+
+		query := u.Query()
+		if query.Get("duration") != test.duration ||
+			query.Get("state") != "state" ||
+			query.Get("client_id") != "id" ||
+			query.Get("response_type") != "code" ||
+			query.Get("redirect_uri") != "https://example.org/auth" {
+			t.Errorf("authorization url incorrect: %s", u)
+		}
+	}
+}
+
+func TestExchangeCode(t *testing.T) {
+	for _, test := range []struct {
+		token []byte
+		opts  AuthCodeOptions
+		err   error
+	}{
+		{
+			[]byte(`{"access_token": "token", "refresh_token": "refresh", "token_type": "bearer", "expires_in": 3600}`),
+			AuthCodeOptions{},
+			nil,
+		},
+		{
+			[]byte(`{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`),
+			AuthCodeOptions{},
+			MissingRefreshTokenErr,
+		},
+		{
+			[]byte(`{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`),
+			AuthCodeOptions{Temporary: true},
+			nil,
+		},
+	} {
+		tokens := jsonServerWhich(test.token, http.StatusOK)
+		token, err := ExchangeCode(
+			"agent",
+			App{ID: "id", Secret: "secret", TokenURL: tokens.URL},
+			"code",
+			test.opts,
+		)
+		tokens.Close()
+
+		if err != test.err {
+			t.Errorf("wanted %v; got %v", test.err, err)
+		}
+		if token == nil || token.AccessToken != "token" {
+			t.Errorf("wanted the token; got %v", token)
+		}
+	}
+}
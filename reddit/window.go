@@ -0,0 +1,90 @@
+package reddit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// listingCeiling is the most elements Reddit serves from a listing, however
+// many pages are requested.
+const listingCeiling = 1000
+
+func (s *scanner) WindowedCollect(subreddit string, from, to time.Time) (
+	[]*Post,
+	error,
+) {
+	subreddit, err := NormalizeSubreddit(subreddit)
+	if err != nil {
+		return nil, err
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("window ends at %v before it begins at %v", to, from)
+	}
+
+	seen := map[string]*Post{}
+	if err := s.collectWindow(subreddit, from.Unix(), to.Unix(), seen); err != nil {
+		return nil, err
+	}
+
+	posts := make([]*Post, 0, len(seen))
+	for _, p := range seen {
+		posts = append(posts, p)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].CreatedUTC != posts[j].CreatedUTC {
+			return posts[i].CreatedUTC > posts[j].CreatedUTC
+		}
+		return posts[i].Name > posts[j].Name
+	})
+
+	return posts, nil
+}
+
+// collectWindow gathers the posts of the subreddit created between from and
+// to, inclusive, in seconds since the epoch. Windows whose listing ends with
+// more posts left, or with as many as a listing serves, are split in half
+// until they fit. A window of one second which does not fit is an error, since
+// it cannot be split.
+func (s *scanner) collectWindow(
+	subreddit string,
+	from, to int64,
+	seen map[string]*Post,
+) error {
+	h, err := s.ListingWithParams(
+		"/r/"+subreddit+"/search", map[string]string{
+			"q":               fmt.Sprintf("timestamp:%d..%d", from, to),
+			"syntax":          "cloudsearch",
+			"restrict_sr":     "on",
+			"include_over_18": "on",
+			"sort":            "new",
+			"limit":           strconv.Itoa(listingCeiling),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range h.Posts {
+		seen[p.Name] = p
+	}
+
+	if h.After == "" && len(h.Posts) < listingCeiling {
+		return nil
+	}
+
+	if from == to {
+		return fmt.Errorf(
+			"more posts were made to /r/%s at %v than a listing serves",
+			subreddit, time.Unix(from, 0).UTC(),
+		)
+	}
+
+	mid := from + (to-from)/2
+	if err := s.collectWindow(subreddit, from, mid, seen); err != nil {
+		return err
+	}
+	return s.collectWindow(subreddit, mid+1, to, seen)
+}
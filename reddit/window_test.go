@@ -0,0 +1,165 @@
+package reddit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// searchReaper serves timestamp searches over posts made one per second,
+// newest first and paged like Reddit's listings, up to the ceiling or the
+// listing ceiling if it is zero. Listings cut short by the ceiling serve no
+// more posts, but still end with an after.
+type searchReaper struct {
+	mockReaper
+	first, last int64
+	ceiling     int
+	searches    map[string]bool
+}
+
+func (s *searchReaper) reap(path string, values map[string]string) (Harvest, error) {
+	s.path = path
+	if values["syntax"] != "cloudsearch" || values["restrict_sr"] != "on" {
+		return Harvest{}, fmt.Errorf("not a subreddit cloudsearch: %v", values)
+	}
+
+	var from, to int64
+	if _, err := fmt.Sscanf(values["q"], "timestamp:%d..%d", &from, &to); err != nil {
+		return Harvest{}, err
+	}
+	s.searches[values["q"]] = true
+
+	ceiling := s.ceiling
+	if ceiling == 0 {
+		ceiling = listingCeiling
+	}
+
+	matched, truncated := []*Post{}, false
+	for created := to; created >= from; created-- {
+		if created < s.first || created > s.last {
+			continue
+		}
+		if len(matched) == ceiling {
+			truncated = true
+			break
+		}
+		matched = append(matched, &Post{
+			Name:       fmt.Sprintf("t3_%d", created),
+			CreatedUTC: uint64(created),
+		})
+	}
+
+	start := 0
+	for i, p := range matched {
+		if p.Name == values["after"] {
+			start = i + 1
+		}
+	}
+
+	var limit int
+	fmt.Sscanf(values["limit"], "%d", &limit)
+	end := start + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	h := Harvest{Posts: matched[start:end]}
+	if end < len(matched) {
+		h.After = matched[end-1].Name
+	} else if truncated {
+		h.After = values["after"]
+		if end > start {
+			h.After = matched[end-1].Name
+		}
+	}
+	return h, nil
+}
+
+func TestWindowedCollect(t *testing.T) {
+	r := &searchReaper{first: 10000, last: 12499, searches: map[string]bool{}}
+
+	posts, err := newScanner(r).WindowedCollect(
+		"/r/golang",
+		time.Unix(9000, 0),
+		time.Unix(13000, 0),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.path != "/r/golang/search" {
+		t.Errorf("searched wrong path: %s", r.path)
+	}
+
+	if len(posts) != 2500 {
+		t.Fatalf("got %d posts; wanted 2500", len(posts))
+	}
+
+	for i, p := range posts {
+		if want := uint64(12499 - i); p.CreatedUTC != want {
+			t.Fatalf("post %d was created at %d; wanted %d", i, p.CreatedUTC, want)
+		}
+	}
+
+	if len(r.searches) < 3 {
+		t.Errorf("made %d searches; wanted the window split", len(r.searches))
+	}
+}
+
+func TestWindowedCollectTruncated(t *testing.T) {
+	r := &searchReaper{
+		first:    10000,
+		last:     10999,
+		ceiling:  250,
+		searches: map[string]bool{},
+	}
+
+	posts, err := newScanner(r).WindowedCollect(
+		"golang",
+		time.Unix(10000, 0),
+		time.Unix(10999, 0),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(posts) != 1000 {
+		t.Errorf("got %d posts; wanted 1000", len(posts))
+	}
+}
+
+// crowdedReaper serves searches whose listings always have more posts left.
+type crowdedReaper struct {
+	mockReaper
+}
+
+func (c *crowdedReaper) reap(path string, values map[string]string) (Harvest, error) {
+	return Harvest{
+		Posts: []*Post{{Name: "t3_abc", CreatedUTC: 1}},
+		After: "t3_abc",
+	}, nil
+}
+
+func TestWindowedCollectOverflow(t *testing.T) {
+	if _, err := newScanner(&crowdedReaper{}).WindowedCollect(
+		"golang", time.Unix(1, 0), time.Unix(2, 0),
+	); err == nil {
+		t.Errorf("wanted error for second with more posts than a listing serves")
+	}
+}
+
+func TestWindowedCollectInvalid(t *testing.T) {
+	r := &searchReaper{searches: map[string]bool{}}
+
+	if _, err := newScanner(r).WindowedCollect(
+		"golang", time.Unix(2, 0), time.Unix(1, 0),
+	); err == nil {
+		t.Errorf("wanted error for window which ends before it begins")
+	}
+
+	if _, err := newScanner(r).WindowedCollect(
+		"has space", time.Unix(1, 0), time.Unix(2, 0),
+	); err == nil {
+		t.Errorf("wanted error for invalid subreddit")
+	}
+}
@@ -0,0 +1,228 @@
+package streams
+
+import (
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// EditPolling watches the comments an Events stream delivers for edits, which
+// the listings of new comments do not show.
+type EditPolling struct {
+	// Window is how long after a comment arrives it is watched. A longer
+	// window catches later edits but costs more requests, since every
+	// comment in it is looked up each poll.
+	Window time.Duration
+	// Interval is how often the watched comments are looked up again. It is
+	// a minute if zero.
+	Interval time.Duration
+	// Clock times the windows and the lookups. It is the system clock if
+	// nil.
+	Clock reddit.Clock
+}
+
+const defaultEditInterval = time.Minute
+
+// infoSource looks up things by their full names.
+type infoSource interface {
+	Info(names []string) (reddit.Harvest, error)
+}
+
+// watchedComment is what a comment looked like when it was last seen.
+type watchedComment struct {
+	body   string
+	edited uint64
+	until  time.Time
+}
+
+// watchList holds the comments being watched for edits. It is shared by the
+// goroutine forwarding comments, which adds them, and the one polling them.
+type watchList struct {
+	mu       sync.Mutex
+	comments map[string]*watchedComment
+}
+
+// add watches the comment until its window passes.
+func (l *watchList) add(c *reddit.Comment, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.comments[c.Name] = &watchedComment{
+		body:   c.Body,
+		edited: c.Edited,
+		until:  until,
+	}
+}
+
+// names forgets the comments whose window has passed by now, and returns the
+// names of the rest.
+func (l *watchList) names(now time.Time) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names := []string{}
+	for name, w := range l.comments {
+		if now.After(w.until) {
+			delete(l.comments, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// changed returns those of the looked up comments which changed since they
+// were last seen, and remembers them as they are now.
+func (l *watchList) changed(comments []*reddit.Comment) []*reddit.Comment {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	edited := []*reddit.Comment{}
+	for _, c := range comments {
+		w, ok := l.comments[c.Name]
+		if !ok || (c.Body == w.body && c.Edited == w.edited) {
+			continue
+		}
+
+		w.body = c.Body
+		w.edited = c.Edited
+		edited = append(edited, c)
+	}
+	return edited
+}
+
+// systemClock is the reddit.Clock of edit polling which has none.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// watchEdits forwards the comments of a stream, and looks the comments up
+// again every interval until their window passes, sending those whose body or
+// edited time changed on the returned edits stream. The lookups are made on
+// their own goroutine, so a slow one does not hold up the comments. Both
+// streams close when the comments stream closes.
+func watchEdits(
+	src infoSource,
+	comments <-chan *reddit.Comment,
+	kill <-chan bool,
+	errs chan<- error,
+	cfg EditPolling,
+) (
+	<-chan *reddit.Comment,
+	<-chan *reddit.Comment,
+) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultEditInterval
+	}
+	clock := cfg.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	forwarded := make(chan *reddit.Comment)
+	edits := make(chan *reddit.Comment)
+	watched := &watchList{comments: map[string]*watchedComment{}}
+	done := make(chan bool)
+
+	go func() {
+		defer close(forwarded)
+		defer close(done)
+
+		for {
+			select {
+			case <-kill:
+				return
+			case c, ok := <-comments:
+				if !ok {
+					return
+				}
+				watched.add(c, clock.Now().Add(cfg.Window))
+				select {
+				case <-kill:
+					return
+				case forwarded <- c:
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(edits)
+
+		for {
+			select {
+			case <-kill:
+				return
+			case <-done:
+				return
+			case <-clock.After(cfg.Interval):
+				for _, c := range pollEdits(src, watched, clock.Now(), kill, errs) {
+					select {
+					case <-kill:
+						return
+					case edits <- c:
+					}
+				}
+			}
+		}
+	}()
+
+	return forwarded, edits
+}
+
+// pollEdits forgets the watched comments whose window has passed by now, looks
+// up the rest, and returns those which changed since they were last seen. A
+// failed lookup is reported on errs unless the stream is killed first.
+func pollEdits(
+	src infoSource,
+	watched *watchList,
+	now time.Time,
+	kill <-chan bool,
+	errs chan<- error,
+) []*reddit.Comment {
+	names := watched.names(now)
+	if len(names) == 0 {
+		return nil
+	}
+
+	h, err := src.Info(names)
+	if err != nil {
+		select {
+		case <-kill:
+		case errs <- err:
+		}
+		return nil
+	}
+
+	return watched.changed(h.Comments)
+}
+
+// withEdits forwards the events of a stream and an EditEvent for each of the
+// edits, closing once both have closed.
+func withEdits(events <-chan *Event, edits <-chan *reddit.Comment) <-chan *Event {
+	merged := make(chan *Event)
+
+	go func() {
+		defer close(merged)
+		for events != nil || edits != nil {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				merged <- e
+			case c, ok := <-edits:
+				if !ok {
+					edits = nil
+					continue
+				}
+				merged <- &Event{Kind: EditEvent, Comment: c}
+			}
+		}
+	}()
+
+	return merged
+}
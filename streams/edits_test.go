@@ -0,0 +1,208 @@
+package streams
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// scriptedInfo returns the comments it holds when they are looked up.
+type scriptedInfo struct {
+	mu       sync.Mutex
+	comments map[string]*reddit.Comment
+	lookups  int
+	// err, if set, fails every lookup.
+	err error
+}
+
+func (s *scriptedInfo) set(c *reddit.Comment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.comments[c.Name] = c
+}
+
+func (s *scriptedInfo) Info(names []string) (reddit.Harvest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lookups++
+	if s.err != nil {
+		return reddit.Harvest{}, s.err
+	}
+
+	h := reddit.Harvest{}
+	for _, name := range names {
+		if c, ok := s.comments[name]; ok {
+			h.Comments = append(h.Comments, c)
+		}
+	}
+	return h, nil
+}
+
+func TestWatchEdits(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	original := &reddit.Comment{Name: "t1_a", Body: "hello"}
+	src := &scriptedInfo{comments: map[string]*reddit.Comment{"t1_a": original}}
+
+	comments := make(chan *reddit.Comment)
+	forwarded, edits := watchEdits(src, comments, kill, errs, EditPolling{
+		Window:   time.Minute,
+		Interval: 5 * time.Millisecond,
+	})
+
+	comments <- original
+	if c := <-forwarded; c != original {
+		t.Fatalf("got forwarded comment %v; wanted %v", c, original)
+	}
+
+	select {
+	case c := <-edits:
+		t.Fatalf("got edit %v of an unchanged comment", c)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	src.set(&reddit.Comment{Name: "t1_a", Body: "buy my stuff", Edited: 10})
+	select {
+	case c := <-edits:
+		if c.Body != "buy my stuff" || c.Edited != 10 {
+			t.Errorf("got edit %+v; wanted the edited comment", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("wanted an edit after the comment's body changed")
+	}
+
+	select {
+	case c := <-edits:
+		t.Errorf("got edit %v again though it did not change", c)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestWatchEditsWindow(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	src := &scriptedInfo{comments: map[string]*reddit.Comment{}}
+	comments := make(chan *reddit.Comment)
+	forwarded, _ := watchEdits(src, comments, kill, errs, EditPolling{
+		Window:   time.Millisecond,
+		Interval: 5 * time.Millisecond,
+	})
+
+	comments <- &reddit.Comment{Name: "t1_a"}
+	<-forwarded
+	time.Sleep(30 * time.Millisecond)
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.lookups != 0 {
+		t.Errorf("looked up comments %d times after their window passed", src.lookups)
+	}
+}
+
+func TestWatchEditsKilledWhileFailing(t *testing.T) {
+	kill := make(chan bool)
+	errs := make(chan error)
+
+	src := &scriptedInfo{
+		comments: map[string]*reddit.Comment{},
+		err:      fmt.Errorf("lookup failed"),
+	}
+	comments := make(chan *reddit.Comment)
+	forwarded, edits := watchEdits(src, comments, kill, errs, EditPolling{
+		Window:   time.Minute,
+		Interval: 5 * time.Millisecond,
+	})
+
+	comments <- &reddit.Comment{Name: "t1_a"}
+	<-forwarded
+	time.Sleep(20 * time.Millisecond)
+
+	// Nothing reads errs, so the failed lookup is blocked reporting.
+	close(kill)
+	select {
+	case _, ok := <-edits:
+		if ok {
+			t.Errorf("got an edit from a failing lookup")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("edit polling did not stop when killed while reporting an error")
+	}
+}
+
+// blockedInfo is an infoSource whose lookups wait until it is released.
+type blockedInfo struct {
+	looking chan bool
+	release chan bool
+}
+
+func (b *blockedInfo) Info(names []string) (reddit.Harvest, error) {
+	b.looking <- true
+	<-b.release
+	return reddit.Harvest{}, nil
+}
+
+func TestWatchEditsSlowLookup(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	clock := reddit.NewFakeClock(time.Unix(1000, 0))
+	src := &blockedInfo{looking: make(chan bool), release: make(chan bool)}
+	defer close(src.release)
+
+	comments := make(chan *reddit.Comment)
+	forwarded, _ := watchEdits(src, comments, kill, errs, EditPolling{
+		Window:   time.Hour,
+		Interval: time.Minute,
+		Clock:    clock,
+	})
+
+	comments <- &reddit.Comment{Name: "t1_a"}
+	<-forwarded
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	<-src.looking
+
+	go func() { comments <- &reddit.Comment{Name: "t1_b"} }()
+	select {
+	case c := <-forwarded:
+		if c.Name != "t1_b" {
+			t.Errorf("got forwarded comment %v; wanted t1_b", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("a slow lookup held up the comments")
+	}
+}
+
+func TestWithEdits(t *testing.T) {
+	events := make(chan *Event)
+	edits := make(chan *reddit.Comment)
+	merged := withEdits(events, edits)
+
+	go func() {
+		events <- &Event{Kind: CommentEvent, Comment: &reddit.Comment{}}
+		close(events)
+		edits <- &reddit.Comment{}
+		close(edits)
+	}()
+
+	kinds := []EventKind{}
+	for e := range merged {
+		kinds = append(kinds, e.Kind)
+	}
+
+	if len(kinds) != 2 || kinds[0] != CommentEvent || kinds[1] != EditEvent {
+		t.Errorf("got event kinds %v; wanted a comment then an edit", kinds)
+	}
+}
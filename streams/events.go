@@ -0,0 +1,313 @@
+package streams
+
+import (
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+
+	"github.com/turnage/graw/streams/internal/monitor"
+)
+
+// EventKind is the kind of element an Event carries.
+type EventKind int
+
+const (
+	// PostEvent is a new post.
+	PostEvent EventKind = iota
+	// CommentEvent is a new comment.
+	CommentEvent
+	// MessageEvent is a new message in the bot's inbox.
+	MessageEvent
+	// EditEvent is a comment which was edited after it was delivered, as
+	// it reads now. It is carried in Comment.
+	EditEvent
+)
+
+// Event is a new element from one of the streams merged by Events. The field
+// for its Kind is set and the others are nil; EditEvents set Comment.
+type Event struct {
+	Kind    EventKind
+	Post    *reddit.Post
+	Comment *reddit.Comment
+	Message *reddit.Message
+}
+
+// EventConfig chooses the streams Events merges.
+type EventConfig struct {
+	// Subreddits are the subreddits to stream new posts from.
+	Subreddits []string
+	// SubredditComments are the subreddits to stream new comments from.
+	SubredditComments []string
+	// CommentEdits, if set, watches the comments streamed from
+	// SubredditComments, and sends an EditEvent each time one's body or
+	// edited time changes within the window.
+	CommentEdits *EditPolling
+
+	// Messages streams the private messages sent to the bot.
+	Messages bool
+	// Mentions streams the mentions of the bot's username.
+	Mentions bool
+	// PostReplies streams the replies to the bot's posts.
+	PostReplies bool
+	// CommentReplies streams the replies to the bot's comments.
+	CommentReplies bool
+}
+
+// Events returns one stream of the new elements of all the streams the config
+// chooses, so a bot can handle them in one loop. Each of those streams
+// consumes its intervals of the handle as it would alone, and errors from any
+// of them are sent on the errors channel without stopping the others. The
+// stream closes when all of them have been killed.
+func Events(
+	bot reddit.Bot,
+	kill <-chan bool,
+	errs chan<- error,
+	config EventConfig,
+) (
+	<-chan *Event,
+	error,
+) {
+	return Config{}.Events(bot, kill, errs, config)
+}
+
+// Events starts the stream Events does, configured by c.
+func (c Config) Events(
+	bot reddit.Bot,
+	kill <-chan bool,
+	errs chan<- error,
+	config EventConfig,
+) (
+	<-chan *Event,
+	error,
+) {
+	posts := []<-chan *reddit.Post{}
+	comments := []<-chan *reddit.Comment{}
+	messages := []<-chan *reddit.Message{}
+	var edits <-chan *reddit.Comment
+
+	// The streams are started with their own kill channel, so those
+	// already started can be killed if a later one fails to start. Their
+	// feeds are drained so none is left blocked on a send.
+	local := make(chan bool)
+	fail := func(err error) (<-chan *Event, error) {
+		close(local)
+		go func() {
+			for range mergeEvents(posts, comments, messages) {
+			}
+		}()
+		return nil, err
+	}
+
+	if len(config.Subreddits) != 0 {
+		feed, err := c.Subreddits(bot, local, errs, config.Subreddits...)
+		if err != nil {
+			return fail(err)
+		}
+		posts = append(posts, feed)
+	}
+
+	if len(config.SubredditComments) != 0 {
+		feed, err := c.SubredditComments(
+			bot, local, errs, config.SubredditComments...,
+		)
+		if err != nil {
+			return fail(err)
+		}
+		if config.CommentEdits != nil {
+			feed, edits = watchEdits(
+				bot, feed, local, errs, *config.CommentEdits,
+			)
+		}
+		comments = append(comments, feed)
+	}
+
+	for _, inbox := range []struct {
+		enabled bool
+		stream  func(reddit.Bot, <-chan bool, chan<- error) (
+			<-chan *reddit.Message,
+			error,
+		)
+	}{
+		{config.Messages, c.Messages},
+		{config.Mentions, c.Mentions},
+		{config.PostReplies, c.PostReplies},
+		{config.CommentReplies, c.CommentReplies},
+	} {
+		if !inbox.enabled {
+			continue
+		}
+
+		feed, err := inbox.stream(bot, local, errs)
+		if err != nil {
+			return fail(err)
+		}
+		messages = append(messages, feed)
+	}
+
+	go forwardKill(kill, local)
+
+	events := mergeEvents(posts, comments, messages)
+	if edits != nil {
+		events = withEdits(events, edits)
+	}
+	return events, nil
+}
+
+// mergeEvents forwards the elements of all of the feeds into one stream of
+// events, which closes when they have all closed.
+func mergeEvents(
+	posts []<-chan *reddit.Post,
+	comments []<-chan *reddit.Comment,
+	messages []<-chan *reddit.Message,
+) <-chan *Event {
+	events := make(chan *Event)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(posts) + len(comments) + len(messages))
+
+	for _, feed := range posts {
+		go func(feed <-chan *reddit.Post) {
+			defer wg.Done()
+			for p := range feed {
+				events <- &Event{Kind: PostEvent, Post: p}
+			}
+		}(feed)
+	}
+	for _, feed := range comments {
+		go func(feed <-chan *reddit.Comment) {
+			defer wg.Done()
+			for c := range feed {
+				events <- &Event{Kind: CommentEvent, Comment: c}
+			}
+		}(feed)
+	}
+	for _, feed := range messages {
+		go func(feed <-chan *reddit.Message) {
+			defer wg.Done()
+			for m := range feed {
+				events <- &Event{Kind: MessageEvent, Message: m}
+			}
+		}(feed)
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	return events
+}
+
+// seenMemory is how many of the names of the elements it has delivered a
+// deduplicating stream remembers.
+const seenMemory = 1000
+
+// UserEvents returns a stream of the new posts and comments a user makes
+// anywhere on Reddit, from their overview listing, each delivered once. It
+// polls every interval, or as User streams do if interval is zero, and
+// consumes one interval of the handle per poll.
+//
+// If the user's overview becomes unreadable, e.g. because their profile goes
+// private or they are suspended, the errors are sent on the errors channel
+// and the stream keeps polling until it is killed, so it picks up again if
+// the profile comes back.
+func UserEvents(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	user string,
+	interval time.Duration,
+) (
+	<-chan *Event,
+	error,
+) {
+	return Config{}.UserEvents(scanner, kill, errs, user, interval)
+}
+
+// UserEvents starts the stream UserEvents does, configured by c.
+func (c Config) UserEvents(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	user string,
+	interval time.Duration,
+) (
+	<-chan *Event,
+	error,
+) {
+	path := "/user/" + user + "/overview"
+	mon, err := c.monitorFromPath(path, scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	pace := c.newPacer(path)
+	if interval > 0 {
+		pace = &pacer{
+			path:     path,
+			cfg:      AdaptivePolling{Min: interval, Max: interval},
+			interval: interval,
+		}
+	}
+
+	return userEvents(mon, pace, c.newSpread(), kill, errs), nil
+}
+
+func userEvents(
+	mon monitor.Monitor,
+	pace *pacer,
+	spread spread,
+	kill <-chan bool,
+	errs chan<- error,
+) <-chan *Event {
+	posts, comments, messages := stream(mon, pace, spread, kill, errs)
+	return dedupEvents(
+		mergeEvents(
+			[]<-chan *reddit.Post{posts},
+			[]<-chan *reddit.Comment{comments},
+			[]<-chan *reddit.Message{messages},
+		),
+		seenMemory,
+	)
+}
+
+// dedupEvents forwards the events of a stream, leaving out those whose
+// element has the same name as one of the last memory elements forwarded.
+func dedupEvents(events <-chan *Event, memory int) <-chan *Event {
+	deduped := make(chan *Event)
+
+	go func() {
+		defer close(deduped)
+
+		seen := map[string]bool{}
+		order := []string{}
+		for e := range events {
+			name := e.name()
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			order = append(order, name)
+			if len(order) > memory {
+				delete(seen, order[0])
+				order = order[1:]
+			}
+
+			deduped <- e
+		}
+	}()
+
+	return deduped
+}
+
+// name returns the full name of the event's element.
+func (e *Event) name() string {
+	switch e.Kind {
+	case PostEvent:
+		return e.Post.Name
+	case CommentEvent, EditEvent:
+		return e.Comment.Name
+	}
+	return e.Message.Name
+}
@@ -0,0 +1,205 @@
+package streams
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestMergeEvents(t *testing.T) {
+	kill := make(chan bool)
+	errs := make(chan error)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	posts, _, _ := stream(
+		&mockMonitor{h: reddit.Harvest{Posts: []*reddit.Post{{Title: "title"}}}},
+		nil, spread{}, kill, errs,
+	)
+	_, comments, _ := stream(
+		&mockMonitor{h: reddit.Harvest{Comments: []*reddit.Comment{{Body: "comment"}}}},
+		nil, spread{}, kill, errs,
+	)
+	_, _, messages := stream(
+		&mockMonitor{h: reddit.Harvest{Messages: []*reddit.Message{{Body: "message"}}}},
+		nil, spread{}, kill, errs,
+	)
+	_, _, failing := stream(&mockMonitor{err: fmt.Errorf("an error")}, nil, spread{}, kill, errs)
+
+	events := mergeEvents(
+		[]<-chan *reddit.Post{posts},
+		[]<-chan *reddit.Comment{comments},
+		[]<-chan *reddit.Message{failing, messages},
+	)
+
+	seen := map[EventKind]bool{}
+	timeout := time.After(time.Second)
+	for len(seen) < 3 {
+		select {
+		case e := <-events:
+			switch {
+			case e.Kind == PostEvent && e.Post.Title == "title",
+				e.Kind == CommentEvent && e.Comment.Body == "comment",
+				e.Kind == MessageEvent && e.Message.Body == "message":
+				seen[e.Kind] = true
+			default:
+				t.Fatalf("got malformed event %+v", e)
+			}
+		case <-timeout:
+			t.Fatalf("events did not come from all sources; got %v", seen)
+		}
+	}
+
+	close(kill)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("events did not close after kill")
+		}
+	}
+}
+
+// scriptedMonitor returns its updates in order, and empty harvests after.
+type scriptedMonitor struct {
+	updates []mockMonitor
+}
+
+func (s *scriptedMonitor) Update() (reddit.Harvest, error) {
+	if len(s.updates) == 0 {
+		return reddit.Harvest{}, nil
+	}
+
+	next := s.updates[0]
+	s.updates = s.updates[1:]
+	return next.h, next.err
+}
+
+func TestUserEvents(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	suspended := &reddit.UserSuspendedError{User: "gopher"}
+	mon := &scriptedMonitor{
+		updates: []mockMonitor{
+			{h: reddit.Harvest{
+				Posts:    []*reddit.Post{{Name: "t3_a"}},
+				Comments: []*reddit.Comment{{Name: "t1_b"}},
+			}},
+			{err: suspended},
+			{h: reddit.Harvest{
+				Posts:    []*reddit.Post{{Name: "t3_a"}, {Name: "t3_c"}},
+				Comments: []*reddit.Comment{{Name: "t1_b"}},
+			}},
+		},
+	}
+
+	events := userEvents(mon, nil, spread{}, kill, errs)
+
+	names := map[string]int{}
+	timeout := time.After(time.Second)
+	for len(names) < 3 {
+		select {
+		case e := <-events:
+			names[e.name()]++
+		case <-timeout:
+			t.Fatalf("got events %v; wanted t3_a, t1_b and t3_c", names)
+		}
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("got duplicate or unexpected event %s", e.name())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	select {
+	case err := <-errs:
+		if err != suspended {
+			t.Errorf("wanted the suspended error; got %v", err)
+		}
+	default:
+		t.Errorf("wanted the suspended error on the errors channel")
+	}
+}
+
+func TestDedupEventsForgets(t *testing.T) {
+	events := make(chan *Event)
+	deduped := dedupEvents(events, 1)
+
+	go func() {
+		for _, name := range []string{"t3_a", "t3_a", "t3_b", "t3_a"} {
+			events <- &Event{Kind: PostEvent, Post: &reddit.Post{Name: name}}
+		}
+		close(events)
+	}()
+
+	got := []string{}
+	for e := range deduped {
+		got = append(got, e.name())
+	}
+
+	if fmt.Sprint(got) != "[t3_a t3_b t3_a]" {
+		t.Errorf("got %v; wanted [t3_a t3_b t3_a]", got)
+	}
+}
+
+// inboxlessBot serves empty subreddit listings, counting its requests, and
+// fails to read its inbox.
+type inboxlessBot struct {
+	reddit.Bot
+	mu    sync.Mutex
+	polls int
+}
+
+func (b *inboxlessBot) Listing(path, after string) (reddit.Harvest, error) {
+	if strings.HasPrefix(path, "/message/") {
+		return reddit.Harvest{}, fmt.Errorf("no inbox")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.polls++
+	return reddit.Harvest{}, nil
+}
+
+func (b *inboxlessBot) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.polls
+}
+
+func TestEventsKillsStartedOnFailure(t *testing.T) {
+	bot := &inboxlessBot{}
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	if _, err := Events(bot, kill, errs, EventConfig{
+		Subreddits: []string{"golang"},
+		Messages:   true,
+	}); err == nil {
+		t.Fatalf("wanted error from the inbox stream")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	polls := bot.count()
+	time.Sleep(20 * time.Millisecond)
+	if bot.count() != polls {
+		t.Errorf("subreddit stream kept polling after Events failed")
+	}
+}
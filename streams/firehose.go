@@ -0,0 +1,211 @@
+package streams
+
+import (
+	"sort"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+
+	"github.com/turnage/graw/streams/internal/monitor"
+)
+
+const defaultFirehoseWindow = 10 * time.Second
+
+// CommentFirehose returns a flat stream of the new comments in a subreddit in
+// the order they were made, each delivered once. It polls every interval, or
+// as SubredditComments streams do if interval is zero, and consumes one
+// interval of the handle per poll.
+//
+// Comments are held for window after they arrive, or for 10 seconds if window
+// is zero, so comments which arrive out of order can be put back in order.
+// Each is delivered once its window has passed, after any held comments made
+// before it. A comment which arrives more than the window later than comments
+// made after it is still delivered, out of order.
+func CommentFirehose(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	subreddit string,
+	interval time.Duration,
+	window time.Duration,
+) (
+	<-chan *reddit.Comment,
+	error,
+) {
+	return Config{}.CommentFirehose(
+		scanner, kill, errs, subreddit, interval, window,
+	)
+}
+
+// CommentFirehose starts the stream CommentFirehose does, configured by c.
+func (c Config) CommentFirehose(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	subreddit string,
+	interval time.Duration,
+	window time.Duration,
+) (
+	<-chan *reddit.Comment,
+	error,
+) {
+	paths, err := reddit.SplitMultireddit([]string{subreddit})
+	if err != nil {
+		return nil, err
+	}
+
+	path := paths[0] + "/comments"
+	mon, err := c.monitorFromPath(path, scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	pace := c.newPacer(path)
+	if interval > 0 {
+		pace = &pacer{
+			path:     path,
+			cfg:      AdaptivePolling{Min: interval, Max: interval},
+			interval: interval,
+		}
+	}
+
+	if window <= 0 {
+		window = defaultFirehoseWindow
+	}
+
+	return commentFirehose(mon, pace, c.newSpread(), kill, errs, window), nil
+}
+
+func commentFirehose(
+	mon monitor.Monitor,
+	pace *pacer,
+	spread spread,
+	kill <-chan bool,
+	errs chan<- error,
+	window time.Duration,
+) <-chan *reddit.Comment {
+	posts, comments, messages := stream(mon, pace, spread, kill, errs)
+	events := dedupEvents(
+		mergeEvents(
+			[]<-chan *reddit.Post{posts},
+			[]<-chan *reddit.Comment{comments},
+			[]<-chan *reddit.Message{messages},
+		),
+		seenMemory,
+	)
+
+	deduped := make(chan *reddit.Comment)
+	go func() {
+		defer close(deduped)
+		for e := range events {
+			if e.Kind == CommentEvent {
+				deduped <- e.Comment
+			}
+		}
+	}()
+
+	return reorderComments(deduped, window)
+}
+
+// heldComment is a comment a firehose holds until its window passes.
+type heldComment struct {
+	comment *reddit.Comment
+	due     time.Time
+}
+
+// reorderComments forwards the comments of a stream, holding each for the
+// window and releasing it after the held comments made before it. The stream
+// closes once the comments it holds when its input closes are released.
+func reorderComments(
+	comments <-chan *reddit.Comment,
+	window time.Duration,
+) <-chan *reddit.Comment {
+	ordered := make(chan *reddit.Comment)
+
+	go func() {
+		defer close(ordered)
+
+		// held is in order of arrival, so the first comment is always
+		// the next to come due.
+		held := []heldComment{}
+		for {
+			var due <-chan time.Time
+			var timer *time.Timer
+			if len(held) != 0 {
+				timer = time.NewTimer(time.Until(held[0].due))
+				due = timer.C
+			}
+
+			select {
+			case c, ok := <-comments:
+				if !ok {
+					for _, c := range sortedComments(held) {
+						ordered <- c
+					}
+					return
+				}
+				held = append(held, heldComment{
+					comment: c,
+					due:     time.Now().Add(window),
+				})
+			case <-due:
+				var released []*reddit.Comment
+				released, held = releaseComments(held)
+				for _, c := range released {
+					ordered <- c
+				}
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}()
+
+	return ordered
+}
+
+// releaseComments releases the first held comment, which is due, and every
+// held comment made before it, in the order they were made. It returns the
+// released comments and those still held.
+func releaseComments(held []heldComment) ([]*reddit.Comment, []heldComment) {
+	due := held[0].comment
+
+	release := []heldComment{}
+	keep := []heldComment{}
+	for _, h := range held {
+		if h.comment == due || !commentBefore(due, h.comment) {
+			release = append(release, h)
+		} else {
+			keep = append(keep, h)
+		}
+	}
+
+	return sortedComments(release), keep
+}
+
+// sortedComments returns the held comments in the order they were made.
+func sortedComments(held []heldComment) []*reddit.Comment {
+	comments := make([]*reddit.Comment, len(held))
+	for i, h := range held {
+		comments[i] = h.comment
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return commentBefore(comments[i], comments[j])
+	})
+	return comments
+}
+
+// commentBefore returns whether comment a was made before comment b. Comments
+// made in the same second are ordered by their ids, which Reddit assigns in
+// increasing order.
+func commentBefore(a, b *reddit.Comment) bool {
+	if a.CreatedUTC != b.CreatedUTC {
+		return a.CreatedUTC < b.CreatedUTC
+	}
+	if len(a.ID) != len(b.ID) {
+		return len(a.ID) < len(b.ID)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,100 @@
+package streams
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestCommentFirehose(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	comment := func(id string, created uint64) *reddit.Comment {
+		return &reddit.Comment{ID: id, Name: "t1_" + id, CreatedUTC: created}
+	}
+	mon := &scriptedMonitor{
+		updates: []mockMonitor{
+			{h: reddit.Harvest{
+				Comments: []*reddit.Comment{comment("c", 30), comment("a", 10)},
+			}},
+			{h: reddit.Harvest{
+				Comments: []*reddit.Comment{
+					comment("a", 10), comment("e", 30), comment("b", 20),
+				},
+			}},
+			{h: reddit.Harvest{
+				Comments: []*reddit.Comment{comment("d", 30)},
+			}},
+		},
+	}
+
+	comments := commentFirehose(mon, nil, spread{}, kill, errs, 50*time.Millisecond)
+
+	got := []string{}
+	timeout := time.After(time.Second)
+	for len(got) < 5 {
+		select {
+		case c := <-comments:
+			got = append(got, c.ID)
+		case <-timeout:
+			t.Fatalf("got comments %v; wanted a through e", got)
+		}
+	}
+
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got comments %v; wanted %v", got, want)
+	}
+
+	select {
+	case c := <-comments:
+		t.Errorf("got duplicate or unexpected comment %s", c.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestReorderCommentsHoldsForWindow(t *testing.T) {
+	in := make(chan *reddit.Comment)
+	out := reorderComments(in, time.Hour)
+
+	in <- &reddit.Comment{ID: "b", CreatedUTC: 20}
+	in <- &reddit.Comment{ID: "a", CreatedUTC: 10}
+	select {
+	case c := <-out:
+		t.Fatalf("comment %s was released before its window passed", c.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(in)
+	got := []string{}
+	for c := range out {
+		got = append(got, c.ID)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got comments %v when the input closed; wanted %v", got, want)
+	}
+}
+
+func TestReleaseComments(t *testing.T) {
+	held := []heldComment{
+		{comment: &reddit.Comment{ID: "c", CreatedUTC: 20}},
+		{comment: &reddit.Comment{ID: "d", CreatedUTC: 30}},
+		{comment: &reddit.Comment{ID: "a", CreatedUTC: 10}},
+		{comment: &reddit.Comment{ID: "b", CreatedUTC: 20}},
+	}
+
+	released, kept := releaseComments(held)
+	got := []string{}
+	for _, c := range released {
+		got = append(got, c.ID)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("released %v; wanted %v", got, want)
+	}
+	if len(kept) != 1 || kept[0].comment.ID != "d" {
+		t.Errorf("kept %v; wanted only d", kept)
+	}
+}
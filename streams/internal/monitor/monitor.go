@@ -16,6 +16,13 @@ const (
 	// maxTipSize is the maximum size of the tip log (number of backup tips
 	// + the current tip).
 	maxTipSize = 20
+	// listingLimit is the number of elements the scanner's Listing asks
+	// Reddit for. A page that full likely means more new elements were
+	// left out of it.
+	listingLimit = 100
+	// maxGapPages is the most extra pages an update will fetch to fill a
+	// gap, so a flood of new elements can't hold the monitor forever.
+	maxGapPages = 10
 )
 
 // defaultTip is the blank reference point in a Reddit listing, which asks for
@@ -39,6 +46,10 @@ type Config struct {
 
 	// Sorter sorts the monitor's new listing elements.
 	Sorter rsort.Sorter
+
+	// OnGap, if set, is called when an update returns a full page and the
+	// monitor fetches the given number of further pages to fill the gap.
+	OnGap func(path string, pages int)
 }
 
 type monitor struct {
@@ -55,15 +66,17 @@ type monitor struct {
 
 	scanner reddit.Scanner
 	sorter  rsort.Sorter
+	onGap   func(string, int)
 }
 
 // New provides a monitor for the listing endpoint.
 func New(c Config) (Monitor, error) {
 	m := &monitor{
-		tip:            []string{""},
-		path:           c.Path,
-		scanner:        c.Scanner,
-		sorter:         c.Sorter,
+		tip:     []string{""},
+		path:    c.Path,
+		scanner: c.Scanner,
+		sorter:  c.Sorter,
+		onGap:   c.OnGap,
 	}
 
 	if err := m.sync(); err != nil {
@@ -81,10 +94,57 @@ func (m *monitor) Update() (reddit.Harvest, error) {
 	}
 
 	names, harvest, err := m.harvest(m.tip[0])
+	if err == nil && full(harvest) && len(names) > 0 {
+		names, harvest = m.fillGap(names, harvest)
+	}
 	m.updateTip(names)
 	return harvest, err
 }
 
+// fillGap fetches the pages of new elements Reddit left out of a full
+// harvest, by asking for the elements after the newest one until a page is
+// not full. If one of those requests fails, what was gathered is kept, and the
+// next update continues from the newest element found.
+func (m *monitor) fillGap(names []string, h reddit.Harvest) (
+	[]string,
+	reddit.Harvest,
+) {
+	page := h
+	pages := 0
+	for ; full(page) && pages < maxGapPages; pages++ {
+		pageNames, next, err := m.harvest(names[0])
+		if err != nil || len(pageNames) == 0 {
+			break
+		}
+
+		page = next
+		names = append(pageNames, names...)
+
+		// lol no generics
+		h.Posts = append(page.Posts, h.Posts...)
+		h.Comments = append(page.Comments, h.Comments...)
+		h.Messages = append(page.Messages, h.Messages...)
+		h.Mores = append(page.Mores, h.Mores...)
+	}
+
+	if pages > 0 && m.onGap != nil {
+		m.onGap(m.path, pages)
+	}
+
+	return names, h
+}
+
+// full returns whether a harvest holds as many elements as a listing request
+// asks for. Since every element after the tip is new, that means there may be
+// more new elements than Reddit returned.
+func full(h reddit.Harvest) bool {
+	dist := h.Dist
+	if dist == 0 {
+		dist = len(h.Posts) + len(h.Comments) + len(h.Messages)
+	}
+	return dist >= listingLimit
+}
+
 // harvest fetches from the listing any posts after the given reference post,
 // and returns those posts and a reverse chronologically sorted list of their
 // names.
@@ -3,6 +3,7 @@ package monitor
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/turnage/graw/reddit"
 )
@@ -13,11 +14,26 @@ func (m *mockScanner) Listing(_, _ string) (reddit.Harvest, error) {
 	return reddit.Harvest{}, nil
 }
 
-
 func (m *mockScanner) ListingWithParams(_ string, _ map[string]string) (reddit.Harvest, error) {
 	return reddit.Harvest{}, nil
 }
 
+func (m *mockScanner) WindowedCollect(_ string, _, _ time.Time) ([]*reddit.Post, error) {
+	return nil, nil
+}
+
+func (m *mockScanner) Search(_ reddit.SearchOptions) (reddit.Harvest, error) {
+	return reddit.Harvest{}, nil
+}
+
+func (m *mockScanner) FrontPage(_ map[string]string) ([]*reddit.Post, string, error) {
+	return nil, "", nil
+}
+
+func (m *mockScanner) Best(_ map[string]string) ([]*reddit.Post, string, error) {
+	return nil, "", nil
+}
+
 type mockSorter struct {
 	names []string
 }
@@ -47,10 +63,10 @@ func TestNew(t *testing.T) {
 
 func TestShaveTip(t *testing.T) {
 	m := &monitor{
-		blanks:         5,
-		tip:            []string{"1", "2"},
-		scanner:        &mockScanner{},
-		sorter:         &mockSorter{},
+		blanks:  5,
+		tip:     []string{"1", "2"},
+		scanner: &mockScanner{},
+		sorter:  &mockSorter{},
 	}
 
 	_, err := m.Update()
@@ -70,10 +86,10 @@ func TestShaveTip(t *testing.T) {
 
 func TestStoreTip(t *testing.T) {
 	m := &monitor{
-		blanks:         0,
-		tip:            []string{"1", "2"},
-		scanner:        &mockScanner{},
-		sorter:         &mockSorter{[]string{"0"}},
+		blanks:  0,
+		tip:     []string{"1", "2"},
+		scanner: &mockScanner{},
+		sorter:  &mockSorter{[]string{"0"}},
 	}
 
 	_, err := m.Update()
@@ -93,10 +109,10 @@ func TestStoreTip(t *testing.T) {
 
 func TestBackoff(t *testing.T) {
 	m := &monitor{
-		blanks:         6,
-		tip:            []string{"1", "2"},
-		scanner:        &mockScanner{},
-		sorter:         &mockSorter{names: []string{"1", "2"}},
+		blanks:  6,
+		tip:     []string{"1", "2"},
+		scanner: &mockScanner{},
+		sorter:  &mockSorter{names: []string{"1", "2"}},
 	}
 
 	_, err := m.Update()
@@ -116,10 +132,10 @@ func TestBackoff(t *testing.T) {
 
 func TestTipFilter(t *testing.T) {
 	m := &monitor{
-		blanks:         6,
-		tip:            []string{"1", "2", "3", "4"},
-		scanner:        &mockScanner{},
-		sorter:         &mockSorter{names: []string{"2", "4"}},
+		blanks:  6,
+		tip:     []string{"1", "2", "3", "4"},
+		scanner: &mockScanner{},
+		sorter:  &mockSorter{names: []string{"2", "4"}},
 	}
 
 	_, err := m.Update()
@@ -139,10 +155,10 @@ func TestTipFilter(t *testing.T) {
 
 func TestTipStaysNonNil(t *testing.T) {
 	m := &monitor{
-		blanks:         2,
-		tip:            []string{""},
-		scanner:        &mockScanner{},
-		sorter:         &mockSorter{names: []string{}},
+		blanks:  2,
+		tip:     []string{""},
+		scanner: &mockScanner{},
+		sorter:  &mockSorter{names: []string{}},
 	}
 
 	_, err := m.Update()
@@ -157,3 +173,123 @@ func TestTipStaysNonNil(t *testing.T) {
 		t.Errorf("error in second update: %v", err)
 	}
 }
+
+// gapScanner returns the harvests it holds for each reference point, and
+// records the reference points it is asked for.
+type gapScanner struct {
+	pages map[string]reddit.Harvest
+	refs  []string
+}
+
+func (g *gapScanner) Listing(_, after string) (reddit.Harvest, error) {
+	g.refs = append(g.refs, after)
+	return g.pages[after], nil
+}
+
+func (g *gapScanner) ListingWithParams(_ string, _ map[string]string) (reddit.Harvest, error) {
+	return reddit.Harvest{}, nil
+}
+
+func (g *gapScanner) WindowedCollect(_ string, _, _ time.Time) ([]*reddit.Post, error) {
+	return nil, nil
+}
+
+func (g *gapScanner) Search(_ reddit.SearchOptions) (reddit.Harvest, error) {
+	return reddit.Harvest{}, nil
+}
+
+func (g *gapScanner) FrontPage(_ map[string]string) ([]*reddit.Post, string, error) {
+	return nil, "", nil
+}
+
+func (g *gapScanner) Best(_ map[string]string) ([]*reddit.Post, string, error) {
+	return nil, "", nil
+}
+
+// postSorter returns the names of the posts in a harvest in listing order.
+type postSorter struct{}
+
+func (p *postSorter) Sort(h reddit.Harvest) []string {
+	names := []string{}
+	for _, post := range h.Posts {
+		names = append(names, post.Name)
+	}
+	return names
+}
+
+func TestFillGap(t *testing.T) {
+	scanner := &gapScanner{
+		pages: map[string]reddit.Harvest{
+			"1": reddit.Harvest{
+				Posts: []*reddit.Post{&reddit.Post{Name: "3"}, &reddit.Post{Name: "2"}},
+				Dist:  listingLimit,
+			},
+			"3": reddit.Harvest{
+				Posts: []*reddit.Post{&reddit.Post{Name: "4"}},
+				Dist:  1,
+			},
+		},
+	}
+
+	gaps := 0
+	m := &monitor{
+		tip:     []string{"1"},
+		path:    "/r/golang/new",
+		scanner: scanner,
+		sorter:  &postSorter{},
+		onGap: func(path string, pages int) {
+			gaps += pages
+			if path != "/r/golang/new" {
+				t.Errorf("gap reported for wrong path %q", path)
+			}
+		},
+	}
+
+	h, err := m.Update()
+	if err != nil {
+		t.Fatalf("error in update: %v", err)
+	}
+
+	if len(h.Posts) != 3 || h.Posts[0].Name != "4" {
+		t.Errorf("wanted gap filled with newest first; got %v", h.Posts)
+	}
+
+	if gaps != 1 {
+		t.Errorf("wanted 1 gap page reported; got %d", gaps)
+	}
+
+	expected := []string{"4", "3", "2", "1"}
+	if !reflect.DeepEqual(m.tip, expected) {
+		t.Errorf("wanted tip at newest post; got %v", m.tip)
+	}
+
+	if !reflect.DeepEqual(scanner.refs, []string{"1", "3"}) {
+		t.Errorf("requested wrong pages: %v", scanner.refs)
+	}
+}
+
+func TestNoGapForPartialPage(t *testing.T) {
+	scanner := &gapScanner{
+		pages: map[string]reddit.Harvest{
+			"1": reddit.Harvest{
+				Posts: []*reddit.Post{&reddit.Post{Name: "2"}},
+				Dist:  1,
+			},
+		},
+	}
+
+	m := &monitor{
+		tip:     []string{"1"},
+		scanner: scanner,
+		sorter:  &postSorter{},
+		onGap:   func(_ string, _ int) { t.Errorf("reported gap for partial page") },
+	}
+
+	if _, err := m.Update(); err != nil {
+		t.Fatalf("error in update: %v", err)
+	}
+
+	if len(scanner.refs) != 1 {
+		t.Errorf("wanted one request; got %v", scanner.refs)
+	}
+}
@@ -0,0 +1,163 @@
+package streams
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// defaultLiveInterval is how often a LiveThread stream polls if no interval
+// is given.
+const defaultLiveInterval = 10 * time.Second
+
+// liveSource is where a LiveThread stream reads a live thread from.
+type liveSource interface {
+	LiveThread(id string) (*reddit.LiveThread, error)
+	LiveUpdates(id string) ([]*reddit.LiveUpdate, error)
+}
+
+// LiveThread returns a stream of the new updates posted to a live thread, each
+// delivered once in the order they were posted. It polls the thread's updates
+// over HTTP every interval, or every 10 seconds if interval is zero, as a
+// fallback for Reddit's websocket feed of them.
+//
+// Updates posted before the stream starts are not delivered. The stream
+// closes when the thread is closed, once it has delivered the thread's last
+// updates.
+func LiveThread(
+	lurker reddit.Lurker,
+	kill <-chan bool,
+	errs chan<- error,
+	id string,
+	interval time.Duration,
+) (
+	<-chan *reddit.LiveUpdate,
+	error,
+) {
+	return Config{}.LiveThread(lurker, kill, errs, id, interval)
+}
+
+// LiveThread starts the stream LiveThread does, configured by c.
+func (c Config) LiveThread(
+	lurker reddit.Lurker,
+	kill <-chan bool,
+	errs chan<- error,
+	id string,
+	interval time.Duration,
+) (
+	<-chan *reddit.LiveUpdate,
+	error,
+) {
+	if interval <= 0 {
+		interval = defaultLiveInterval
+	}
+
+	return liveThread(lurker, kill, errs, id, interval, c.newSpread())
+}
+
+func liveThread(
+	src liveSource,
+	kill <-chan bool,
+	errs chan<- error,
+	id string,
+	interval time.Duration,
+	spread spread,
+) (
+	<-chan *reddit.LiveUpdate,
+	error,
+) {
+	if id == "" {
+		return nil, fmt.Errorf("empty live thread id")
+	}
+
+	if _, err := src.LiveThread(id); err != nil {
+		return nil, err
+	}
+
+	updates, err := src.LiveUpdates(id)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := newLiveMemory(seenMemory)
+	seen.fresh(updates)
+
+	live := make(chan *reddit.LiveUpdate)
+	go func() {
+		defer close(live)
+
+		for {
+			select {
+			case <-kill:
+				return
+			case <-time.After(interval + spread.delay()):
+			}
+
+			updates, err := src.LiveUpdates(id)
+			if err != nil {
+				errs <- err
+				continue
+			}
+
+			fresh := seen.fresh(updates)
+			for _, u := range fresh {
+				select {
+				case <-kill:
+					return
+				case live <- u:
+				}
+			}
+
+			// A thread is only checked for closing once it goes quiet,
+			// so the updates posted last before it closed are not
+			// missed.
+			if len(fresh) != 0 {
+				continue
+			}
+
+			thread, err := src.LiveThread(id)
+			if err != nil {
+				errs <- err
+			} else if thread.Complete() {
+				return
+			}
+		}
+	}()
+
+	return live, nil
+}
+
+// liveMemory remembers the ids of the last live updates a stream saw.
+type liveMemory struct {
+	memory int
+	seen   map[string]bool
+	order  []string
+}
+
+func newLiveMemory(memory int) *liveMemory {
+	return &liveMemory{memory: memory, seen: map[string]bool{}}
+}
+
+// fresh returns the updates, newest first as Reddit lists them, which were not
+// seen before, oldest first. It remembers them as seen.
+func (m *liveMemory) fresh(updates []*reddit.LiveUpdate) []*reddit.LiveUpdate {
+	fresh := []*reddit.LiveUpdate{}
+	for i := len(updates) - 1; i >= 0; i-- {
+		u := updates[i]
+		if m.seen[u.ID] {
+			continue
+		}
+
+		m.seen[u.ID] = true
+		m.order = append(m.order, u.ID)
+		if len(m.order) > m.memory {
+			delete(m.seen, m.order[0])
+			m.order = m.order[1:]
+		}
+
+		fresh = append(fresh, u)
+	}
+
+	return fresh
+}
@@ -0,0 +1,122 @@
+package streams
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// scriptedLive is a live thread whose pages of updates are returned in turn,
+// the last one repeating, and which is closed once they run out.
+type scriptedLive struct {
+	mu    sync.Mutex
+	pages [][]*reddit.LiveUpdate
+	err   error
+	polls int
+}
+
+func (s *scriptedLive) LiveThread(id string) (*reddit.LiveThread, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.polls >= len(s.pages) {
+		return &reddit.LiveThread{ID: id, State: "complete"}, nil
+	}
+	return &reddit.LiveThread{ID: id, State: "live"}, nil
+}
+
+func (s *scriptedLive) LiveUpdates(id string) ([]*reddit.LiveUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	page := s.pages[len(s.pages)-1]
+	if s.polls < len(s.pages) {
+		page = s.pages[s.polls]
+	}
+	s.polls++
+	return page, nil
+}
+
+func liveUpdates(ids ...string) []*reddit.LiveUpdate {
+	updates := []*reddit.LiveUpdate{}
+	for _, id := range ids {
+		updates = append(updates, &reddit.LiveUpdate{ID: id})
+	}
+	return updates
+}
+
+func TestLiveThread(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	src := &scriptedLive{pages: [][]*reddit.LiveUpdate{
+		liveUpdates("a"),
+		liveUpdates("c", "b", "a"),
+		liveUpdates("d", "c", "b"),
+	}}
+
+	live, err := liveThread(src, kill, errs, "thread", time.Millisecond, spread{})
+	if err != nil {
+		t.Fatalf("failed to start live thread stream: %v", err)
+	}
+
+	got := []string{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case u, ok := <-live:
+			if !ok {
+				if want := []string{"b", "c", "d"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got updates %v; wanted %v", got, want)
+				}
+				return
+			}
+			got = append(got, u.ID)
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-timeout:
+			t.Fatalf("stream did not close with the thread; got updates %v", got)
+		}
+	}
+}
+
+func TestLiveThreadErrors(t *testing.T) {
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	if _, err := liveThread(&scriptedLive{}, kill, errs, "", time.Millisecond, spread{}); err == nil {
+		t.Errorf("wanted error for empty live thread id")
+	}
+
+	src := &scriptedLive{err: reddit.PermissionDeniedErr}
+	if _, err := liveThread(src, kill, errs, "thread", time.Millisecond, spread{}); err != reddit.PermissionDeniedErr {
+		t.Errorf("wanted PermissionDeniedErr starting the stream; got %v", err)
+	}
+
+	src = &scriptedLive{pages: [][]*reddit.LiveUpdate{liveUpdates("a")}}
+	if _, err := liveThread(src, kill, errs, "thread", time.Millisecond, spread{}); err != nil {
+		t.Fatalf("failed to start live thread stream: %v", err)
+	}
+
+	src.mu.Lock()
+	src.err = reddit.BusyErr
+	src.mu.Unlock()
+
+	select {
+	case err := <-errs:
+		if err != reddit.BusyErr {
+			t.Errorf("wanted BusyErr; got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("wanted poll errors on the errors channel")
+	}
+}
@@ -0,0 +1,115 @@
+package streams
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Stream starts a stream which stops when kill is closed. It is usually a
+// closure over one of the stream functions in this package, e.g.
+//
+//	func(kill <-chan bool) error {
+//		posts, err := streams.Subreddits(bot, kill, errs, "golang")
+//		if err != nil {
+//			return err
+//		}
+//		go handle(posts)
+//		return nil
+//	}
+type Stream func(kill <-chan bool) error
+
+// errManagerStopped is returned for streams added to a stopped manager.
+var errManagerStopped = fmt.Errorf("the stream manager is stopped")
+
+// StreamManager runs named streams, each with its own kill channel, so they
+// can be started and stopped one at a time, e.g. as the subreddits a bot
+// monitors change. The zero value is ready to use, and it is safe to use from
+// many goroutines.
+//
+// A stopped stream closes its channels once it notices the kill signal, which
+// it only checks between polls, so keep reading a removed stream until its
+// channels close.
+type StreamManager struct {
+	mu      sync.Mutex
+	kills   map[string]chan bool
+	stopped bool
+}
+
+// Add starts a stream under the name, stopping the stream already running
+// under it if there is one. If the stream fails to start, the error is
+// returned and nothing runs under the name. The stream is started without
+// holding the manager, so a slow start does not hold up its other calls; the
+// stream already under the name runs until the new one has started.
+func (m *StreamManager) Add(name string, stream Stream) error {
+	m.mu.Lock()
+	stopped := m.stopped
+	m.mu.Unlock()
+	if stopped {
+		return errManagerStopped
+	}
+
+	kill := make(chan bool)
+	err := stream(kill)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		close(kill)
+		m.remove(name)
+		return err
+	}
+
+	if m.stopped {
+		close(kill)
+		return errManagerStopped
+	}
+
+	m.remove(name)
+	if m.kills == nil {
+		m.kills = map[string]chan bool{}
+	}
+	m.kills[name] = kill
+	return nil
+}
+
+// Remove stops the stream running under the name, and returns whether there
+// was one.
+func (m *StreamManager) Remove(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.remove(name)
+}
+
+// Names returns the names of the running streams, in no particular order.
+func (m *StreamManager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.kills))
+	for name := range m.kills {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Stop stops all of the streams. Streams added afterward are refused.
+func (m *StreamManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name := range m.kills {
+		m.remove(name)
+	}
+	m.stopped = true
+}
+
+func (m *StreamManager) remove(name string) bool {
+	kill, ok := m.kills[name]
+	if ok {
+		close(kill)
+		delete(m.kills, name)
+	}
+	return ok
+}
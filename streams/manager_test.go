@@ -0,0 +1,189 @@
+package streams
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// testStream returns a stream of a monitor which always has a post, which
+// marks done when its posts channel closes.
+func testStream(done *sync.WaitGroup) Stream {
+	return func(kill <-chan bool) error {
+		posts, _, _ := stream(
+			&mockMonitor{h: reddit.Harvest{Posts: []*reddit.Post{{}}}},
+			nil,
+			spread{},
+			kill,
+			make(chan error),
+		)
+
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			for range posts {
+			}
+		}()
+		return nil
+	}
+}
+
+func TestStreamManager(t *testing.T) {
+	m := &StreamManager{}
+	done := &sync.WaitGroup{}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("stream%d", i)
+			if err := m.Add(name, testStream(done)); err != nil {
+				t.Errorf("failed to add %s: %v", name, err)
+			}
+			if i%2 == 0 && !m.Remove(name) {
+				t.Errorf("%s was not running to remove", name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if names := m.Names(); len(names) != 5 {
+		t.Errorf("wanted 5 streams running; got %v", names)
+	}
+
+	if m.Remove("stream0") {
+		t.Errorf("removed stream0 twice")
+	}
+
+	m.Stop()
+	stopped := make(chan bool)
+	go func() {
+		done.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("streams did not all stop")
+	}
+
+	if err := m.Add("late", testStream(done)); err != errManagerStopped {
+		t.Errorf("wanted errManagerStopped; got %v", err)
+	}
+}
+
+func TestStreamManagerReplace(t *testing.T) {
+	m := &StreamManager{}
+	defer m.Stop()
+
+	first := &sync.WaitGroup{}
+	if err := m.Add("golang", testStream(first)); err != nil {
+		t.Fatalf("failed to add stream: %v", err)
+	}
+
+	second := &sync.WaitGroup{}
+	if err := m.Add("golang", testStream(second)); err != nil {
+		t.Fatalf("failed to replace stream: %v", err)
+	}
+
+	stopped := make(chan bool)
+	go func() {
+		first.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("replaced stream did not stop")
+	}
+
+	failure := fmt.Errorf("no such subreddit")
+	if err := m.Add("rust", func(<-chan bool) error { return failure }); err != failure {
+		t.Errorf("wanted the stream's error; got %v", err)
+	}
+
+	if names := m.Names(); len(names) != 1 || names[0] != "golang" {
+		t.Errorf("wanted only golang running; got %v", names)
+	}
+}
+
+func TestStreamManagerSlowStart(t *testing.T) {
+	m := &StreamManager{}
+	defer m.Stop()
+
+	starting, release := make(chan bool), make(chan bool)
+	added := make(chan error)
+	go func() {
+		added <- m.Add("slow", func(<-chan bool) error {
+			close(starting)
+			<-release
+			return nil
+		})
+	}()
+	<-starting
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		m.Names()
+		m.Remove("other")
+		if err := m.Add("fast", func(<-chan bool) error { return nil }); err != nil {
+			t.Errorf("failed to add stream: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("a slow stream start held up the manager")
+	}
+
+	close(release)
+	if err := <-added; err != nil {
+		t.Fatalf("failed to add slow stream: %v", err)
+	}
+	if names := m.Names(); len(names) != 2 {
+		t.Errorf("wanted slow and fast running; got %v", names)
+	}
+}
+
+func TestStreamManagerStopWhileStarting(t *testing.T) {
+	m := &StreamManager{}
+
+	starting, release := make(chan bool), make(chan bool)
+	killed := make(chan bool)
+	added := make(chan error)
+	go func() {
+		added <- m.Add("slow", func(kill <-chan bool) error {
+			close(starting)
+			<-release
+			go func() {
+				<-kill
+				close(killed)
+			}()
+			return nil
+		})
+	}()
+	<-starting
+
+	m.Stop()
+	close(release)
+	if err := <-added; err != errManagerStopped {
+		t.Errorf("wanted errManagerStopped; got %v", err)
+	}
+
+	select {
+	case <-killed:
+	case <-time.After(time.Second):
+		t.Errorf("stream started while the manager stopped was not killed")
+	}
+	if names := m.Names(); len(names) != 0 {
+		t.Errorf("wanted nothing running; got %v", names)
+	}
+}
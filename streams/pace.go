@@ -0,0 +1,149 @@
+package streams
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+
+	"github.com/turnage/graw/streams/internal/monitor"
+)
+
+// busyHarvest is the number of new elements in one poll which makes an
+// adaptive stream poll more often.
+const busyHarvest = 25
+
+// AdaptivePolling paces the polls of streams by their activity. A stream which
+// finds many new elements polls sooner, down to Min between polls, and one
+// which finds none waits longer, up to Max.
+//
+// Streams already share the rate limit of their handle, so this mostly frees
+// the intervals of quiet listings for busy ones.
+type AdaptivePolling struct {
+	Min time.Duration
+	Max time.Duration
+
+	// OnInterval, if set, is called with a stream's listing path and its
+	// new interval each time the interval changes.
+	OnInterval func(path string, interval time.Duration)
+}
+
+// PollLimit limits how many of the streams which share it poll Reddit at the
+// same time. Streams over the limit wait for one of the others to finish its
+// poll.
+type PollLimit struct {
+	// slots holds a token for each stream polling.
+	slots chan struct{}
+}
+
+// NewPollLimit returns a limit of max streams polling at once.
+func NewPollLimit(max int) *PollLimit {
+	if max < 1 {
+		max = 1
+	}
+	return &PollLimit{slots: make(chan struct{}, max)}
+}
+
+// randomDuration returns a random duration in [0, max).
+var randomDuration = func(max time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// spread is how a stream spreads its polls out among those of other streams,
+// as its config's Jitter and Polls were set when it started.
+type spread struct {
+	jitter time.Duration
+	random func(max time.Duration) time.Duration
+	// slots limits concurrent polls, if it is not nil.
+	slots chan struct{}
+}
+
+func (c Config) newSpread() spread {
+	s := spread{jitter: c.Jitter, random: randomDuration}
+	if c.Polls != nil {
+		s.slots = c.Polls.slots
+	}
+	return s
+}
+
+// delay returns a random delay up to the jitter.
+func (s spread) delay() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return s.random(s.jitter)
+}
+
+// errKilled is returned by poll when the stream is killed while it waits for
+// a slot.
+var errKilled = fmt.Errorf("stream killed")
+
+// poll updates the monitor, holding one of the slots while it does if polls
+// are limited.
+func (s spread) poll(
+	mon monitor.Monitor,
+	kill <-chan bool,
+) (reddit.Harvest, error) {
+	if s.slots != nil {
+		select {
+		case <-kill:
+			return reddit.Harvest{}, errKilled
+		case s.slots <- struct{}{}:
+		}
+		defer func() { <-s.slots }()
+	}
+
+	return mon.Update()
+}
+
+// pacer tracks the interval between the polls of one stream.
+type pacer struct {
+	path     string
+	cfg      AdaptivePolling
+	interval time.Duration
+}
+
+// newPacer returns a pacer for the stream of the listing at path, or nil if
+// the config does not pace streams.
+func (c Config) newPacer(path string) *pacer {
+	if c.Adaptive == nil {
+		return nil
+	}
+
+	cfg := *c.Adaptive
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+
+	return &pacer{path: path, cfg: cfg, interval: cfg.Min}
+}
+
+// next returns how long to wait before the next poll, given the harvest of the
+// last one.
+func (p *pacer) next(h reddit.Harvest) time.Duration {
+	interval := p.interval
+	switch size := len(h.Posts) + len(h.Comments) + len(h.Messages); {
+	case size == 0:
+		interval *= 2
+		if interval == 0 {
+			interval = time.Second
+		}
+	case size >= busyHarvest:
+		interval /= 2
+	}
+
+	if interval < p.cfg.Min {
+		interval = p.cfg.Min
+	}
+	if interval > p.cfg.Max {
+		interval = p.cfg.Max
+	}
+
+	if interval != p.interval && p.cfg.OnInterval != nil {
+		p.cfg.OnInterval(p.path, interval)
+	}
+
+	p.interval = interval
+	return interval
+}
@@ -0,0 +1,213 @@
+package streams
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func harvestOf(size int) reddit.Harvest {
+	h := reddit.Harvest{}
+	for i := 0; i < size; i++ {
+		h.Posts = append(h.Posts, &reddit.Post{})
+	}
+	return h
+}
+
+func TestPacer(t *testing.T) {
+	intervals := []time.Duration{}
+	p := &pacer{
+		path: "/r/golang/new",
+		cfg: AdaptivePolling{
+			Min: time.Second,
+			Max: 8 * time.Second,
+			OnInterval: func(path string, interval time.Duration) {
+				intervals = append(intervals, interval)
+			},
+		},
+		interval: time.Second,
+	}
+
+	for i, test := range []struct {
+		size     int
+		interval time.Duration
+	}{
+		// A quiet period backs off to the maximum.
+		{0, 2 * time.Second},
+		{0, 4 * time.Second},
+		{0, 8 * time.Second},
+		{0, 8 * time.Second},
+		// Some activity holds the interval.
+		{3, 8 * time.Second},
+		// A busy period speeds up to the minimum.
+		{busyHarvest, 4 * time.Second},
+		{50, 2 * time.Second},
+		{100, time.Second},
+		{100, time.Second},
+	} {
+		if interval := p.next(harvestOf(test.size)); interval != test.interval {
+			t.Errorf("wrong interval on %d; got %v, wanted %v", i, interval, test.interval)
+		}
+	}
+
+	if len(intervals) != 6 {
+		t.Errorf("wanted 6 interval changes reported; got %v", intervals)
+	}
+}
+
+func TestNewPacer(t *testing.T) {
+	if p := (Config{}).newPacer("/r/golang/new"); p != nil {
+		t.Errorf("wanted no pacer without adaptive polling")
+	}
+
+	c := Config{Adaptive: &AdaptivePolling{Min: time.Minute, Max: time.Second}}
+	p := c.newPacer("/r/golang/new")
+	if p == nil || p.interval != time.Minute || p.cfg.Max != time.Minute {
+		t.Errorf("pacer configured incorrectly: %+v", p)
+	}
+}
+
+func TestPacedFlowKill(t *testing.T) {
+	done := make(chan bool)
+	kill := make(chan bool)
+	mon := &mockMonitor{}
+	pace := &pacer{cfg: AdaptivePolling{Min: time.Hour, Max: time.Hour}, interval: time.Hour}
+
+	go func() {
+		flow(
+			mon, pace, spread{}, kill, make(chan error),
+			make(chan *reddit.Post),
+			make(chan *reddit.Comment),
+			make(chan *reddit.Message),
+		)
+		done <- true
+	}()
+
+	kill <- true
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("paced flow did not accept kill while waiting")
+	}
+}
+
+// stopStreams kills streams and waits for them to stop.
+func stopStreams(kill chan bool, feeds []<-chan *reddit.Post) {
+	close(kill)
+	for _, feed := range feeds {
+		for range feed {
+		}
+	}
+}
+
+// timedMonitor records when it is first updated.
+type timedMonitor struct {
+	first chan time.Time
+	once  sync.Once
+}
+
+func (m *timedMonitor) Update() (reddit.Harvest, error) {
+	m.once.Do(func() { m.first <- time.Now() })
+	return reddit.Harvest{}, nil
+}
+
+func TestJitterSpreadsFirstPolls(t *testing.T) {
+	defer func(r func(time.Duration) time.Duration) { randomDuration = r }(randomDuration)
+
+	delays := make(chan time.Duration, 3)
+	for _, d := range []time.Duration{0, 30 * time.Millisecond, 60 * time.Millisecond} {
+		delays <- d
+	}
+	randomDuration = func(max time.Duration) time.Duration {
+		select {
+		case d := <-delays:
+			return d
+		default:
+			return max
+		}
+	}
+
+	kill := make(chan bool)
+	first := make(chan time.Time, 3)
+	feeds := []<-chan *reddit.Post{}
+	c := Config{Jitter: 100 * time.Millisecond}
+	for i := 0; i < 3; i++ {
+		posts, _, _ := stream(
+			&timedMonitor{first: first},
+			nil,
+			c.newSpread(),
+			kill,
+			make(chan error),
+		)
+		feeds = append(feeds, posts)
+	}
+	defer stopStreams(kill, feeds)
+
+	starts := []time.Time{}
+	for len(starts) < 3 {
+		select {
+		case start := <-first:
+			starts = append(starts, start)
+		case <-time.After(time.Second):
+			t.Fatalf("streams did not all poll")
+		}
+	}
+
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 20*time.Millisecond {
+			t.Errorf("first polls %d and %d were %v apart; wanted them spread", i-1, i, gap)
+		}
+	}
+}
+
+// countingMonitor tracks how many of its kind are being updated at once.
+type countingMonitor struct {
+	mu      *sync.Mutex
+	current *int
+	most    *int
+}
+
+func (m *countingMonitor) Update() (reddit.Harvest, error) {
+	m.mu.Lock()
+	*m.current++
+	if *m.current > *m.most {
+		*m.most = *m.current
+	}
+	m.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	m.mu.Lock()
+	*m.current--
+	m.mu.Unlock()
+	return reddit.Harvest{}, nil
+}
+
+func TestPollLimit(t *testing.T) {
+	c := Config{Polls: NewPollLimit(2)}
+
+	mu, current, most := &sync.Mutex{}, 0, 0
+	kill := make(chan bool)
+	feeds := []<-chan *reddit.Post{}
+	for i := 0; i < 6; i++ {
+		posts, _, _ := stream(
+			&countingMonitor{mu: mu, current: &current, most: &most},
+			nil,
+			c.newSpread(),
+			kill,
+			make(chan error),
+		)
+		feeds = append(feeds, posts)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	stopStreams(kill, feeds)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if most != 2 {
+		t.Errorf("at most %d streams polled at once; wanted 2", most)
+	}
+}
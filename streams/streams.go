@@ -36,6 +36,8 @@ package streams
 
 import (
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/turnage/graw/reddit"
 
@@ -43,11 +45,37 @@ import (
 	"github.com/turnage/graw/streams/internal/rsort"
 )
 
+// Config configures streams. The stream functions of this package start their
+// streams with the zero Config, and the methods of Config of the same names
+// start them configured by it, e.g.
+//
+//	streams.Config{OnGap: logGap}.Subreddits(bot, kill, errs, "golang")
+type Config struct {
+	// OnGap, if set, is called whenever a stream finds a full page of new
+	// elements in its listing and fetches the given number of further
+	// pages in one update so none are missed. Busy listings, and bursts of
+	// activity in them, are where this happens.
+	OnGap func(path string, pages int)
+	// Adaptive, if set, makes streams pace their polls. Otherwise streams
+	// poll as often as their handle's rate limit allows.
+	Adaptive *AdaptivePolling
+	// Jitter, if set, delays each stream's first poll, and each wait
+	// between its polls, by a random amount up to Jitter, so streams
+	// started together do not poll together.
+	Jitter time.Duration
+	// Polls, if set, limits how many streams poll Reddit at once, among
+	// all the streams started with it. Streams of one handle usually share
+	// one PollLimit.
+	Polls *PollLimit
+}
+
 // Subreddits returns a stream of new posts from the requested subreddits. This
 // stream monitors the combination listing of all subreddits using Reddit's "+"
 // feature e.g. /r/golang+rust. This will consume one interval of the handle per
 // call, so it is best to gather all the subreddits needed and invoke this
-// function once.
+// function once. If there are too many subreddits to combine into one listing,
+// they are split across several, each consuming an interval. The names are
+// passed to Reddit as they are given, so exclusions such as "all-pics" work.
 //
 // Be aware that these posts are new and will not have comments. If you are
 // interested in comment trees, save their permalinks and fetch them later.
@@ -60,9 +88,41 @@ func Subreddits(
 	<-chan *reddit.Post,
 	error,
 ) {
-	path := "/r/" + strings.Join(subreddits, "+") + "/new"
-	posts, _, _, err := streamFromPath(scanner, kill, errs, path)
-	return posts, err
+	return Config{}.Subreddits(scanner, kill, errs, subreddits...)
+}
+
+// Subreddits starts the stream Subreddits does, configured by c.
+func (c Config) Subreddits(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	subreddits ...string,
+) (
+	<-chan *reddit.Post,
+	error,
+) {
+	paths := reddit.SplitMultiredditNames(subreddits)
+
+	// The monitors are started with their own kill channel, so those
+	// already started can be killed if a later one fails to start. Their
+	// feeds are drained so none is left blocked on a send.
+	local := make(chan bool)
+	feeds := []<-chan *reddit.Post{}
+	for _, path := range paths {
+		posts, _, _, err := c.streamFromPath(scanner, local, errs, path+"/new")
+		if err != nil {
+			close(local)
+			go func() {
+				for range mergePosts(feeds...) {
+				}
+			}()
+			return nil, err
+		}
+		feeds = append(feeds, posts)
+	}
+
+	go forwardKill(kill, local)
+	return mergePosts(feeds...), nil
 }
 
 // CustomFeeds returns a stream of new posts from the requested custom feeds.
@@ -78,9 +138,23 @@ func CustomFeeds(
 ) (
 	<-chan *reddit.Post,
 	error,
+) {
+	return Config{}.CustomFeeds(scanner, kill, errs, user, feeds...)
+}
+
+// CustomFeeds starts the stream CustomFeeds does, configured by c.
+func (c Config) CustomFeeds(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	user string,
+	feeds ...string,
+) (
+	<-chan *reddit.Post,
+	error,
 ) {
 	path := "/user/" + user + "/m/" + strings.Join(feeds, "+") + "/new"
-	posts, _, _, err := streamFromPath(scanner, kill, errs, path)
+	posts, _, _, err := c.streamFromPath(scanner, kill, errs, path)
 	return posts, err
 }
 
@@ -88,7 +162,8 @@ func CustomFeeds(
 // subreddits. This stream monitors the combination listing of all subreddits
 // using Reddit's "+" feature e.g. /r/golang+rust. This will consume one
 // interval of the handle per call, so it is best to gather all the subreddits
-// needed and invoke this function once.
+// needed and invoke this function once. As with Subreddits, too many
+// subreddits are split across several listings.
 //
 // Be aware that these comments are new, and will not have reply trees. If you
 // are interested in comment trees, save the permalinks of their parent posts
@@ -102,9 +177,45 @@ func SubredditComments(
 	<-chan *reddit.Comment,
 	error,
 ) {
-	path := "/r/" + strings.Join(subreddits, "+") + "/comments"
-	_, comments, _, err := streamFromPath(scanner, kill, errs, path)
-	return comments, err
+	return Config{}.SubredditComments(scanner, kill, errs, subreddits...)
+}
+
+// SubredditComments starts the stream SubredditComments does, configured by c.
+func (c Config) SubredditComments(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	subreddits ...string,
+) (
+	<-chan *reddit.Comment,
+	error,
+) {
+	paths := reddit.SplitMultiredditNames(subreddits)
+
+	// As in Subreddits, the monitors already started are killed and
+	// drained if a later one fails to start.
+	local := make(chan bool)
+	feeds := []<-chan *reddit.Comment{}
+	for _, path := range paths {
+		_, comments, _, err := c.streamFromPath(
+			scanner,
+			local,
+			errs,
+			path+"/comments",
+		)
+		if err != nil {
+			close(local)
+			go func() {
+				for range mergeComments(feeds...) {
+				}
+			}()
+			return nil, err
+		}
+		feeds = append(feeds, comments)
+	}
+
+	go forwardKill(kill, local)
+	return mergeComments(feeds...), nil
 }
 
 // User returns a stream of new posts and comments made by a user. Each user
@@ -118,9 +229,23 @@ func User(
 	<-chan *reddit.Post,
 	<-chan *reddit.Comment,
 	error,
+) {
+	return Config{}.User(scanner, kill, errs, user)
+}
+
+// User starts the stream User does, configured by c.
+func (c Config) User(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	user string,
+) (
+	<-chan *reddit.Post,
+	<-chan *reddit.Comment,
+	error,
 ) {
 	path := "/u/" + user
-	posts, comments, _, err := streamFromPath(scanner, kill, errs, path)
+	posts, comments, _, err := c.streamFromPath(scanner, kill, errs, path)
 	return posts, comments, err
 }
 
@@ -134,7 +259,19 @@ func PostReplies(
 	<-chan *reddit.Message,
 	error,
 ) {
-	return inboxStream(bot, kill, errs, "selfreply")
+	return Config{}.PostReplies(bot, kill, errs)
+}
+
+// PostReplies starts the stream PostReplies does, configured by c.
+func (c Config) PostReplies(
+	bot reddit.Bot,
+	kill <-chan bool,
+	errs chan<- error,
+) (
+	<-chan *reddit.Message,
+	error,
+) {
+	return c.inboxStream(bot, kill, errs, "selfreply")
 }
 
 // CommentReplies returns a stream of replies to comments made by the bot's
@@ -147,7 +284,19 @@ func CommentReplies(
 	<-chan *reddit.Message,
 	error,
 ) {
-	return inboxStream(bot, kill, errs, "comments")
+	return Config{}.CommentReplies(bot, kill, errs)
+}
+
+// CommentReplies starts the stream CommentReplies does, configured by c.
+func (c Config) CommentReplies(
+	bot reddit.Bot,
+	kill <-chan bool,
+	errs chan<- error,
+) (
+	<-chan *reddit.Message,
+	error,
+) {
+	return c.inboxStream(bot, kill, errs, "comments")
 }
 
 // Mentions returns a stream of mentions of the bot's username anywhere on
@@ -162,7 +311,19 @@ func Mentions(
 	<-chan *reddit.Message,
 	error,
 ) {
-	return inboxStream(bot, kill, errs, "mentions")
+	return Config{}.Mentions(bot, kill, errs)
+}
+
+// Mentions starts the stream Mentions does, configured by c.
+func (c Config) Mentions(
+	bot reddit.Bot,
+	kill <-chan bool,
+	errs chan<- error,
+) (
+	<-chan *reddit.Message,
+	error,
+) {
+	return c.inboxStream(bot, kill, errs, "mentions")
 }
 
 // Messages returns a stream of messages sent to the bot's inbox. It consumes
@@ -174,11 +335,28 @@ func Messages(
 ) (
 	<-chan *reddit.Message,
 	error,
+) {
+	return Config{}.Messages(bot, kill, errs)
+}
+
+// Messages starts the stream Messages does, configured by c.
+func (c Config) Messages(
+	bot reddit.Bot,
+	kill <-chan bool,
+	errs chan<- error,
+) (
+	<-chan *reddit.Message,
+	error,
 ) {
 	onlyMessages := make(chan *reddit.Message)
 
-	messages, err := inboxStream(bot, kill, errs, "inbox")
+	messages, err := c.inboxStream(bot, kill, errs, "inbox")
+	if err != nil {
+		return nil, err
+	}
+
 	go func() {
+		defer close(onlyMessages)
 		for m := range messages {
 			if !m.WasComment {
 				onlyMessages <- m
@@ -186,10 +364,10 @@ func Messages(
 		}
 	}()
 
-	return onlyMessages, err
+	return onlyMessages, nil
 }
 
-func inboxStream(
+func (c Config) inboxStream(
 	scanner reddit.Scanner,
 	kill <-chan bool,
 	errs chan<- error,
@@ -199,11 +377,11 @@ func inboxStream(
 	error,
 ) {
 	path := "/message/" + subpath
-	_, _, messages, err := streamFromPath(scanner, kill, errs, path)
+	_, _, messages, err := c.streamFromPath(scanner, kill, errs, path)
 	return messages, err
 }
 
-func streamFromPath(
+func (c Config) streamFromPath(
 	scanner reddit.Scanner,
 	kill <-chan bool,
 	errs chan<- error,
@@ -214,27 +392,39 @@ func streamFromPath(
 	<-chan *reddit.Message,
 	error,
 ) {
-	mon, err := monitorFromPath(path, scanner)
+	mon, err := c.monitorFromPath(path, scanner)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	posts, comments, messages := stream(mon, kill, errs)
+	posts, comments, messages := stream(
+		mon,
+		c.newPacer(path),
+		c.newSpread(),
+		kill,
+		errs,
+	)
 	return posts, comments, messages, nil
 }
 
-func monitorFromPath(path string, sc reddit.Scanner) (monitor.Monitor, error) {
+func (c Config) monitorFromPath(
+	path string,
+	sc reddit.Scanner,
+) (monitor.Monitor, error) {
 	return monitor.New(
 		monitor.Config{
 			Path:    path,
 			Scanner: sc,
 			Sorter:  rsort.New(),
+			OnGap:   c.OnGap,
 		},
 	)
 }
 
 func stream(
 	mon monitor.Monitor,
+	pace *pacer,
+	spread spread,
 	kill <-chan bool,
 	errs chan<- error,
 ) (
@@ -246,30 +436,44 @@ func stream(
 	comments := make(chan *reddit.Comment)
 	messages := make(chan *reddit.Message)
 
-	go flow(mon, kill, errs, posts, comments, messages)
+	go flow(mon, pace, spread, kill, errs, posts, comments, messages)
 
 	return posts, comments, messages
 }
 
 func flow(
 	mon monitor.Monitor,
+	pace *pacer,
+	spread spread,
 	kill <-chan bool,
 	errs chan<- error,
 	posts chan<- *reddit.Post,
 	comments chan<- *reddit.Comment,
 	messages chan<- *reddit.Message,
 ) {
+	defer close(posts)
+	defer close(comments)
+	defer close(messages)
+
+	if delay := spread.delay(); delay > 0 {
+		select {
+		case <-kill:
+			return
+		case <-time.After(delay):
+		}
+	}
+
 	for {
 		select {
 		// if the errors channel is closed, the master goroutine is
 		// shutting us down.
 		case <-kill:
-			close(posts)
-			close(comments)
-			close(messages)
 			return
 		default:
-			if h, err := mon.Update(); err != nil {
+			h, err := spread.poll(mon, kill)
+			if err == errKilled {
+				return
+			} else if err != nil {
 				errs <- err
 			} else {
 				// lol no generics
@@ -283,6 +487,81 @@ func flow(
 					messages <- m
 				}
 			}
+
+			wait := spread.delay()
+			if pace != nil && err == nil {
+				wait += pace.next(h)
+			}
+			if wait == 0 {
+				continue
+			}
+
+			select {
+			case <-kill:
+				return
+			case <-time.After(wait):
+			}
 		}
 	}
 }
+
+// forwardKill closes local once kill is closed or sent a value, killing the
+// streams started with local.
+func forwardKill(kill <-chan bool, local chan<- bool) {
+	<-kill
+	close(local)
+}
+
+// mergePosts forwards the posts from all of the feeds into one stream, which
+// closes when they have all closed.
+func mergePosts(feeds ...<-chan *reddit.Post) <-chan *reddit.Post {
+	if len(feeds) == 1 {
+		return feeds[0]
+	}
+
+	merged := make(chan *reddit.Post)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(feeds))
+	for _, feed := range feeds {
+		go func(feed <-chan *reddit.Post) {
+			defer wg.Done()
+			for p := range feed {
+				merged <- p
+			}
+		}(feed)
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
+
+// mergeComments forwards the comments from all of the feeds into one stream,
+// which closes when they have all closed.
+func mergeComments(feeds ...<-chan *reddit.Comment) <-chan *reddit.Comment {
+	if len(feeds) == 1 {
+		return feeds[0]
+	}
+
+	merged := make(chan *reddit.Comment)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(feeds))
+	for _, feed := range feeds {
+		go func(feed <-chan *reddit.Comment) {
+			defer wg.Done()
+			for c := range feed {
+				merged <- c
+			}
+		}(feed)
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
@@ -2,6 +2,7 @@ package streams
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -33,7 +34,7 @@ func TestStream(t *testing.T) {
 		},
 	}
 
-	posts, comments, messages := stream(mon, kill, errs)
+	posts, comments, messages := stream(mon, nil, spread{}, kill, errs)
 
 	done := make(chan bool)
 	wg := &sync.WaitGroup{}
@@ -72,7 +73,7 @@ func TestErrorPropagation(t *testing.T) {
 	messages := make(chan *reddit.Message)
 	mon := &mockMonitor{err: fmt.Errorf("an error")}
 	go func() {
-		flow(mon, kill, errs, posts, comments, messages)
+		flow(mon, nil, spread{}, kill, errs, posts, comments, messages)
 		done <- true
 	}()
 	go func() {
@@ -92,3 +93,99 @@ func TestErrorPropagation(t *testing.T) {
 		t.Errorf("loop did not report error or accept kill")
 	}
 }
+
+func TestMergePosts(t *testing.T) {
+	feeds := []chan *reddit.Post{
+		make(chan *reddit.Post),
+		make(chan *reddit.Post),
+	}
+
+	merged := mergePosts(feeds[0], feeds[1])
+	go func() {
+		feeds[0] <- &reddit.Post{Title: "first"}
+		feeds[1] <- &reddit.Post{Title: "second"}
+		close(feeds[0])
+		close(feeds[1])
+	}()
+
+	titles := map[string]bool{}
+	for p := range merged {
+		titles[p.Title] = true
+	}
+
+	if !titles["first"] || !titles["second"] {
+		t.Errorf("merged stream missed posts; got %v", titles)
+	}
+}
+
+// brokenScanner serves empty listings, counting its requests, except for paths
+// which contain broken, which fail.
+type brokenScanner struct {
+	reddit.Scanner
+	mu    sync.Mutex
+	polls int
+}
+
+func (b *brokenScanner) Listing(path, after string) (reddit.Harvest, error) {
+	if strings.Contains(path, "broken") {
+		return reddit.Harvest{}, fmt.Errorf("no such subreddit")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.polls++
+	return reddit.Harvest{}, nil
+}
+
+func (b *brokenScanner) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.polls
+}
+
+func TestSplitStreamsKilledOnFailure(t *testing.T) {
+	// A listing takes at most 100 subreddits, so the last one is monitored
+	// on its own, after the others have started.
+	subreddits := []string{}
+	for i := 0; i < 100; i++ {
+		subreddits = append(subreddits, fmt.Sprintf("sr%d", i))
+	}
+	subreddits = append(subreddits, "broken")
+
+	for _, start := range []struct {
+		name   string
+		stream func(reddit.Scanner, <-chan bool, chan<- error) error
+	}{
+		{"Subreddits", func(s reddit.Scanner, kill <-chan bool, errs chan<- error) error {
+			_, err := Subreddits(s, kill, errs, subreddits...)
+			return err
+		}},
+		{"SubredditComments", func(s reddit.Scanner, kill <-chan bool, errs chan<- error) error {
+			_, err := SubredditComments(s, kill, errs, subreddits...)
+			return err
+		}},
+	} {
+		scanner := &brokenScanner{}
+		kill := make(chan bool)
+		errs := make(chan error)
+		go func() {
+			for range errs {
+			}
+		}()
+
+		if err := start.stream(scanner, kill, errs); err == nil {
+			t.Fatalf("[%s] wanted error from the broken subreddit", start.name)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		polls := scanner.count()
+		if polls == 0 {
+			t.Errorf("[%s] the first monitor never started", start.name)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if scanner.count() != polls {
+			t.Errorf("[%s] started monitor kept polling after the stream failed", start.name)
+		}
+		close(kill)
+	}
+}